@@ -3,7 +3,6 @@ package main
 import (
 	"context"
 	"crypto/rand"
-	"encoding/hex"
 	"fmt"
 	"log/slog"
 	"os"
@@ -70,15 +69,56 @@ func main() {
 		"version", version,
 		"domain", cfg.Server.Domain,
 		"dns_enabled", cfg.Server.DNS.Enabled,
-		"https_enabled", cfg.Server.HTTPS.Enabled)
+		"https_enabled", cfg.Server.HTTPS.Enabled,
+		"storage_backend", cfg.Storage.Backend)
 
-	// Create ID generator
+	// Create ID generators. Only hook IDs follow the configured format,
+	// interaction IDs stay long enough to never collide within a hook
 	idGenerator := func() string {
-		return generateID()
+		return generateID(cfg.Server.HookID.Alphabet, cfg.Server.HookID.Length)
+	}
+	interactionIDGenerator := func() string {
+		return generateID("hex", 16)
+	}
+
+	// Create context for graceful shutdown
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Create the orphan store for interactions to unknown hooks
+	var orphans *storage.OrphanStore
+	if cfg.Orphans.Enabled {
+		orphans = storage.NewOrphanStore(cfg.Orphans.Retention, cfg.Orphans.MaxSize, cfg.Orphans.MaxMemoryMB*1024*1024)
 	}
 
 	// Create storage manager
-	storageManager := storage.NewMemoryManager(idGenerator)
+	var storageManager storage.Manager
+	switch cfg.Storage.Backend {
+	case "disk":
+		diskManager, err := storage.NewDiskManager(cfg.Storage.Path, cfg.Storage.CompactInterval, idGenerator, logger)
+		if err != nil {
+			logger.Error("failed to open disk storage", "error", err, "path", cfg.Storage.Path)
+			os.Exit(1)
+		}
+		defer func() {
+			if err := diskManager.Close(); err != nil {
+				logger.Error("failed to close disk storage", "error", err)
+			}
+		}()
+		diskManager.SetOrphans(orphans)
+		go diskManager.Start(ctx)
+
+		stats := diskManager.Stats()
+		logger.Info("disk storage loaded",
+			"path", cfg.Storage.Path,
+			"hooks", stats.HooksActive,
+			"interactions", stats.InteractionsTotal)
+		storageManager = diskManager
+	default:
+		memoryManager := storage.NewMemoryManager(idGenerator)
+		memoryManager.SetOrphans(orphans)
+		storageManager = memoryManager
+	}
 
 	// Create ACME provider for DNS-01 challenges
 	acmeProvider := acme.NewProvider(logger)
@@ -86,28 +126,41 @@ func main() {
 	// Create evictor
 	evictor := eviction.NewEvictor(storageManager, cfg.Eviction, logger)
 
-	// Create context for graceful shutdown
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
 	// Start eviction system
 	go evictor.Start(ctx)
 
+	// Start HTTP/HTTPS server
+	httpServer := http.NewServer(
+		cfg.Server,
+		storageManager,
+		evictor,
+		acmeProvider,
+		logger,
+		interactionIDGenerator,
+	)
+
 	// Start DNS server if enabled
 	if cfg.Server.DNS.Enabled {
 		dnsServer, err := dns.NewServer(
-			cfg.Server.Domain,
-			cfg.Server.DNS.Port,
+			cfg.Server,
 			storageManager,
 			acmeProvider,
 			logger,
-			idGenerator,
+			interactionIDGenerator,
 		)
 		if err != nil {
 			logger.Error("failed to create dns server", "error", err)
 			os.Exit(1)
 		}
 
+		// DoH queries are answered by the DNS server, over the HTTPS listener
+		httpServer.SetDoHHandler(dnsServer.HandleDoH)
+		// DoT reuses the certificate obtained for the HTTPS listener
+		dnsServer.SetTLSConfigSource(httpServer.WaitTLSConfig)
+		httpServer.SetDNSMetrics(dnsServer.GetRateLimitMetrics)
+		// Hooks named like a nameserver or static name would never see DNS queries
+		httpServer.SetReservedIDs(dnsServer.IsReservedLabel)
+
 		go func() {
 			if err := dnsServer.Start(ctx); err != nil {
 				logger.Error("dns server error", "error", err)
@@ -116,16 +169,6 @@ func main() {
 		}()
 	}
 
-	// Start HTTP/HTTPS server
-	httpServer := http.NewServer(
-		cfg.Server,
-		storageManager,
-		evictor,
-		acmeProvider,
-		logger,
-		idGenerator,
-	)
-
 	go func() {
 		if err := httpServer.Start(ctx); err != nil {
 			logger.Error("http server error", "error", err)
@@ -179,13 +222,38 @@ func setupLogger(cfg config.ObservabilityConfig) *slog.Logger {
 	return slog.New(handler)
 }
 
-// generateID generates a random alphanumeric ID
-func generateID() string {
-	b := make([]byte, 8)
-	if _, err := rand.Read(b); err != nil {
-		panic(fmt.Sprintf("failed to generate ID: %v", err))
+// idAlphabets maps the server.hook_id.alphabet values to their characters
+var idAlphabets = map[string]string{
+	"hex":    "0123456789abcdef",
+	"base32": "abcdefghijklmnopqrstuvwxyz234567",
+	"base36": "0123456789abcdefghijklmnopqrstuvwxyz",
+}
+
+// generateID generates a random ID of the given length from the named alphabet
+func generateID(alphabet string, length int) string {
+	chars := idAlphabets[alphabet]
+
+	// Reject bytes past the largest multiple of the alphabet size to avoid modulo bias
+	limit := byte(256 - 256%len(chars))
+
+	id := make([]byte, 0, length)
+	buf := make([]byte, length)
+	for len(id) < length {
+		if _, err := rand.Read(buf); err != nil {
+			panic(fmt.Sprintf("failed to generate ID: %v", err))
+		}
+		for _, b := range buf {
+			if limit != 0 && b >= limit {
+				continue
+			}
+			id = append(id, chars[int(b)%len(chars)])
+			if len(id) == length {
+				break
+			}
+		}
 	}
-	return hex.EncodeToString(b)
+
+	return string(id)
 }
 
 // printHelp prints usage information
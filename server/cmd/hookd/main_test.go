@@ -105,7 +105,7 @@ func TestGenerateID(t *testing.T) {
 	// Test multiple IDs for uniqueness
 	ids := make(map[string]bool)
 	for i := 0; i < 100; i++ {
-		id := generateID()
+		id := generateID("hex", 16)
 
 		if id == "" {
 			t.Error("expected non-empty ID")
@@ -135,6 +135,35 @@ func TestGenerateID(t *testing.T) {
 	}
 }
 
+func TestGenerateID_Alphabets(t *testing.T) {
+	tests := []struct {
+		alphabet string
+		length   int
+	}{
+		{"hex", 8},
+		{"base32", 12},
+		{"base36", 30},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.alphabet, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				id := generateID(tt.alphabet, tt.length)
+
+				if len(id) != tt.length {
+					t.Fatalf("expected ID length %d, got %d", tt.length, len(id))
+				}
+
+				for _, c := range id {
+					if !strings.ContainsRune(idAlphabets[tt.alphabet], c) {
+						t.Fatalf("unexpected character %c in %s ID %s", c, tt.alphabet, id)
+					}
+				}
+			}
+		})
+	}
+}
+
 func TestPrintHelp(t *testing.T) {
 	// Capture stdout
 	oldStdout := os.Stdout
@@ -4,39 +4,83 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"net"
+	"strings"
 	"time"
 )
 
 // Config represents the application configuration
 type Config struct {
 	Server        ServerConfig        `mapstructure:"server"`
+	Storage       StorageConfig       `mapstructure:"storage"`
 	Eviction      EvictionConfig      `mapstructure:"eviction"`
+	Orphans       OrphansConfig       `mapstructure:"orphans"`
 	Observability ObservabilityConfig `mapstructure:"observability"`
 }
 
 // ServerConfig holds server-related configuration
 type ServerConfig struct {
-	Domain string      `mapstructure:"domain"`
-	DNS    DNSConfig   `mapstructure:"dns"`
-	HTTP   HTTPConfig  `mapstructure:"http"`
-	HTTPS  HTTPSConfig `mapstructure:"https"`
-	API    APIConfig   `mapstructure:"api"`
+	Domain string       `mapstructure:"domain"`
+	DNS    DNSConfig    `mapstructure:"dns"`
+	HTTP   HTTPConfig   `mapstructure:"http"`
+	HTTPS  HTTPSConfig  `mapstructure:"https"`
+	API    APIConfig    `mapstructure:"api"`
+	HookID HookIDConfig `mapstructure:"hook_id"`
 }
 
 // DNSConfig holds DNS server configuration
 type DNSConfig struct {
+	Enabled     bool            `mapstructure:"enabled"`
+	Bind        string          `mapstructure:"bind"` // empty binds all addresses
+	Port        int             `mapstructure:"port"`
+	PublicIP    string          `mapstructure:"public_ip"`    // IPv4 returned in A answers
+	PublicIPv6  string          `mapstructure:"public_ipv6"`  // IPv6 returned in AAAA answers
+	IPDetection []string        `mapstructure:"ip_detection"` // opt-in strategies tried in order when no public IP is set
+	Nameservers []string        `mapstructure:"nameservers"`  // NS names of the zone, ns1/ns2.<domain> if empty
+	DoT         DoTConfig       `mapstructure:"dot"`
+	RateLimit   RateLimitConfig `mapstructure:"rate_limit"`
+	Records     []StaticRecord  `mapstructure:"records"`   // served before hook handling
+	ZoneFile    string          `mapstructure:"zone_file"` // RFC 1035 zone file, served like records
+}
+
+// StaticRecord is a record published in the zone, e.g. a site verification
+// TXT record or the mail hosts of the domain
+type StaticRecord struct {
+	Name  string `mapstructure:"name"`  // "@" for the apex, relative to the domain unless it ends with a dot
+	Type  string `mapstructure:"type"`  // record type, except SOA and NS which hookd serves itself
+	Value string `mapstructure:"value"` // RDATA in zone file syntax
+	TTL   int    `mapstructure:"ttl"`   // 3600 if zero
+}
+
+// DoTConfig holds DNS-over-TLS listener configuration. The listener shares
+// the DNS bind address and the certificate obtained for HTTPS
+type DoTConfig struct {
 	Enabled bool `mapstructure:"enabled"`
 	Port    int  `mapstructure:"port"`
 }
 
+// RateLimitConfig holds DNS response rate limiting configuration. UDP
+// responses are limited per source prefix and name, the way RRL does
+// on authoritative servers, so hookd cannot be used as a reflector
+type RateLimitConfig struct {
+	Enabled               bool `mapstructure:"enabled"`
+	ResponsesPerSecond    int  `mapstructure:"responses_per_second"`    // answered per prefix and name
+	Slip                  int  `mapstructure:"slip"`                    // every slip-th limited response is sent truncated, 0 drops them all
+	InteractionsPerSecond int  `mapstructure:"interactions_per_second"` // recorded per prefix and name, limited or not
+	IPv4PrefixLength      int  `mapstructure:"ipv4_prefix_length"`
+	IPv6PrefixLength      int  `mapstructure:"ipv6_prefix_length"`
+}
+
 // HTTPConfig holds HTTP server configuration
 type HTTPConfig struct {
-	Port int `mapstructure:"port"`
+	Bind string `mapstructure:"bind"` // empty binds all addresses
+	Port int    `mapstructure:"port"`
 }
 
 // HTTPSConfig holds HTTPS server configuration
 type HTTPSConfig struct {
 	Enabled  bool   `mapstructure:"enabled"`
+	Bind     string `mapstructure:"bind"` // empty binds all addresses
 	Port     int    `mapstructure:"port"`
 	AutoCert bool   `mapstructure:"autocert"`
 	CacheDir string `mapstructure:"cache_dir"`
@@ -44,7 +88,21 @@ type HTTPSConfig struct {
 
 // APIConfig holds API configuration
 type APIConfig struct {
-	AuthToken string `mapstructure:"auth_token"`
+	AuthToken  string `mapstructure:"auth_token"`
+	AdminToken string `mapstructure:"admin_token"` // required by admin endpoints such as /orphans
+}
+
+// HookIDConfig holds the format of generated hook IDs
+type HookIDConfig struct {
+	Length   int    `mapstructure:"length"`
+	Alphabet string `mapstructure:"alphabet"` // hex, base32 or base36
+}
+
+// StorageConfig holds storage backend configuration
+type StorageConfig struct {
+	Backend         string        `mapstructure:"backend"`
+	Path            string        `mapstructure:"path"`
+	CompactInterval time.Duration `mapstructure:"compact_interval"`
 }
 
 // EvictionConfig holds eviction-related configuration
@@ -56,6 +114,14 @@ type EvictionConfig struct {
 	CleanupInterval time.Duration `mapstructure:"cleanup_interval"`
 }
 
+// OrphansConfig holds the catch-all store of interactions for unknown or expired hooks
+type OrphansConfig struct {
+	Enabled     bool          `mapstructure:"enabled"`
+	Retention   time.Duration `mapstructure:"retention"`
+	MaxSize     int           `mapstructure:"max_size"`      // orphans kept, the oldest are dropped first
+	MaxMemoryMB int           `mapstructure:"max_memory_mb"` // estimated memory of the orphans kept, the oldest are dropped first
+}
+
 // ObservabilityConfig holds observability configuration
 type ObservabilityConfig struct {
 	MetricsEnabled bool   `mapstructure:"metrics_enabled"`
@@ -71,6 +137,18 @@ func DefaultConfig() *Config {
 			DNS: DNSConfig{
 				Enabled: true,
 				Port:    53,
+				DoT: DoTConfig{
+					Enabled: false,
+					Port:    853,
+				},
+				RateLimit: RateLimitConfig{
+					Enabled:               true,
+					ResponsesPerSecond:    20,
+					Slip:                  2,
+					InteractionsPerSecond: 100,
+					IPv4PrefixLength:      24,
+					IPv6PrefixLength:      56,
+				},
 			},
 			HTTP: HTTPConfig{
 				Port: 80,
@@ -84,6 +162,15 @@ func DefaultConfig() *Config {
 			API: APIConfig{
 				AuthToken: "",
 			},
+			HookID: HookIDConfig{
+				Length:   16,
+				Alphabet: "hex",
+			},
+		},
+		Storage: StorageConfig{
+			Backend:         "memory",
+			Path:            "/var/lib/hookd/data",
+			CompactInterval: 5 * time.Minute,
 		},
 		Eviction: EvictionConfig{
 			InteractionTTL:  1 * time.Hour,
@@ -92,6 +179,12 @@ func DefaultConfig() *Config {
 			MaxMemoryMB:     1800,
 			CleanupInterval: 10 * time.Second,
 		},
+		Orphans: OrphansConfig{
+			Enabled:     false,
+			Retention:   24 * time.Hour,
+			MaxSize:     10000,
+			MaxMemoryMB: 64,
+		},
 		Observability: ObservabilityConfig{
 			MetricsEnabled: true,
 			LogLevel:       "info",
@@ -106,10 +199,82 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("server.domain is required")
 	}
 
+	if c.Server.DNS.PublicIP != "" && !isIPv4(c.Server.DNS.PublicIP) {
+		return fmt.Errorf("server.dns.public_ip must be an IPv4 unicast address")
+	}
+
+	if c.Server.DNS.PublicIPv6 != "" && !isIPv6(c.Server.DNS.PublicIPv6) {
+		return fmt.Errorf("server.dns.public_ipv6 must be an IPv6 unicast address")
+	}
+
+	validDetections := map[string]bool{"interface": true, "route": true, "http": true}
+	for _, strategy := range c.Server.DNS.IPDetection {
+		if !validDetections[strategy] {
+			return fmt.Errorf("server.dns.ip_detection entries must be one of: interface, route, http")
+		}
+	}
+
+	for _, ns := range c.Server.DNS.Nameservers {
+		if !isHostname(ns) {
+			return fmt.Errorf("server.dns.nameservers entries must be hostnames")
+		}
+	}
+
+	binds := []struct{ key, addr string }{
+		{"server.dns.bind", c.Server.DNS.Bind},
+		{"server.http.bind", c.Server.HTTP.Bind},
+		{"server.https.bind", c.Server.HTTPS.Bind},
+	}
+	for _, bind := range binds {
+		if bind.addr != "" && net.ParseIP(bind.addr) == nil {
+			return fmt.Errorf("%s must be an IP address", bind.key)
+		}
+	}
+
 	if c.Server.DNS.Enabled && (c.Server.DNS.Port < 1 || c.Server.DNS.Port > 65535) {
 		return fmt.Errorf("server.dns.port must be between 1 and 65535")
 	}
 
+	for _, rec := range c.Server.DNS.Records {
+		if rec.Name == "" || rec.Type == "" || rec.Value == "" {
+			return fmt.Errorf("server.dns.records entries require a name, a type and a value")
+		}
+		if rec.TTL < 0 {
+			return fmt.Errorf("server.dns.records ttl must not be negative")
+		}
+	}
+
+	dot := c.Server.DNS.Enabled && c.Server.DNS.DoT.Enabled
+	if dot && (c.Server.DNS.DoT.Port < 1 || c.Server.DNS.DoT.Port > 65535) {
+		return fmt.Errorf("server.dns.dot.port must be between 1 and 65535")
+	}
+
+	if dot && c.Server.DNS.DoT.Port == c.Server.DNS.Port {
+		return fmt.Errorf("server.dns.dot.port must differ from server.dns.port")
+	}
+
+	if dot && !(c.Server.HTTPS.Enabled && c.Server.HTTPS.AutoCert) {
+		return fmt.Errorf("server.dns.dot requires server.https.enabled and server.https.autocert for its certificate")
+	}
+
+	if rl := c.Server.DNS.RateLimit; c.Server.DNS.Enabled && rl.Enabled {
+		if rl.ResponsesPerSecond <= 0 {
+			return fmt.Errorf("server.dns.rate_limit.responses_per_second must be positive")
+		}
+		if rl.InteractionsPerSecond < rl.ResponsesPerSecond {
+			return fmt.Errorf("server.dns.rate_limit.interactions_per_second must be at least responses_per_second")
+		}
+		if rl.Slip < 0 || rl.Slip > 10 {
+			return fmt.Errorf("server.dns.rate_limit.slip must be between 0 and 10")
+		}
+		if rl.IPv4PrefixLength < 8 || rl.IPv4PrefixLength > 32 {
+			return fmt.Errorf("server.dns.rate_limit.ipv4_prefix_length must be between 8 and 32")
+		}
+		if rl.IPv6PrefixLength < 16 || rl.IPv6PrefixLength > 128 {
+			return fmt.Errorf("server.dns.rate_limit.ipv6_prefix_length must be between 16 and 128")
+		}
+	}
+
 	if c.Server.HTTP.Port < 1 || c.Server.HTTP.Port > 65535 {
 		return fmt.Errorf("server.http.port must be between 1 and 65535")
 	}
@@ -122,6 +287,29 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("server.https.cache_dir is required when autocert is enabled")
 	}
 
+	validAlphabets := map[string]bool{"hex": true, "base32": true, "base36": true}
+	if !validAlphabets[c.Server.HookID.Alphabet] {
+		return fmt.Errorf("server.hook_id.alphabet must be one of: hex, base32, base36")
+	}
+
+	// Leaves room for a 32 character prefix within a 63 character DNS label
+	if c.Server.HookID.Length < 6 || c.Server.HookID.Length > 30 {
+		return fmt.Errorf("server.hook_id.length must be between 6 and 30")
+	}
+
+	validBackends := map[string]bool{"memory": true, "disk": true}
+	if !validBackends[c.Storage.Backend] {
+		return fmt.Errorf("storage.backend must be one of: memory, disk")
+	}
+
+	if c.Storage.Backend == "disk" && c.Storage.Path == "" {
+		return fmt.Errorf("storage.path is required when backend is disk")
+	}
+
+	if c.Storage.Backend == "disk" && c.Storage.CompactInterval <= 0 {
+		return fmt.Errorf("storage.compact_interval must be positive")
+	}
+
 	if c.Eviction.InteractionTTL <= 0 {
 		return fmt.Errorf("eviction.interaction_ttl must be positive")
 	}
@@ -142,6 +330,26 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("eviction.cleanup_interval must be positive")
 	}
 
+	if c.Orphans.Enabled && c.Orphans.Retention <= 0 {
+		return fmt.Errorf("orphans.retention must be positive")
+	}
+
+	if c.Orphans.Enabled && c.Orphans.MaxSize <= 0 {
+		return fmt.Errorf("orphans.max_size must be positive")
+	}
+
+	if c.Orphans.Enabled && c.Orphans.MaxMemoryMB <= 0 {
+		return fmt.Errorf("orphans.max_memory_mb must be positive")
+	}
+
+	if c.Orphans.Enabled && c.Server.API.AdminToken == "" {
+		return fmt.Errorf("server.api.admin_token is required when orphans are enabled")
+	}
+
+	if c.Server.API.AdminToken != "" && c.Server.API.AdminToken == c.Server.API.AuthToken {
+		return fmt.Errorf("server.api.admin_token must differ from server.api.auth_token")
+	}
+
 	validLogLevels := map[string]bool{"debug": true, "info": true, "warn": true, "error": true}
 	if !validLogLevels[c.Observability.LogLevel] {
 		return fmt.Errorf("observability.log_level must be one of: debug, info, warn, error")
@@ -155,6 +363,45 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// isIPv4 reports whether s is a literal IPv4 address usable in an A answer
+func isIPv4(s string) bool {
+	ip := net.ParseIP(s)
+	return ip != nil && ip.To4() != nil && isUnicast(ip)
+}
+
+// isIPv6 reports whether s is a literal IPv6 address usable in an AAAA answer,
+// excluding IPv4-mapped forms
+func isIPv6(s string) bool {
+	ip := net.ParseIP(s)
+	return ip != nil && ip.To4() == nil && isUnicast(ip)
+}
+
+// isHostname reports whether s is a fully qualified hostname with at least
+// two labels, with or without the trailing dot
+func isHostname(s string) bool {
+	labels := strings.Split(strings.TrimSuffix(s, "."), ".")
+	if len(labels) < 2 {
+		return false
+	}
+	for _, label := range labels {
+		if len(label) == 0 || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, c := range strings.ToLower(label) {
+			if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isUnicast rejects addresses no client could ever connect back to. Loopback
+// and private addresses stay allowed for local and offline testing
+func isUnicast(ip net.IP) bool {
+	return !ip.IsUnspecified() && !ip.IsMulticast() && !ip.IsLinkLocalUnicast() && !ip.Equal(net.IPv4bcast)
+}
+
 // EnsureAuthToken ensures an auth token exists, generating one if needed
 func (c *Config) EnsureAuthToken() (string, bool) {
 	if c.Server.API.AuthToken != "" {
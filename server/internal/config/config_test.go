@@ -27,6 +27,10 @@ func TestDefaultConfig(t *testing.T) {
 	if cfg.Eviction.MaxPerHook != 1000 {
 		t.Errorf("expected default max_per_hook 1000, got %d", cfg.Eviction.MaxPerHook)
 	}
+
+	if cfg.Storage.Backend != "memory" {
+		t.Errorf("expected default storage backend memory, got %s", cfg.Storage.Backend)
+	}
 }
 
 func TestConfig_Validate(t *testing.T) {
@@ -98,6 +102,273 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "dot with autocert",
+			modify: func(c *Config) {
+				c.Server.HTTPS.Enabled = true
+				c.Server.HTTPS.AutoCert = true
+				c.Server.DNS.DoT.Enabled = true
+			},
+			wantErr: false,
+		},
+		{
+			name: "dot without https certificate",
+			modify: func(c *Config) {
+				c.Server.DNS.DoT.Enabled = true
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid dot port",
+			modify: func(c *Config) {
+				c.Server.HTTPS.Enabled = true
+				c.Server.HTTPS.AutoCert = true
+				c.Server.DNS.DoT.Enabled = true
+				c.Server.DNS.DoT.Port = 0
+			},
+			wantErr: true,
+		},
+		{
+			name: "dot port same as dns port",
+			modify: func(c *Config) {
+				c.Server.HTTPS.Enabled = true
+				c.Server.HTTPS.AutoCert = true
+				c.Server.DNS.DoT.Enabled = true
+				c.Server.DNS.DoT.Port = c.Server.DNS.Port
+			},
+			wantErr: true,
+		},
+		{
+			name: "zero rate limit responses",
+			modify: func(c *Config) {
+				c.Server.DNS.RateLimit.ResponsesPerSecond = 0
+			},
+			wantErr: true,
+		},
+		{
+			name: "rate limit interactions below responses",
+			modify: func(c *Config) {
+				c.Server.DNS.RateLimit.InteractionsPerSecond = c.Server.DNS.RateLimit.ResponsesPerSecond - 1
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid rate limit slip",
+			modify: func(c *Config) {
+				c.Server.DNS.RateLimit.Slip = 11
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid rate limit prefix length",
+			modify: func(c *Config) {
+				c.Server.DNS.RateLimit.IPv6PrefixLength = 8
+			},
+			wantErr: true,
+		},
+		{
+			name: "rate limit disabled ignores thresholds",
+			modify: func(c *Config) {
+				c.Server.DNS.RateLimit.Enabled = false
+				c.Server.DNS.RateLimit.ResponsesPerSecond = 0
+			},
+			wantErr: false,
+		},
+		{
+			name: "static record",
+			modify: func(c *Config) {
+				c.Server.DNS.Records = []StaticRecord{{Name: "@", Type: "TXT", Value: "site-verification=abc"}}
+			},
+			wantErr: false,
+		},
+		{
+			name: "static record without value",
+			modify: func(c *Config) {
+				c.Server.DNS.Records = []StaticRecord{{Name: "www", Type: "A"}}
+			},
+			wantErr: true,
+		},
+		{
+			name: "static record with negative ttl",
+			modify: func(c *Config) {
+				c.Server.DNS.Records = []StaticRecord{{Name: "www", Type: "A", Value: "192.0.2.1", TTL: -1}}
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid storage backend",
+			modify: func(c *Config) {
+				c.Storage.Backend = "redis"
+			},
+			wantErr: true,
+		},
+		{
+			name: "disk backend without path",
+			modify: func(c *Config) {
+				c.Storage.Backend = "disk"
+				c.Storage.Path = ""
+			},
+			wantErr: true,
+		},
+		{
+			name: "disk backend",
+			modify: func(c *Config) {
+				c.Storage.Backend = "disk"
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid hook ID alphabet",
+			modify: func(c *Config) {
+				c.Server.HookID.Alphabet = "base64"
+			},
+			wantErr: true,
+		},
+		{
+			name: "hook ID too short",
+			modify: func(c *Config) {
+				c.Server.HookID.Length = 4
+			},
+			wantErr: true,
+		},
+		{
+			name: "hook ID too long",
+			modify: func(c *Config) {
+				c.Server.HookID.Length = 31
+			},
+			wantErr: true,
+		},
+		{
+			name: "base32 hook IDs",
+			modify: func(c *Config) {
+				c.Server.HookID.Alphabet = "base32"
+				c.Server.HookID.Length = 12
+			},
+			wantErr: false,
+		},
+		{
+			name: "public IPs and bind addresses",
+			modify: func(c *Config) {
+				c.Server.DNS.PublicIP = "203.0.113.10"
+				c.Server.DNS.PublicIPv6 = "2001:db8::10"
+				c.Server.DNS.Bind = "::"
+				c.Server.HTTP.Bind = "0.0.0.0"
+				c.Server.HTTPS.Bind = "2001:db8::10"
+			},
+			wantErr: false,
+		},
+		{
+			name: "IPv6 as public IPv4",
+			modify: func(c *Config) {
+				c.Server.DNS.PublicIP = "2001:db8::10"
+			},
+			wantErr: true,
+		},
+		{
+			name: "IPv4 as public IPv6",
+			modify: func(c *Config) {
+				c.Server.DNS.PublicIPv6 = "203.0.113.10"
+			},
+			wantErr: true,
+		},
+		{
+			name: "unspecified public IP",
+			modify: func(c *Config) {
+				c.Server.DNS.PublicIP = "0.0.0.0"
+			},
+			wantErr: true,
+		},
+		{
+			name: "link-local public IPv6",
+			modify: func(c *Config) {
+				c.Server.DNS.PublicIPv6 = "fe80::1"
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown IP detection strategy",
+			modify: func(c *Config) {
+				c.Server.DNS.IPDetection = []string{"route", "stun"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "IP detection strategies",
+			modify: func(c *Config) {
+				c.Server.DNS.IPDetection = []string{"interface", "http"}
+			},
+			wantErr: false,
+		},
+		{
+			name: "public IP with detection disabled",
+			modify: func(c *Config) {
+				c.Server.DNS.PublicIP = "203.0.113.10"
+				c.Server.DNS.IPDetection = nil
+			},
+			wantErr: false,
+		},
+		{
+			name: "custom nameservers",
+			modify: func(c *Config) {
+				c.Server.DNS.Nameservers = []string{"ns1.example.net", "ns2.example.net."}
+			},
+			wantErr: false,
+		},
+		{
+			name: "single label nameserver",
+			modify: func(c *Config) {
+				c.Server.DNS.Nameservers = []string{"ns1"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "hostname as bind address",
+			modify: func(c *Config) {
+				c.Server.HTTP.Bind = "localhost"
+			},
+			wantErr: true,
+		},
+		{
+			name: "orphans with admin token",
+			modify: func(c *Config) {
+				c.Orphans.Enabled = true
+				c.Server.API.AdminToken = "admin-token"
+			},
+			wantErr: false,
+		},
+		{
+			name: "orphans without admin token",
+			modify: func(c *Config) {
+				c.Orphans.Enabled = true
+			},
+			wantErr: true,
+		},
+		{
+			name: "orphans without size limit",
+			modify: func(c *Config) {
+				c.Orphans.Enabled = true
+				c.Orphans.MaxSize = 0
+				c.Server.API.AdminToken = "admin-token"
+			},
+			wantErr: true,
+		},
+		{
+			name: "orphans without memory limit",
+			modify: func(c *Config) {
+				c.Orphans.Enabled = true
+				c.Orphans.MaxMemoryMB = 0
+				c.Server.API.AdminToken = "admin-token"
+			},
+			wantErr: true,
+		},
+		{
+			name: "admin token reusing auth token",
+			modify: func(c *Config) {
+				c.Server.API.AuthToken = "same-token"
+				c.Server.API.AdminToken = "same-token"
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,79 @@
+package dns
+
+import (
+	"net"
+	"strconv"
+
+	"github.com/miekg/dns"
+)
+
+// captureQuery records the details of a query in an interaction. The resolver
+// fingerprint (flags, EDNS0 buffer size) and the EDNS Client Subnet often
+// reveal the network of the client behind a public resolver
+func captureQuery(data map[string]interface{}, w dns.ResponseWriter, r *dns.Msg) {
+	q := r.Question[0]
+
+	data["qclass"] = dns.Class(q.Qclass).String()
+	data["message_id"] = int(r.Id)
+	data["rd"] = r.RecursionDesired
+	data["cd"] = r.CheckingDisabled
+	data["do"] = false
+	data["transport"] = transport(w)
+	if dw, ok := w.(*dohResponseWriter); ok {
+		captureDoH(data, dw.request)
+	}
+
+	if _, port, err := net.SplitHostPort(w.RemoteAddr().String()); err == nil {
+		if n, err := strconv.Atoi(port); err == nil {
+			data["source_port"] = n
+		}
+	}
+
+	opt := r.IsEdns0()
+	if opt == nil {
+		return
+	}
+
+	data["do"] = opt.Do()
+	data["edns_udp_size"] = int(opt.UDPSize())
+
+	for _, option := range opt.Option {
+		if ecs, ok := option.(*dns.EDNS0_SUBNET); ok {
+			if subnet := clientSubnet(ecs); subnet != "" {
+				data["client_subnet"] = subnet
+			}
+		}
+	}
+}
+
+// captureResponse records the response sent for a query in an interaction
+func captureResponse(data map[string]interface{}, m *dns.Msg) {
+	answers := make([]string, 0, len(m.Answer))
+	for _, rr := range m.Answer {
+		answers = append(answers, rr.String())
+	}
+
+	data["rcode"] = dns.RcodeToString[m.Rcode]
+	data["answers"] = answers
+	if m.Truncated {
+		data["truncated"] = true
+	}
+}
+
+// clientSubnet formats an EDNS Client Subnet option (RFC 7871) as a CIDR
+// prefix, or returns "" when the option is malformed
+func clientSubnet(ecs *dns.EDNS0_SUBNET) string {
+	bits := 32
+	ip := ecs.Address.To4()
+	if ecs.Family == 2 {
+		bits, ip = 128, ecs.Address.To16()
+	}
+
+	if ip == nil || int(ecs.SourceNetmask) > bits {
+		return ""
+	}
+
+	mask := net.CIDRMask(int(ecs.SourceNetmask), bits)
+	subnet := net.IPNet{IP: ip.Mask(mask), Mask: mask}
+	return subnet.String()
+}
@@ -0,0 +1,119 @@
+package dns
+
+import (
+	"log/slog"
+	"net"
+	"testing"
+
+	"github.com/miekg/dns"
+
+	"github.com/jomar/hookd/internal/acme"
+	"github.com/jomar/hookd/internal/storage"
+)
+
+func TestServer_HandleDNSRequest_CaptureDetails(t *testing.T) {
+	idGen := func() string { return "abc123" }
+	manager := storage.NewMemoryManager(idGen)
+	acmeProvider := acme.NewProvider(slog.Default())
+	logger := slog.Default()
+
+	server, _ := NewServer(testConfig(5353), manager, acmeProvider, logger, func() string { return "int-id" })
+	hook := manager.CreateHook("example.com")
+
+	t.Run("edns with client subnet", func(t *testing.T) {
+		m := new(dns.Msg)
+		m.SetQuestion("abc123.example.com.", dns.TypeA)
+		m.Id = 4242
+		m.RecursionDesired = true
+		m.CheckingDisabled = true
+		m.SetEdns0(4096, true)
+		m.IsEdns0().Option = append(m.IsEdns0().Option, &dns.EDNS0_SUBNET{
+			Code:          dns.EDNS0SUBNET,
+			Family:        1,
+			SourceNetmask: 24,
+			Address:       net.ParseIP("198.51.100.77"),
+		})
+
+		w := &mockResponseWriter{remoteAddr: &net.UDPAddr{IP: net.ParseIP("1.2.3.4"), Port: 40000}}
+		server.handleDNSRequest(w, m)
+
+		interactions, _ := manager.PollInteractions(hook.ID)
+		if len(interactions) != 1 {
+			t.Fatalf("expected 1 interaction, got %d", len(interactions))
+		}
+
+		data := interactions[0].Data
+		expected := map[string]interface{}{
+			"qclass":        "IN",
+			"message_id":    4242,
+			"rd":            true,
+			"cd":            true,
+			"do":            true,
+			"edns_udp_size": 4096,
+			"client_subnet": "198.51.100.0/24",
+			"source_port":   40000,
+			"transport":     "udp",
+			"rcode":         "NOERROR",
+		}
+		for key, want := range expected {
+			if data[key] != want {
+				t.Errorf("expected %s = %v, got %v", key, want, data[key])
+			}
+		}
+
+		answers, _ := data["answers"].([]string)
+		if len(answers) != 1 || answers[0] != w.msg.Answer[0].String() {
+			t.Errorf("expected the answer sent to be recorded, got %v", data["answers"])
+		}
+	})
+
+	t.Run("plain query", func(t *testing.T) {
+		m := new(dns.Msg)
+		m.SetQuestion("_dmarc.abc123.example.com.", dns.TypeTXT)
+		m.RecursionDesired = false
+
+		w := &mockResponseWriter{remoteAddr: &net.TCPAddr{IP: net.ParseIP("1.2.3.4"), Port: 53000}}
+		server.handleDNSRequest(w, m)
+
+		interactions, _ := manager.PollInteractions(hook.ID)
+		if len(interactions) != 1 {
+			t.Fatalf("expected 1 interaction, got %d", len(interactions))
+		}
+
+		data := interactions[0].Data
+		if data["rd"] != false || data["do"] != false || data["transport"] != "tcp" {
+			t.Errorf("unexpected flags: %v", data)
+		}
+		if _, ok := data["edns_udp_size"]; ok {
+			t.Error("expected no edns_udp_size without EDNS0")
+		}
+		if _, ok := data["client_subnet"]; ok {
+			t.Error("expected no client_subnet without EDNS0")
+		}
+		if answers, _ := data["answers"].([]string); len(answers) != 1 {
+			t.Errorf("expected 1 recorded answer, got %v", data["answers"])
+		}
+	})
+}
+
+func TestClientSubnet(t *testing.T) {
+	tests := []struct {
+		name string
+		ecs  dns.EDNS0_SUBNET
+		want string
+	}{
+		{"ipv4", dns.EDNS0_SUBNET{Family: 1, SourceNetmask: 24, Address: net.ParseIP("198.51.100.77")}, "198.51.100.0/24"},
+		{"ipv6", dns.EDNS0_SUBNET{Family: 2, SourceNetmask: 56, Address: net.ParseIP("2001:db8:aa:bbcc::1")}, "2001:db8:aa:bb00::/56"},
+		{"zero prefix", dns.EDNS0_SUBNET{Family: 1, SourceNetmask: 0, Address: net.ParseIP("0.0.0.0")}, "0.0.0.0/0"},
+		{"prefix too long", dns.EDNS0_SUBNET{Family: 1, SourceNetmask: 33, Address: net.ParseIP("198.51.100.77")}, ""},
+		{"missing address", dns.EDNS0_SUBNET{Family: 1, SourceNetmask: 24}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := clientSubnet(&tt.ecs); got != tt.want {
+				t.Errorf("clientSubnet() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ipEchoURL returns the caller's address as plain text over both IPv4 and IPv6
+const ipEchoURL = "https://icanhazip.com"
+
+// detectTimeout bounds each detection strategy so startup never hangs
+const detectTimeout = 5 * time.Second
+
+// ipDetector finds an address of the given family ("ip4" or "ip6")
+type ipDetector func(ctx context.Context, family string) (string, error)
+
+// ipDetectors maps the server.dns.ip_detection values to their implementation
+var ipDetectors = map[string]ipDetector{
+	"interface": detectInterfaceIP,
+	"route":     detectRouteIP,
+	"http": func(ctx context.Context, family string) (string, error) {
+		return detectHTTPIP(ctx, family, ipEchoURL)
+	},
+}
+
+// detectPublicIP tries each strategy in order and returns the first address
+// found along with the strategy that found it
+func detectPublicIP(ctx context.Context, family string, strategies []string) (string, string, error) {
+	var errs []string
+	for _, strategy := range strategies {
+		detect, ok := ipDetectors[strategy]
+		if !ok {
+			errs = append(errs, fmt.Sprintf("%s: unknown strategy", strategy))
+			continue
+		}
+
+		detectCtx, cancel := context.WithTimeout(ctx, detectTimeout)
+		ip, err := detect(detectCtx, family)
+		cancel()
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", strategy, err))
+			continue
+		}
+		return ip, strategy, nil
+	}
+
+	if len(errs) == 0 {
+		return "", "", fmt.Errorf("no detection strategy configured")
+	}
+	return "", "", fmt.Errorf("%s", strings.Join(errs, "; "))
+}
+
+// detectInterfaceIP returns the first public address assigned to a local
+// interface. It finds nothing behind NAT or on cloud VMs with elastic IPs
+func detectInterfaceIP(_ context.Context, family string) (string, error) {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return "", err
+	}
+
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || !matchesFamily(ipNet.IP, family) {
+			continue
+		}
+		if ipNet.IP.IsGlobalUnicast() && !ipNet.IP.IsPrivate() {
+			return ipNet.IP.String(), nil
+		}
+	}
+
+	return "", fmt.Errorf("no public %s address on local interfaces", family)
+}
+
+// detectRouteIP returns the source address of the default route. No packets
+// are sent, but the result is a private address behind NAT
+func detectRouteIP(ctx context.Context, family string) (string, error) {
+	network, target := "udp4", "8.8.8.8:80"
+	if family == "ip6" {
+		network, target = "udp6", "[2001:4860:4860::8888]:80"
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, network, target)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	localAddr := conn.LocalAddr().(*net.UDPAddr)
+	return localAddr.IP.String(), nil
+}
+
+// detectHTTPIP asks an external echo service which address our requests come
+// from, forcing the connection over the requested family
+func detectHTTPIP(ctx context.Context, family, url string) (string, error) {
+	network := "tcp4"
+	if family == "ip6" {
+		network = "tcp6"
+	}
+
+	var dialer net.Dialer
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, addr string) (net.Conn, error) {
+				return dialer.DialContext(ctx, network, addr)
+			},
+		},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %d from %s", resp.StatusCode, url)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64))
+	if err != nil {
+		return "", err
+	}
+
+	ip := net.ParseIP(strings.TrimSpace(string(body)))
+	if ip == nil || !matchesFamily(ip, family) {
+		return "", fmt.Errorf("invalid %s address from %s: %q", family, url, strings.TrimSpace(string(body)))
+	}
+
+	return ip.String(), nil
+}
+
+// matchesFamily reports whether ip belongs to family ("ip4" or "ip6")
+func matchesFamily(ip net.IP, family string) bool {
+	if family == "ip6" {
+		return ip.To4() == nil
+	}
+	return ip.To4() != nil
+}
@@ -0,0 +1,142 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDetectPublicIP(t *testing.T) {
+	original := ipDetectors
+	defer func() { ipDetectors = original }()
+
+	ipDetectors = map[string]ipDetector{
+		"failing": func(context.Context, string) (string, error) {
+			return "", errors.New("unavailable")
+		},
+		"fixed": func(context.Context, string) (string, error) {
+			return "203.0.113.10", nil
+		},
+	}
+
+	t.Run("first working strategy wins", func(t *testing.T) {
+		ip, strategy, err := detectPublicIP(context.Background(), "ip4", []string{"failing", "fixed"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if ip != "203.0.113.10" {
+			t.Errorf("expected IP 203.0.113.10, got %s", ip)
+		}
+
+		if strategy != "fixed" {
+			t.Errorf("expected strategy fixed, got %s", strategy)
+		}
+	})
+
+	t.Run("all strategies fail", func(t *testing.T) {
+		if _, _, err := detectPublicIP(context.Background(), "ip4", []string{"failing", "unknown"}); err == nil {
+			t.Error("expected error when every strategy fails")
+		}
+	})
+
+	t.Run("no strategies", func(t *testing.T) {
+		if _, _, err := detectPublicIP(context.Background(), "ip4", nil); err == nil {
+			t.Error("expected error without strategies")
+		}
+	})
+}
+
+func TestDetectRouteIP(t *testing.T) {
+	ip, err := detectRouteIP(context.Background(), "ip4")
+	if err != nil {
+		t.Skipf("no ipv4 default route: %v", err)
+	}
+
+	parsed := net.ParseIP(ip)
+	if parsed == nil || parsed.To4() == nil {
+		t.Errorf("expected IPv4 address, got %s", ip)
+	}
+}
+
+func TestDetectHTTPIP(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		status  int
+		family  string
+		want    string
+		wantErr bool
+	}{
+		{"ipv4 with newline", "203.0.113.10\n", http.StatusOK, "ip4", "203.0.113.10", false},
+		{"wrong family", "2001:db8::10\n", http.StatusOK, "ip4", "", true},
+		{"not an address", "<html>", http.StatusOK, "ip4", "", true},
+		{"error status", "203.0.113.10", http.StatusServiceUnavailable, "ip4", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			ip, err := detectHTTPIP(context.Background(), tt.family, server.URL)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("detectHTTPIP() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if ip != tt.want {
+				t.Errorf("expected IP %q, got %q", tt.want, ip)
+			}
+		})
+	}
+}
+
+func TestMatchesFamily(t *testing.T) {
+	tests := []struct {
+		ip     string
+		family string
+		want   bool
+	}{
+		{"203.0.113.10", "ip4", true},
+		{"203.0.113.10", "ip6", false},
+		{"2001:db8::10", "ip6", true},
+		{"2001:db8::10", "ip4", false},
+	}
+
+	for _, tt := range tests {
+		if got := matchesFamily(net.ParseIP(tt.ip), tt.family); got != tt.want {
+			t.Errorf("matchesFamily(%s, %s) = %v, want %v", tt.ip, tt.family, got, tt.want)
+		}
+	}
+}
+
+func TestResolvePublicIP(t *testing.T) {
+	original := ipDetectors
+	defer func() { ipDetectors = original }()
+
+	ipDetectors = map[string]ipDetector{
+		"private": func(context.Context, string) (string, error) {
+			return "10.0.0.5", nil
+		},
+	}
+
+	t.Run("configured private address is kept", func(t *testing.T) {
+		ip, err := resolvePublicIP("127.0.0.1", "ip4", nil, slog.Default())
+		if err != nil || ip != "127.0.0.1" {
+			t.Errorf("expected the configured address, got %q %v", ip, err)
+		}
+	})
+
+	t.Run("detected private address is rejected", func(t *testing.T) {
+		if ip, err := resolvePublicIP("", "ip4", []string{"private"}, slog.Default()); err == nil {
+			t.Errorf("expected an error, got %s", ip)
+		}
+	})
+}
@@ -0,0 +1,162 @@
+package dns
+
+import (
+	"encoding/base64"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// dohContentType is the media type of DNS messages carried over HTTPS (RFC 8484)
+const dohContentType = "application/dns-message"
+
+// dohResponseWriter adapts an HTTP exchange to dns.ResponseWriter so DoH
+// queries go through the same handler as UDP and TCP ones
+type dohResponseWriter struct {
+	request    *http.Request
+	localAddr  net.Addr
+	remoteAddr net.Addr
+	msg        *dns.Msg
+}
+
+func newDoHResponseWriter(r *http.Request) *dohResponseWriter {
+	w := &dohResponseWriter{
+		request:    r,
+		localAddr:  &net.TCPAddr{},
+		remoteAddr: &net.TCPAddr{},
+	}
+
+	if addr, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr); ok {
+		w.localAddr = addr
+	}
+	if addr, err := net.ResolveTCPAddr("tcp", r.RemoteAddr); err == nil {
+		w.remoteAddr = addr
+	}
+
+	return w
+}
+
+func (w *dohResponseWriter) LocalAddr() net.Addr  { return w.localAddr }
+func (w *dohResponseWriter) RemoteAddr() net.Addr { return w.remoteAddr }
+
+// WriteMsg keeps the reply, it is packed into the HTTP response afterwards
+func (w *dohResponseWriter) WriteMsg(m *dns.Msg) error {
+	w.msg = m
+	return nil
+}
+
+func (w *dohResponseWriter) Write(b []byte) (int, error) {
+	m := new(dns.Msg)
+	if err := m.Unpack(b); err != nil {
+		return 0, err
+	}
+	w.msg = m
+	return len(b), nil
+}
+
+func (w *dohResponseWriter) Close() error        { return nil }
+func (w *dohResponseWriter) TsigStatus() error   { return nil }
+func (w *dohResponseWriter) TsigTimersOnly(bool) {}
+func (w *dohResponseWriter) Hijack()             {}
+
+// HandleDoH answers DNS-over-HTTPS queries (RFC 8484), GET with the message
+// in the dns parameter or POST with it as the body. Queries are answered and
+// recorded like UDP and TCP ones, with the HTTP client details added
+func (s *Server) HandleDoH(w http.ResponseWriter, r *http.Request) {
+	var (
+		wire []byte
+		err  error
+	)
+
+	switch r.Method {
+	case http.MethodGet:
+		param := r.URL.Query().Get("dns")
+		if param == "" {
+			http.Error(w, "Missing dns parameter", http.StatusBadRequest)
+			return
+		}
+		// The parameter is unpadded base64url, tolerate padding anyway
+		wire, err = base64.RawURLEncoding.DecodeString(strings.TrimRight(param, "="))
+	case http.MethodPost:
+		if r.Header.Get("Content-Type") != dohContentType {
+			http.Error(w, "Unsupported media type", http.StatusUnsupportedMediaType)
+			return
+		}
+		wire, err = io.ReadAll(io.LimitReader(r.Body, dns.MaxMsgSize+1))
+		if err == nil && len(wire) > dns.MaxMsgSize {
+			http.Error(w, "DNS message too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	req := new(dns.Msg)
+	if err == nil {
+		err = req.Unpack(wire)
+	}
+	if err != nil {
+		http.Error(w, "Invalid DNS message", http.StatusBadRequest)
+		return
+	}
+
+	dw := newDoHResponseWriter(r)
+	s.handleDNSRequest(dw, req)
+	if dw.msg == nil {
+		http.Error(w, "No DNS response", http.StatusInternalServerError)
+		return
+	}
+
+	packed, err := dw.msg.Pack()
+	if err != nil {
+		s.logger.Error("failed to pack doh response", "error", err)
+		http.Error(w, "Failed to pack DNS response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", dohContentType)
+	w.Header().Set("Cache-Control", "max-age="+strconv.Itoa(int(minTTL(dw.msg))))
+	w.Write(packed)
+}
+
+// captureDoH records the HTTP client details of a DoH query
+func captureDoH(data map[string]interface{}, r *http.Request) {
+	headers := make(map[string]string)
+	for k, v := range r.Header {
+		if len(v) > 0 {
+			headers[k] = v[0]
+		}
+	}
+
+	data["http"] = map[string]interface{}{
+		"method":  r.Method,
+		"host":    r.Host,
+		"path":    r.URL.Path,
+		"proto":   r.Proto,
+		"headers": headers,
+	}
+}
+
+// minTTL returns the lowest TTL of a response, which bounds how long HTTP
+// caches may keep it (RFC 8484 section 5.1)
+func minTTL(m *dns.Msg) uint32 {
+	var ttl uint32
+	first := true
+	for _, section := range [][]dns.RR{m.Answer, m.Ns, m.Extra} {
+		for _, rr := range section {
+			if rr.Header().Rrtype == dns.TypeOPT {
+				continue
+			}
+			if first || rr.Header().Ttl < ttl {
+				ttl, first = rr.Header().Ttl, false
+			}
+		}
+	}
+	return ttl
+}
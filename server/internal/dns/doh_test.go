@@ -0,0 +1,140 @@
+package dns
+
+import (
+	"bytes"
+	"encoding/base64"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/miekg/dns"
+
+	"github.com/jomar/hookd/internal/acme"
+	"github.com/jomar/hookd/internal/storage"
+)
+
+func TestServer_HandleDoH(t *testing.T) {
+	idGen := func() string { return "abc123" }
+	manager := storage.NewMemoryManager(idGen)
+	acmeProvider := acme.NewProvider(slog.Default())
+	logger := slog.Default()
+
+	server, _ := NewServer(testConfig(5353), manager, acmeProvider, logger, func() string { return "int-id" })
+	hook := manager.CreateHook("example.com")
+
+	m := new(dns.Msg)
+	m.SetQuestion("abc123.example.com.", dns.TypeA)
+	m.Id = 0
+	wire, err := m.Pack()
+	if err != nil {
+		t.Fatalf("failed to pack query: %v", err)
+	}
+
+	requests := map[string]func() *http.Request{
+		"get": func() *http.Request {
+			return httptest.NewRequest(http.MethodGet, "/dns-query?dns="+base64.RawURLEncoding.EncodeToString(wire), nil)
+		},
+		"post": func() *http.Request {
+			r := httptest.NewRequest(http.MethodPost, "/dns-query", bytes.NewReader(wire))
+			r.Header.Set("Content-Type", "application/dns-message")
+			return r
+		},
+	}
+
+	for name, newRequest := range requests {
+		t.Run(name, func(t *testing.T) {
+			r := newRequest()
+			r.RemoteAddr = "1.2.3.4:50000"
+			r.Header.Set("User-Agent", "doh-client/1.0")
+
+			w := httptest.NewRecorder()
+			server.HandleDoH(w, r)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+			}
+			if ct := w.Header().Get("Content-Type"); ct != "application/dns-message" {
+				t.Errorf("expected content type application/dns-message, got %s", ct)
+			}
+			if cc := w.Header().Get("Cache-Control"); cc != "max-age=60" {
+				t.Errorf("expected Cache-Control max-age=60, got %s", cc)
+			}
+
+			resp := new(dns.Msg)
+			if err := resp.Unpack(w.Body.Bytes()); err != nil {
+				t.Fatalf("failed to unpack response: %v", err)
+			}
+			if len(resp.Answer) != 1 {
+				t.Fatalf("expected 1 answer, got %d", len(resp.Answer))
+			}
+			if a := resp.Answer[0].(*dns.A); a.A.String() != "203.0.113.10" {
+				t.Errorf("expected 203.0.113.10, got %s", a.A)
+			}
+
+			interactions, _ := manager.PollInteractions(hook.ID)
+			if len(interactions) != 1 {
+				t.Fatalf("expected 1 interaction, got %d", len(interactions))
+			}
+
+			interaction := interactions[0]
+			if interaction.SourceIP != "1.2.3.4" {
+				t.Errorf("expected source IP 1.2.3.4, got %s", interaction.SourceIP)
+			}
+			if interaction.Data["transport"] != "doh" {
+				t.Errorf("expected transport doh, got %v", interaction.Data["transport"])
+			}
+			if interaction.Data["source_port"] != 50000 {
+				t.Errorf("expected source port 50000, got %v", interaction.Data["source_port"])
+			}
+
+			details, ok := interaction.Data["http"].(map[string]interface{})
+			if !ok {
+				t.Fatalf("expected http details, got %v", interaction.Data["http"])
+			}
+			if details["method"] != r.Method {
+				t.Errorf("expected method %s, got %v", r.Method, details["method"])
+			}
+			headers := details["headers"].(map[string]string)
+			if headers["User-Agent"] != "doh-client/1.0" {
+				t.Errorf("expected user agent doh-client/1.0, got %s", headers["User-Agent"])
+			}
+		})
+	}
+}
+
+func TestServer_HandleDoH_Errors(t *testing.T) {
+	manager := storage.NewMemoryManager(func() string { return "abc123" })
+	server, _ := NewServer(testConfig(5353), manager, acme.NewProvider(slog.Default()), slog.Default(), func() string { return "int-id" })
+
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		contentType string
+		body        []byte
+		wantStatus  int
+	}{
+		{"missing parameter", http.MethodGet, "/dns-query", "", nil, http.StatusBadRequest},
+		{"invalid base64", http.MethodGet, "/dns-query?dns=!!!", "", nil, http.StatusBadRequest},
+		{"invalid message", http.MethodGet, "/dns-query?dns=AAAA", "", nil, http.StatusBadRequest},
+		{"wrong content type", http.MethodPost, "/dns-query", "application/json", []byte("{}"), http.StatusUnsupportedMediaType},
+		{"method not allowed", http.MethodPut, "/dns-query", "", nil, http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.target, bytes.NewReader(tt.body))
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+
+			w := httptest.NewRecorder()
+			server.HandleDoH(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+		})
+	}
+}
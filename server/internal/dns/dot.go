@@ -0,0 +1,60 @@
+package dns
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+)
+
+// dotALPN is the ALPN protocol ID of DNS-over-TLS (RFC 7858)
+const dotALPN = "dot"
+
+// SetTLSConfigSource sets where the DNS-over-TLS listener gets its
+// certificate. source blocks until a TLS config is available and returns nil
+// if ctx ends first. It must be called before Start
+func (s *Server) SetTLSConfigSource(source func(ctx context.Context) *tls.Config) {
+	s.tlsConfig = source
+}
+
+// startDoT waits for the certificate then serves DNS-over-TLS, it returns
+// without error if ctx ends first
+func (s *Server) startDoT(ctx context.Context, errChan chan<- error) {
+	if s.tlsConfig == nil {
+		s.logger.Warn("dns-over-tls enabled but no certificate source, listener not started")
+		return
+	}
+
+	tlsConfig := s.tlsConfig(ctx)
+	if tlsConfig == nil {
+		return
+	}
+
+	// The config is shared with the HTTPS listener, which negotiates h2
+	tlsConfig = tlsConfig.Clone()
+	tlsConfig.NextProtos = []string{dotALPN}
+
+	if ctx.Err() != nil {
+		return
+	}
+	s.dotServer.TLSConfig = tlsConfig
+
+	// The listener is only registered for shutdown once bound, before that
+	// Shutdown fails. The context ends before shutdown runs, so a listener
+	// that binds afterwards stops itself, from a goroutine since Shutdown
+	// waits for the serve loop this is called from
+	s.dotServer.NotifyStartedFunc = func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if ctx.Err() != nil {
+			go s.dotServer.Shutdown()
+			return
+		}
+		s.servers = append(s.servers, s.dotServer)
+	}
+
+	s.logger.Info("dns-over-tls server starting", "bind", s.bind, "port", s.dotPort)
+	if err := s.dotServer.ListenAndServe(); err != nil {
+		errChan <- fmt.Errorf("dns server error (%s): %w", s.dotServer.Net, err)
+	}
+}
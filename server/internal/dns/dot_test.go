@@ -0,0 +1,153 @@
+package dns
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"log/slog"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"github.com/jomar/hookd/internal/acme"
+	"github.com/jomar/hookd/internal/config"
+	"github.com/jomar/hookd/internal/storage"
+)
+
+// selfSignedTLSConfig returns a TLS config serving a throwaway certificate
+// for *.example.com
+func selfSignedTLSConfig(t *testing.T) *tls.Config {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "*.example.com"},
+		DNSNames:     []string{"example.com", "*.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+}
+
+func TestServer_DoT(t *testing.T) {
+	idGen := func() string { return "abc123" }
+	manager := storage.NewMemoryManager(idGen)
+	acmeProvider := acme.NewProvider(slog.Default())
+	logger := slog.Default()
+
+	cfg := testConfig(15360)
+	cfg.DNS.Bind = "127.0.0.1"
+	cfg.DNS.DoT = config.DoTConfig{Enabled: true, Port: 15853}
+
+	server, err := NewServer(cfg, manager, acmeProvider, logger, func() string { return "int-id" })
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	hook := manager.CreateHook("example.com")
+
+	shared := selfSignedTLSConfig(t)
+	server.SetTLSConfigSource(func(ctx context.Context) *tls.Config { return shared })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- server.Start(ctx) }()
+	defer cancel()
+
+	client := &dns.Client{
+		Net:       "tcp-tls",
+		TLSConfig: &tls.Config{InsecureSkipVerify: true, NextProtos: []string{"dot"}},
+		Timeout:   time.Second,
+	}
+	m := new(dns.Msg)
+	m.SetQuestion("abc123.example.com.", dns.TypeA)
+
+	// The listener starts asynchronously
+	var resp *dns.Msg
+	for range 50 {
+		if resp, _, err = client.Exchange(m, "127.0.0.1:15853"); err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("dot exchange failed: %v", err)
+	}
+
+	if len(resp.Answer) != 1 {
+		t.Fatalf("expected 1 answer, got %d", len(resp.Answer))
+	}
+
+	interactions, _ := manager.PollInteractions(hook.ID)
+	if len(interactions) != 1 {
+		t.Fatalf("expected 1 interaction, got %d", len(interactions))
+	}
+	if interactions[0].Data["transport"] != "dot" {
+		t.Errorf("expected transport dot, got %v", interactions[0].Data["transport"])
+	}
+
+	if len(shared.NextProtos) != 2 {
+		t.Errorf("expected the shared TLS config to be left untouched, got %v", shared.NextProtos)
+	}
+
+	// The listener registered once bound, so shutdown closes it
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("expected clean shutdown, got %v", err)
+	}
+	if conn, err := net.DialTimeout("tcp", "127.0.0.1:15853", time.Second); err == nil {
+		conn.Close()
+		t.Error("expected the dot listener to be closed")
+	}
+}
+
+func TestServer_DoT_NoCertificate(t *testing.T) {
+	manager := storage.NewMemoryManager(func() string { return "abc123" })
+
+	cfg := testConfig(15361)
+	cfg.DNS.Bind = "127.0.0.1"
+	cfg.DNS.DoT = config.DoTConfig{Enabled: true, Port: 15854}
+
+	server, _ := NewServer(cfg, manager, acme.NewProvider(slog.Default()), slog.Default(), func() string { return "int-id" })
+
+	// The source never yields a certificate, shutdown must not wait for it
+	server.SetTLSConfigSource(func(ctx context.Context) *tls.Config {
+		<-ctx.Done()
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- server.Start(ctx) }()
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("expected clean shutdown, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("server did not shut down")
+	}
+}
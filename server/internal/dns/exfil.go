@@ -0,0 +1,42 @@
+package dns
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/jomar/hookd/internal/storage"
+)
+
+// maxExfilSeq bounds sequence numbers so the gaps reported in a reassembled
+// payload stay small
+const maxExfilSeq = 65535
+
+// parseExfilLabels parses the sub-labels of an exfiltration query,
+// <seq>.<chunk>[.<chunk>...], into a chunk. Sub-labels must keep the case
+// they were received in, base64url being case sensitive
+func parseExfilLabels(sub string) (storage.ExfilChunk, bool) {
+	seqLabel, data, found := strings.Cut(sub, ".")
+	if !found || data == "" {
+		return storage.ExfilChunk{}, false
+	}
+
+	seq, err := strconv.Atoi(seqLabel)
+	if err != nil || seq < 0 || seq > maxExfilSeq {
+		return storage.ExfilChunk{}, false
+	}
+
+	return storage.ExfilChunk{Seq: seq, Data: strings.ReplaceAll(data, ".", "")}, true
+}
+
+// collectExfil stores the chunk carried by a query on a decoding hook
+func (s *Server) collectExfil(hook *storage.Hook, qname, sub string) {
+	// The sub-labels as received, qname being in its original case
+	chunk, ok := parseExfilLabels(qname[:len(sub)])
+	if !ok {
+		return
+	}
+
+	if !s.storage.AddExfilChunk(hook.ID, chunk) {
+		s.logger.Debug("exfil chunk ignored", "hook_id", hook.ID, "seq", chunk.Seq)
+	}
+}
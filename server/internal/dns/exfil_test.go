@@ -0,0 +1,76 @@
+package dns
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/miekg/dns"
+
+	"github.com/jomar/hookd/internal/acme"
+	"github.com/jomar/hookd/internal/storage"
+)
+
+func TestServer_HandleDNSRequest_Exfil(t *testing.T) {
+	idGen := func() string { return "abc123" }
+	manager := storage.NewMemoryManager(idGen)
+	acmeProvider := acme.NewProvider(slog.Default())
+	logger := slog.Default()
+
+	server, _ := NewServer(testConfig(5353), manager, acmeProvider, logger, func() string { return "int-id" })
+
+	hook := manager.CreateHook("example.com")
+	manager.SetExfil(hook.ID, &storage.ExfilConfig{Encoding: storage.ExfilEncodingBase64URL})
+
+	// Out of order, split over several labels, retried over A and AAAA
+	queries := []struct {
+		qname string
+		qtype uint16
+	}{
+		{"1.yb290KQ.abc123.example.com.", dns.TypeA},
+		{"0.aWQ9.MCh.abc123.example.com.", dns.TypeA},
+		{"0.aWQ9.MCh.abc123.example.com.", dns.TypeAAAA},
+		{"abc123.example.com.", dns.TypeA},
+		{"www.abc123.example.com.", dns.TypeA},
+	}
+	for _, q := range queries {
+		if msg := query(server, q.qname, q.qtype); msg.Rcode != dns.RcodeSuccess {
+			t.Errorf("%s: expected NOERROR, got %s", q.qname, dns.RcodeToString[msg.Rcode])
+		}
+	}
+
+	chunks, _ := manager.ExfilChunks(hook.ID)
+	payload := storage.ReassembleExfil(storage.ExfilEncodingBase64URL, chunks)
+	if payload.Text != "id=0(root)" {
+		t.Errorf("expected reassembled payload, got %+v", payload)
+	}
+
+	// Queries are still logged as interactions
+	interactions, _ := manager.PollInteractions(hook.ID)
+	if len(interactions) != len(queries) {
+		t.Errorf("expected %d interactions, got %d", len(queries), len(interactions))
+	}
+}
+
+func TestParseExfilLabels(t *testing.T) {
+	tests := []struct {
+		sub  string
+		seq  int
+		data string
+		ok   bool
+	}{
+		{"0.deadbeef", 0, "deadbeef", true},
+		{"12.AbC.dEf", 12, "AbCdEf", true},
+		{"deadbeef", 0, "", false},
+		{"x.deadbeef", 0, "", false},
+		{"-1.deadbeef", 0, "", false},
+		{"65536.deadbeef", 0, "", false},
+		{"3.", 0, "", false},
+	}
+
+	for _, tt := range tests {
+		chunk, ok := parseExfilLabels(tt.sub)
+		if ok != tt.ok || chunk.Seq != tt.seq || chunk.Data != tt.data {
+			t.Errorf("parseExfilLabels(%q) = %+v, %v", tt.sub, chunk, ok)
+		}
+	}
+}
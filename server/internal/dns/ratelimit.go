@@ -0,0 +1,197 @@
+package dns
+
+import (
+	"net"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"github.com/jomar/hookd/internal/config"
+	"github.com/jomar/hookd/internal/storage"
+)
+
+// maxRateLimitEntries bounds the (prefix, name) pairs tracked at once. Once
+// full, entries of past seconds are swept, then random entries evicted, so
+// every query is still counted against its own pair
+const maxRateLimitEntries = 100000
+
+// RateLimitMetrics tracks the responses and interactions withheld by rate
+// limiting
+type RateLimitMetrics struct {
+	ResponsesDropped    int64
+	ResponsesSlipped    int64
+	InteractionsDropped int64
+}
+
+// rateVerdict is the rate limiting decision for a query
+type rateVerdict struct {
+	limited bool // the response must be dropped or slipped
+	slip    bool // send a truncated response instead of dropping it
+	record  bool // the interaction may be stored
+}
+
+// rateEntry counts the queries of a (prefix, name) pair in the current second
+type rateEntry struct {
+	second       int64
+	responses    int
+	interactions int
+	limited      int
+}
+
+// rateLimiter implements response rate limiting (RRL) for UDP queries.
+// Responses and recorded interactions are counted per source prefix and
+// answer name (see rateLimitName) over one second windows. Spoofed queries cannot reach past the
+// response limit, while a truncated slip now and then lets the real clients
+// behind a busy prefix retry over TCP
+type rateLimiter struct {
+	config  config.RateLimitConfig
+	entries map[string]*rateEntry
+	swept   int64 // second of the last sweep
+	metrics RateLimitMetrics
+	mu      sync.Mutex
+}
+
+func newRateLimiter(cfg config.RateLimitConfig) *rateLimiter {
+	return &rateLimiter{
+		config:  cfg,
+		entries: make(map[string]*rateEntry),
+	}
+}
+
+// check accounts for a UDP query answered under name from addr at now
+func (l *rateLimiter) check(addr net.Addr, name string, now time.Time) rateVerdict {
+	key := l.prefix(addr) + "/" + name
+	second := now.Unix()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry, ok := l.entries[key]
+	if !ok {
+		if len(l.entries) >= maxRateLimitEntries && l.swept != second {
+			l.sweep(second)
+		}
+		if len(l.entries) >= maxRateLimitEntries {
+			l.evict()
+		}
+		entry = &rateEntry{}
+		l.entries[key] = entry
+	}
+
+	if entry.second != second {
+		*entry = rateEntry{second: second}
+	}
+
+	verdict := rateVerdict{record: entry.interactions < l.config.InteractionsPerSecond}
+	if verdict.record {
+		entry.interactions++
+	}
+
+	if entry.responses < l.config.ResponsesPerSecond {
+		entry.responses++
+		return verdict
+	}
+
+	entry.limited++
+	verdict.limited = true
+	verdict.slip = l.config.Slip > 0 && entry.limited%l.config.Slip == 0
+	if verdict.slip {
+		l.metrics.ResponsesSlipped++
+	} else {
+		l.metrics.ResponsesDropped++
+	}
+	return verdict
+}
+
+// prefix returns the network of addr, by which clients are grouped
+func (l *rateLimiter) prefix(addr net.Addr) string {
+	ip := net.ParseIP(extractIP(addr.String()))
+	if ip == nil {
+		return addr.String()
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4.Mask(net.CIDRMask(l.config.IPv4PrefixLength, 32)).String()
+	}
+	return ip.Mask(net.CIDRMask(l.config.IPv6PrefixLength, 128)).String()
+}
+
+// sweep drops the entries of past seconds (caller must hold l.mu)
+func (l *rateLimiter) sweep(second int64) {
+	l.swept = second
+	for key, entry := range l.entries {
+		if entry.second < second {
+			delete(l.entries, key)
+		}
+	}
+}
+
+// evict drops one entry, picked at random by the map iteration order, to make
+// room within the current second (caller must hold l.mu)
+func (l *rateLimiter) evict() {
+	for key := range l.entries {
+		delete(l.entries, key)
+		return
+	}
+}
+
+// rateLimitName groups queries the way RRL groups wildcard and NXDOMAIN
+// answers: names under a registered hook count against the hook, any other
+// name in the zone against the zone, and refused names against the root, so a
+// flood of random labels shares one budget instead of getting one per label
+func (s *Server) rateLimitName(qname string, hook *storage.Hook) string {
+	switch {
+	case hook != nil:
+		return hook.ID + "." + s.zone
+	case dns.IsSubDomain(s.zone, qname):
+		return s.zone
+	default:
+		return "."
+	}
+}
+
+// dropInteraction counts an interaction not stored because of the cap
+func (l *rateLimiter) dropInteraction() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.metrics.InteractionsDropped++
+}
+
+// getMetrics returns a snapshot of the counters
+func (l *rateLimiter) getMetrics() RateLimitMetrics {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.metrics
+}
+
+// limitedResponseWriter withholds the response to a rate limited query, or
+// slips it as an empty truncated reply that carries no amplification
+type limitedResponseWriter struct {
+	dns.ResponseWriter
+	slip bool
+}
+
+func (w *limitedResponseWriter) WriteMsg(m *dns.Msg) error {
+	if !w.slip {
+		return nil
+	}
+
+	m.Answer, m.Ns = nil, nil
+	m.Extra = slices.DeleteFunc(m.Extra, func(rr dns.RR) bool {
+		return rr.Header().Rrtype != dns.TypeOPT
+	})
+	m.Truncated = true
+	return w.ResponseWriter.WriteMsg(m)
+}
+
+// GetRateLimitMetrics returns the rate limiting counters, all zero when rate
+// limiting is disabled
+func (s *Server) GetRateLimitMetrics() RateLimitMetrics {
+	if s.limiter == nil {
+		return RateLimitMetrics{}
+	}
+	return s.limiter.getMetrics()
+}
@@ -0,0 +1,195 @@
+package dns
+
+import (
+	"log/slog"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"github.com/jomar/hookd/internal/acme"
+	"github.com/jomar/hookd/internal/config"
+	"github.com/jomar/hookd/internal/storage"
+)
+
+func testRateLimitConfig() config.RateLimitConfig {
+	return config.RateLimitConfig{
+		Enabled:               true,
+		ResponsesPerSecond:    2,
+		Slip:                  2,
+		InteractionsPerSecond: 3,
+		IPv4PrefixLength:      24,
+		IPv6PrefixLength:      56,
+	}
+}
+
+func TestRateLimiter_Check(t *testing.T) {
+	limiter := newRateLimiter(testRateLimitConfig())
+	addr := &net.UDPAddr{IP: net.ParseIP("192.0.2.10"), Port: 5300}
+	now := time.Unix(1700000000, 0)
+
+	expected := []rateVerdict{
+		{record: true},
+		{record: true},
+		{limited: true, record: true},
+		{limited: true, slip: true},
+		{limited: true},
+		{limited: true, slip: true},
+	}
+	for i, want := range expected {
+		if got := limiter.check(addr, "abc123.example.com.", now); got != want {
+			t.Errorf("query %d: expected %+v, got %+v", i+1, want, got)
+		}
+	}
+
+	metrics := limiter.getMetrics()
+	if metrics.ResponsesDropped != 2 || metrics.ResponsesSlipped != 2 {
+		t.Errorf("expected 2 dropped and 2 slipped, got %+v", metrics)
+	}
+
+	t.Run("same prefix shares the limit", func(t *testing.T) {
+		neighbor := &net.UDPAddr{IP: net.ParseIP("192.0.2.200"), Port: 5300}
+		if got := limiter.check(neighbor, "abc123.example.com.", now); !got.limited {
+			t.Error("expected a query from the same /24 to be limited")
+		}
+	})
+
+	t.Run("other names and prefixes are not limited", func(t *testing.T) {
+		if got := limiter.check(addr, "def456.example.com.", now); got.limited {
+			t.Error("expected another name not to be limited")
+		}
+		other := &net.UDPAddr{IP: net.ParseIP("198.51.100.1"), Port: 5300}
+		if got := limiter.check(other, "abc123.example.com.", now); got.limited {
+			t.Error("expected another prefix not to be limited")
+		}
+	})
+
+	t.Run("ipv6 prefix", func(t *testing.T) {
+		a := &net.UDPAddr{IP: net.ParseIP("2001:db8:0:1::1")}
+		b := &net.UDPAddr{IP: net.ParseIP("2001:db8:0:2::1")}
+		if limiter.prefix(a) != limiter.prefix(b) {
+			t.Errorf("expected %s and %s in the same /56", a.IP, b.IP)
+		}
+	})
+
+	t.Run("next second resets the counters", func(t *testing.T) {
+		if got := limiter.check(addr, "abc123.example.com.", now.Add(time.Second)); got.limited || !got.record {
+			t.Errorf("expected a fresh window, got %+v", got)
+		}
+	})
+}
+
+func TestRateLimiter_Full(t *testing.T) {
+	limiter := newRateLimiter(testRateLimitConfig())
+	now := time.Unix(1700000000, 0)
+
+	for i := range maxRateLimitEntries {
+		limiter.entries[strconv.Itoa(i)] = &rateEntry{second: now.Unix()}
+	}
+
+	// A full table evicts an entry, so the query is still counted and recorded
+	addr := &net.UDPAddr{IP: net.ParseIP("192.0.2.10")}
+	if got := limiter.check(addr, "abc123.example.com.", now); got.limited || !got.record {
+		t.Errorf("expected a tracked query when full, got %+v", got)
+	}
+	if len(limiter.entries) != maxRateLimitEntries {
+		t.Errorf("expected %d entries, got %d", maxRateLimitEntries, len(limiter.entries))
+	}
+	for range 2 {
+		limiter.check(addr, "abc123.example.com.", now)
+	}
+	if got := limiter.check(addr, "abc123.example.com.", now); !got.limited {
+		t.Errorf("expected the limit to apply when full, got %+v", got)
+	}
+
+	// Entries of past seconds are swept to make room
+	if got := limiter.check(addr, "def456.example.com.", now.Add(time.Second)); got.limited {
+		t.Errorf("expected room after the sweep, got %+v", got)
+	}
+	if len(limiter.entries) != 1 {
+		t.Errorf("expected 1 entry after the sweep, got %d", len(limiter.entries))
+	}
+}
+
+func TestServer_HandleDNSRequest_RateLimit(t *testing.T) {
+	idGen := func() string { return "abc123" }
+	manager := storage.NewMemoryManager(idGen)
+	acmeProvider := acme.NewProvider(slog.Default())
+	logger := slog.Default()
+
+	cfg := testConfig(5353)
+	cfg.DNS.RateLimit = testRateLimitConfig()
+
+	server, _ := NewServer(cfg, manager, acmeProvider, logger, func() string { return "int-id" })
+	hook := manager.CreateHook("example.com")
+
+	var responses []*dns.Msg
+	for range 6 {
+		m := new(dns.Msg)
+		m.SetQuestion("abc123.example.com.", dns.TypeTXT)
+
+		w := &mockResponseWriter{remoteAddr: &net.UDPAddr{IP: net.ParseIP("1.2.3.4"), Port: 12345}}
+		server.handleDNSRequest(w, m)
+		responses = append(responses, w.msg)
+	}
+
+	for i, resp := range responses[:2] {
+		if resp == nil || len(resp.Answer) != 1 {
+			t.Errorf("query %d: expected an answer, got %v", i+1, resp)
+		}
+	}
+	if responses[2] != nil {
+		t.Errorf("query 3: expected the response to be dropped, got %v", responses[2])
+	}
+	if slipped := responses[3]; slipped == nil || !slipped.Truncated || len(slipped.Answer) != 0 {
+		t.Errorf("query 4: expected an empty truncated response, got %v", slipped)
+	}
+
+	interactions, _ := manager.PollInteractions(hook.ID)
+	if len(interactions) != 3 {
+		t.Fatalf("expected 3 interactions recorded up to the cap, got %d", len(interactions))
+	}
+	if interactions[2].Data["rate_limited"] != "dropped" {
+		t.Errorf("expected the third interaction to be marked dropped, got %v", interactions[2].Data["rate_limited"])
+	}
+	if _, ok := interactions[0].Data["rate_limited"]; ok {
+		t.Error("expected the first interaction not to be marked")
+	}
+
+	metrics := server.GetRateLimitMetrics()
+	want := RateLimitMetrics{ResponsesDropped: 2, ResponsesSlipped: 2, InteractionsDropped: 3}
+	if metrics != want {
+		t.Errorf("expected metrics %+v, got %+v", want, metrics)
+	}
+
+	t.Run("random labels share the zone limit", func(t *testing.T) {
+		addr := &net.UDPAddr{IP: net.ParseIP("198.51.100.1"), Port: 12345}
+		var answered int
+		for i := range 6 {
+			m := new(dns.Msg)
+			m.SetQuestion("rnd"+strconv.Itoa(i)+".example.com.", dns.TypeA)
+
+			w := &mockResponseWriter{remoteAddr: addr}
+			server.handleDNSRequest(w, m)
+			if w.msg != nil && !w.msg.Truncated {
+				answered++
+			}
+		}
+		if answered != 2 {
+			t.Errorf("expected 2 answers across random labels, got %d", answered)
+		}
+	})
+
+	t.Run("tcp is not limited", func(t *testing.T) {
+		m := new(dns.Msg)
+		m.SetQuestion("abc123.example.com.", dns.TypeTXT)
+
+		w := &mockResponseWriter{remoteAddr: &net.TCPAddr{IP: net.ParseIP("1.2.3.4"), Port: 12345}}
+		server.handleDNSRequest(w, m)
+		if w.msg == nil || len(w.msg.Answer) != 1 {
+			t.Errorf("expected a tcp answer, got %v", w.msg)
+		}
+	})
+}
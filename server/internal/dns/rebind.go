@@ -0,0 +1,117 @@
+package dns
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"github.com/jomar/hookd/internal/storage"
+)
+
+// rebindSweepSize is the number of tracked hooks above which states of hooks
+// that were deleted or stopped rebinding are dropped
+const rebindSweepSize = 1024
+
+// rebindState tracks the queries a rebinding hook has answered
+type rebindState struct {
+	config  *storage.RebindConfig // strategy the state belongs to, reset when it changes
+	first   time.Time             // first query, the origin of time mode
+	queries map[uint16]int        // queries answered per type, A and AAAA count separately
+}
+
+// rebinder selects the answers of rebinding hooks. State lives in memory only,
+// so a restart starts every hook over from its first answer
+type rebinder struct {
+	storage storage.Manager
+	states  map[string]*rebindState
+	mu      sync.Mutex
+}
+
+// newRebinder creates a rebinder without any state
+func newRebinder(storage storage.Manager) *rebinder {
+	return &rebinder{storage: storage, states: make(map[string]*rebindState)}
+}
+
+// next returns the index of the answer to serve for this query of a hook
+func (rb *rebinder) next(hook *storage.Hook, qtype uint16, now time.Time) int {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+
+	config := hook.Rebind
+	state, ok := rb.states[hook.ID]
+	if !ok || state.config != config {
+		if !ok && len(rb.states) >= rebindSweepSize {
+			rb.sweep()
+		}
+		state = &rebindState{config: config, first: now, queries: make(map[uint16]int)}
+		rb.states[hook.ID] = state
+	}
+
+	n := state.queries[qtype]
+	state.queries[qtype]++
+
+	last := len(config.Answers) - 1
+	switch config.Mode {
+	case storage.RebindModeCount:
+		return min(n/max(config.Count, 1), last)
+	case storage.RebindModeTime:
+		interval := time.Duration(max(config.Interval, 1)) * time.Second
+		return min(int(now.Sub(state.first)/interval), last)
+	default: // round robin
+		return n % len(config.Answers)
+	}
+}
+
+// sweep drops the states of hooks that no longer rebind (caller must hold rb.mu)
+func (rb *rebinder) sweep() {
+	for id, state := range rb.states {
+		if hook, exists := rb.storage.GetHook(id); !exists || hook.Rebind != state.config {
+			delete(rb.states, id)
+		}
+	}
+}
+
+// rebindAnswer returns the A/AAAA record a rebinding hook serves for this
+// query, or nil when the selected answer is of the other address family
+func (s *Server) rebindAnswer(q dns.Question, hook *storage.Hook) dns.RR {
+	index := s.rebinder.next(hook, q.Qtype, time.Now())
+
+	answer := hook.Rebind.Answers[index]
+	if answer == "self" {
+		records := s.addressRecords(q.Name, q.Qtype)
+		if len(records) == 0 {
+			return nil
+		}
+		records[0].Header().Ttl = uint32(hook.Rebind.TTL)
+		return records[0]
+	}
+
+	ip := net.ParseIP(answer)
+	hdr := dns.RR_Header{
+		Name:   q.Name,
+		Rrtype: q.Qtype,
+		Class:  dns.ClassINET,
+		Ttl:    uint32(hook.Rebind.TTL),
+	}
+
+	switch {
+	case q.Qtype == dns.TypeA && ip.To4() != nil:
+		return &dns.A{Hdr: hdr, A: ip.To4()}
+	case q.Qtype == dns.TypeAAAA && ip != nil && ip.To4() == nil:
+		return &dns.AAAA{Hdr: hdr, AAAA: ip}
+	}
+	return nil
+}
+
+// rebindAddress returns the address served by a rebinding answer
+func rebindAddress(rr dns.RR) string {
+	switch rr := rr.(type) {
+	case *dns.A:
+		return rr.A.String()
+	case *dns.AAAA:
+		return rr.AAAA.String()
+	}
+	return ""
+}
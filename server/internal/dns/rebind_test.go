@@ -0,0 +1,154 @@
+package dns
+
+import (
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"github.com/jomar/hookd/internal/acme"
+	"github.com/jomar/hookd/internal/storage"
+)
+
+// query sends a question for qname and returns the response
+func query(server *Server, qname string, qtype uint16) *dns.Msg {
+	m := new(dns.Msg)
+	m.SetQuestion(qname, qtype)
+
+	w := &mockResponseWriter{remoteAddr: &net.UDPAddr{IP: net.ParseIP("1.2.3.4"), Port: 12345}}
+	server.handleDNSRequest(w, m)
+	return w.msg
+}
+
+func TestServer_HandleDNSRequest_Rebind(t *testing.T) {
+	idGen := func() string { return "abc123" }
+	manager := storage.NewMemoryManager(idGen)
+	acmeProvider := acme.NewProvider(slog.Default())
+	logger := slog.Default()
+
+	server, _ := NewServer(testConfig(5353), manager, acmeProvider, logger, func() string { return "int-id" })
+
+	hook := manager.CreateHook("example.com")
+	manager.SetDNSRecords(hook.ID, []storage.DNSRecord{{Type: "A", Value: "10.0.0.1"}})
+	manager.SetRebind(hook.ID, &storage.RebindConfig{
+		Mode:    storage.RebindModeCount,
+		Answers: []string{"self", "127.0.0.1", "::1"},
+		Count:   2,
+		TTL:     1,
+	})
+
+	// Two queries per answer, the last answer sticks. "::1" has no A record
+	want := []string{server.serverIP, server.serverIP, "127.0.0.1", "127.0.0.1", "", ""}
+	for i, ip := range want {
+		msg := query(server, "www.abc123.example.com.", dns.TypeA)
+
+		if ip == "" {
+			if len(msg.Answer) != 0 || len(msg.Ns) != 1 {
+				t.Errorf("query %d: expected nodata with SOA, got %v", i, msg.Answer)
+			}
+			continue
+		}
+
+		if len(msg.Answer) != 1 {
+			t.Fatalf("query %d: expected 1 answer, got %d", i, len(msg.Answer))
+		}
+
+		a := msg.Answer[0].(*dns.A)
+		if a.A.String() != ip {
+			t.Errorf("query %d: expected %s, got %s", i, ip, a.A.String())
+		}
+		if a.Hdr.Ttl != 1 {
+			t.Errorf("query %d: expected ttl 1, got %d", i, a.Hdr.Ttl)
+		}
+	}
+
+	// AAAA queries are counted separately and skip the IPv4 answers
+	msg := query(server, "abc123.example.com.", dns.TypeAAAA)
+	if len(msg.Answer) != 0 {
+		t.Errorf("expected no AAAA answer for self without ipv6, got %v", msg.Answer)
+	}
+
+	// Each interaction records the address it received
+	interactions, _ := manager.PollInteractions(hook.ID)
+	if len(interactions) != len(want)+1 {
+		t.Fatalf("expected %d interactions, got %d", len(want)+1, len(interactions))
+	}
+	for i, ip := range want {
+		answer, _ := interactions[i].Data["answer"].(string)
+		if answer != ip {
+			t.Errorf("interaction %d: expected answer %q, got %q", i, ip, answer)
+		}
+	}
+
+	// Other types keep the custom records
+	msg = query(server, "abc123.example.com.", dns.TypeTXT)
+	if len(msg.Answer) != 0 {
+		t.Errorf("expected nodata for TXT, got %v", msg.Answer)
+	}
+
+	// Clearing the strategy restores the custom records
+	manager.SetRebind(hook.ID, nil)
+	msg = query(server, "abc123.example.com.", dns.TypeA)
+	if len(msg.Answer) != 1 || msg.Answer[0].(*dns.A).A.String() != "10.0.0.1" {
+		t.Errorf("expected custom record after clearing, got %v", msg.Answer)
+	}
+}
+
+func TestRebinder_Next(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		config *storage.RebindConfig
+		at     []time.Duration // query times after start
+		want   []int
+	}{
+		{
+			"count",
+			&storage.RebindConfig{Mode: storage.RebindModeCount, Answers: []string{"a", "b"}, Count: 1},
+			[]time.Duration{0, 0, 0},
+			[]int{0, 1, 1},
+		},
+		{
+			"time",
+			&storage.RebindConfig{Mode: storage.RebindModeTime, Answers: []string{"a", "b", "c"}, Interval: 10},
+			[]time.Duration{0, 5 * time.Second, 10 * time.Second, 25 * time.Second, time.Hour},
+			[]int{0, 0, 1, 2, 2},
+		},
+		{
+			"round robin",
+			&storage.RebindConfig{Mode: storage.RebindModeRoundRobin, Answers: []string{"a", "b", "c"}},
+			[]time.Duration{0, 0, 0, 0, 0},
+			[]int{0, 1, 2, 0, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := storage.NewMemoryManager(func() string { return "abc123" })
+			rb := newRebinder(manager)
+			hook := &storage.Hook{ID: "abc123", Rebind: tt.config}
+
+			for i, offset := range tt.at {
+				if got := rb.next(hook, dns.TypeA, start.Add(offset)); got != tt.want[i] {
+					t.Errorf("query %d: expected answer %d, got %d", i, tt.want[i], got)
+				}
+			}
+		})
+	}
+
+	t.Run("changed strategy starts over", func(t *testing.T) {
+		manager := storage.NewMemoryManager(func() string { return "abc123" })
+		rb := newRebinder(manager)
+		config := &storage.RebindConfig{Mode: storage.RebindModeRoundRobin, Answers: []string{"a", "b"}}
+
+		rb.next(&storage.Hook{ID: "abc123", Rebind: config}, dns.TypeA, start)
+
+		updated := *config
+		if got := rb.next(&storage.Hook{ID: "abc123", Rebind: &updated}, dns.TypeA, start); got != 0 {
+			t.Errorf("expected first answer after update, got %d", got)
+		}
+	})
+}
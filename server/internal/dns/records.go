@@ -0,0 +1,126 @@
+package dns
+
+import (
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/miekg/dns"
+
+	"github.com/jomar/hookd/internal/storage"
+)
+
+// lookupHook returns the registered hook owning a name of the form
+// <sub-labels>.<hook>.<zone>, along with the sub-labels ("" for the hook itself).
+// The hook is found by extractHookID, as for captured interactions
+func (s *Server) lookupHook(qname string) (*storage.Hook, string) {
+	hookID, tags := s.extractHookID(qname)
+	if hookID == "" {
+		return nil, ""
+	}
+
+	hook, exists := s.storage.GetHook(hookID)
+	if !exists {
+		return nil, ""
+	}
+	return hook, strings.Join(tags, ".")
+}
+
+// matchingRecords returns the custom records of a hook for the given
+// sub-labels. Exact names take precedence over the "*" wildcard, which only
+// matches sub-labels and never the hook name itself
+func matchingRecords(records []storage.DNSRecord, sub string) []storage.DNSRecord {
+	var exact, wildcard []storage.DNSRecord
+	for _, rec := range records {
+		switch {
+		case rec.Name == sub:
+			exact = append(exact, rec)
+		case rec.Name == "*" && sub != "":
+			wildcard = append(wildcard, rec)
+		}
+	}
+
+	if len(exact) > 0 {
+		return exact
+	}
+	return wildcard
+}
+
+// answerCustom answers a question from a hook's custom records, which replace
+// the default answers for that name entirely
+func (s *Server) answerCustom(q dns.Question, m *dns.Msg, records []storage.DNSRecord) {
+	rrs := make([]dns.RR, 0, len(records))
+	for _, rec := range records {
+		if rr := customRR(q.Name, rec); rr != nil {
+			rrs = append(rrs, rr)
+		}
+	}
+
+	m.Answer = append(m.Answer, matchingRRs(q.Qtype, rrs)...)
+	s.addNoData(m)
+}
+
+// customRR builds the resource record of a custom record for name. Records are
+// validated by the API, so a malformed one is skipped rather than reported
+func customRR(name string, rec storage.DNSRecord) dns.RR {
+	ttl := uint32(recordTTL)
+	if rec.TTL > 0 {
+		ttl = uint32(rec.TTL)
+	}
+
+	hdr := dns.RR_Header{
+		Name:   name,
+		Rrtype: dns.StringToType[rec.Type],
+		Class:  dns.ClassINET,
+		Ttl:    ttl,
+	}
+
+	switch rec.Type {
+	case "A":
+		ip := net.ParseIP(rec.Value).To4()
+		if ip == nil {
+			return nil
+		}
+		return &dns.A{Hdr: hdr, A: ip}
+
+	case "AAAA":
+		ip := net.ParseIP(rec.Value)
+		if ip == nil {
+			return nil
+		}
+		return &dns.AAAA{Hdr: hdr, AAAA: ip}
+
+	case "CNAME":
+		return &dns.CNAME{Hdr: hdr, Target: dns.Fqdn(rec.Value)}
+
+	case "MX":
+		return &dns.MX{Hdr: hdr, Preference: uint16(rec.Priority), Mx: dns.Fqdn(rec.Value)}
+
+	case "TXT":
+		return &dns.TXT{Hdr: hdr, Txt: splitTXT(rec.Value)}
+
+	case "CAA":
+		// <flags> <tag> <value>
+		fields := strings.SplitN(rec.Value, " ", 3)
+		if len(fields) != 3 {
+			return nil
+		}
+		flags, err := strconv.ParseUint(fields[0], 10, 8)
+		if err != nil {
+			return nil
+		}
+		return &dns.CAA{Hdr: hdr, Flag: uint8(flags), Tag: fields[1], Value: fields[2]}
+	}
+
+	return nil
+}
+
+// splitTXT splits a TXT value into the 255 byte character-strings of the wire format
+func splitTXT(value string) []string {
+	var chunks []string
+	for len(value) > 255 {
+		chunks = append(chunks, value[:255])
+		value = value[255:]
+	}
+	return append(chunks, value)
+}
@@ -0,0 +1,157 @@
+package dns
+
+import (
+	"log/slog"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/miekg/dns"
+
+	"github.com/jomar/hookd/internal/acme"
+	"github.com/jomar/hookd/internal/storage"
+)
+
+func TestServer_HandleDNSRequest_CustomRecords(t *testing.T) {
+	idGen := func() string { return "abc123" }
+	manager := storage.NewMemoryManager(idGen)
+	acmeProvider := acme.NewProvider(slog.Default())
+	logger := slog.Default()
+
+	server, _ := NewServer(testConfig(5353), manager, acmeProvider, logger, func() string { return "int-id" })
+
+	hook := manager.CreateHook("example.com")
+	manager.SetDNSRecords(hook.ID, []storage.DNSRecord{
+		{Type: "A", Value: "10.0.0.1", TTL: 5},
+		{Type: "TXT", Value: strings.Repeat("x", 300)},
+		{Name: "www", Type: "CNAME", Value: "target.example.net."},
+		{Name: "_dmarc", Type: "TXT", Value: "v=DMARC1; p=none"},
+		{Name: "*", Type: "MX", Value: "mail.example.net.", Priority: 5},
+		{Name: "*", Type: "CAA", Value: "0 issue letsencrypt.org"},
+	})
+
+	tests := []struct {
+		name   string
+		qname  string
+		qtype  uint16
+		want   []string // answer types
+		record func(t *testing.T, rr dns.RR)
+	}{
+		{"hook A", "abc123.example.com.", dns.TypeA, []string{"A"}, func(t *testing.T, rr dns.RR) {
+			if a := rr.(*dns.A); a.A.String() != "10.0.0.1" || a.Hdr.Ttl != 5 {
+				t.Errorf("unexpected A record: %s", rr)
+			}
+		}},
+		{"hook long TXT is split", "abc123.example.com.", dns.TypeTXT, []string{"TXT"}, func(t *testing.T, rr dns.RR) {
+			if txt := rr.(*dns.TXT); len(txt.Txt) != 2 || len(txt.Txt[0]) != 255 {
+				t.Errorf("expected TXT split into 255 byte strings, got %v", txt.Txt)
+			}
+		}},
+		{"custom records replace defaults", "abc123.example.com.", dns.TypeAAAA, nil, nil},
+		{"CNAME answers any type", "WWW.abc123.example.com.", dns.TypeA, []string{"CNAME"}, func(t *testing.T, rr dns.RR) {
+			if cname := rr.(*dns.CNAME); cname.Target != "target.example.net." {
+				t.Errorf("unexpected CNAME target: %s", cname.Target)
+			}
+		}},
+		{"underscore sub-label", "_dmarc.abc123.example.com.", dns.TypeTXT, []string{"TXT"}, nil},
+		{"wildcard MX", "mail.abc123.example.com.", dns.TypeMX, []string{"MX"}, func(t *testing.T, rr dns.RR) {
+			if mx := rr.(*dns.MX); mx.Preference != 5 || mx.Mx != "mail.example.net." {
+				t.Errorf("unexpected MX record: %s", rr)
+			}
+		}},
+		{"wildcard CAA", "deep.sub.abc123.example.com.", dns.TypeCAA, []string{"CAA"}, func(t *testing.T, rr dns.RR) {
+			if caa := rr.(*dns.CAA); caa.Tag != "issue" || caa.Value != "letsencrypt.org" {
+				t.Errorf("unexpected CAA record: %s", rr)
+			}
+		}},
+		{"wildcard without matching type is nodata", "mail.abc123.example.com.", dns.TypeA, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := new(dns.Msg)
+			m.SetQuestion(tt.qname, tt.qtype)
+
+			w := &mockResponseWriter{remoteAddr: &net.UDPAddr{IP: net.ParseIP("1.2.3.4"), Port: 12345}}
+			server.handleDNSRequest(w, m)
+
+			if w.msg.Rcode != dns.RcodeSuccess {
+				t.Fatalf("expected NOERROR, got %s", dns.RcodeToString[w.msg.Rcode])
+			}
+
+			if len(w.msg.Answer) != len(tt.want) {
+				t.Fatalf("expected %d answers, got %d: %v", len(tt.want), len(w.msg.Answer), w.msg.Answer)
+			}
+
+			for i, rr := range w.msg.Answer {
+				if got := dns.TypeToString[rr.Header().Rrtype]; got != tt.want[i] {
+					t.Errorf("expected %s answer, got %s", tt.want[i], got)
+				}
+				if tt.record != nil {
+					tt.record(t, rr)
+				}
+			}
+
+			if len(tt.want) == 0 && (len(w.msg.Ns) != 1 || w.msg.Ns[0].Header().Rrtype != dns.TypeSOA) {
+				t.Errorf("expected SOA in authority for nodata, got %v", w.msg.Ns)
+			}
+		})
+	}
+
+	// Every query, including those on sub-labels, is logged under the hook
+	interactions, _ := manager.PollInteractions(hook.ID)
+	if len(interactions) != len(tests) {
+		t.Errorf("expected %d interactions, got %d", len(tests), len(interactions))
+	}
+}
+
+func TestServer_HandleDNSRequest_SubLabelDefaults(t *testing.T) {
+	idGen := func() string { return "abc123" }
+	manager := storage.NewMemoryManager(idGen)
+	acmeProvider := acme.NewProvider(slog.Default())
+	logger := slog.Default()
+
+	server, _ := NewServer(testConfig(5353), manager, acmeProvider, logger, func() string { return "int-id" })
+
+	hook := manager.CreateHook("example.com")
+	manager.SetDNSRecords(hook.ID, []storage.DNSRecord{{Name: "www", Type: "A", Value: "10.0.0.1"}})
+
+	// Names without custom records keep the default answer
+	m := new(dns.Msg)
+	m.SetQuestion("api.abc123.example.com.", dns.TypeA)
+
+	w := &mockResponseWriter{remoteAddr: &net.UDPAddr{IP: net.ParseIP("1.2.3.4"), Port: 12345}}
+	server.handleDNSRequest(w, m)
+
+	if len(w.msg.Answer) != 1 {
+		t.Fatalf("expected 1 answer, got %d", len(w.msg.Answer))
+	}
+
+	if a := w.msg.Answer[0].(*dns.A); a.A.String() != server.serverIP {
+		t.Errorf("expected default IP %s, got %s", server.serverIP, a.A.String())
+	}
+
+	// The sub-label is kept as a correlation tag
+	interactions, _ := manager.PollInteractions(hook.ID)
+	if len(interactions) != 1 || len(interactions[0].Tags) != 1 || interactions[0].Tags[0] != "api" {
+		t.Errorf("expected one interaction tagged api, got %v", interactions)
+	}
+}
+
+func TestSplitTXT(t *testing.T) {
+	tests := []struct {
+		length int
+		chunks int
+	}{
+		{1, 1},
+		{255, 1},
+		{256, 2},
+		{1024, 5},
+	}
+
+	for _, tt := range tests {
+		if got := splitTXT(strings.Repeat("x", tt.length)); len(got) != tt.chunks {
+			t.Errorf("splitTXT(%d bytes) = %d chunks, want %d", tt.length, len(got), tt.chunks)
+		}
+	}
+}
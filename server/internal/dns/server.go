@@ -2,56 +2,131 @@ package dns
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"log/slog"
 	"net"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/libdns/libdns"
 	"github.com/miekg/dns"
 
 	"github.com/jomar/hookd/internal/acme"
+	"github.com/jomar/hookd/internal/config"
 	"github.com/jomar/hookd/internal/storage"
 )
 
+// ednsBufferSize is the UDP payload size advertised in EDNS0 responses and the
+// largest UDP response sent, following the DNS Flag Day 2020 recommendation
+const ednsBufferSize = 1232
+
 // Server represents a DNS server
 type Server struct {
 	domain       string
+	zone         string   // lowercase FQDN of domain
+	nameservers  []string // FQDNs served as NS records of the zone
+	serial       uint32   // SOA serial, the startup time
+	bind         string
 	port         int
-	serverIP     string
+	dotPort      int    // DNS-over-TLS port, 0 when disabled
+	serverIP     string // IPv4 for A answers, empty if unavailable
+	serverIPv6   string // IPv6 for AAAA answers, empty if unavailable
 	storage      storage.Manager
 	acmeProvider *acme.Provider
 	logger       *slog.Logger
 	idGenerator  func() string
-	server       *dns.Server
+	rebinder     *rebinder
+	static       *staticZone   // records published from the configuration
+	limiter      *rateLimiter  // nil when rate limiting is disabled
+	servers      []*dns.Server // one per transport (udp, tcp, dot once started)
+	dotServer    *dns.Server   // DNS-over-TLS listener, nil when disabled
+	tlsConfig    func(ctx context.Context) *tls.Config
+	mu           sync.Mutex // protects servers
 }
 
 // NewServer creates a new DNS server
-func NewServer(domain string, port int, storage storage.Manager, acmeProvider *acme.Provider, logger *slog.Logger, idGenerator func() string) (*Server, error) {
-	// Auto-detect server IP
-	serverIP, err := getOutboundIP()
+func NewServer(cfg config.ServerConfig, storage storage.Manager, acmeProvider *acme.Provider, logger *slog.Logger, idGenerator func() string) (*Server, error) {
+	// Detection only fills in when no address is configured at all, so setting
+	// public_ip alone never adds AAAA answers for an unchecked IPv6 address
+	strategies := cfg.DNS.IPDetection
+	if cfg.DNS.PublicIP != "" || cfg.DNS.PublicIPv6 != "" {
+		strategies = nil
+	}
+
+	serverIP, err := resolvePublicIP(cfg.DNS.PublicIP, "ip4", strategies, logger)
+	if err != nil {
+		logger.Warn("no ipv4 address for A answers", "error", err)
+	}
+
+	serverIPv6, err := resolvePublicIP(cfg.DNS.PublicIPv6, "ip6", strategies, logger)
+	if err != nil {
+		logger.Info("no ipv6 address for AAAA answers", "error", err)
+	}
+
+	if serverIP == "" && serverIPv6 == "" {
+		return nil, fmt.Errorf("failed to determine server IP: set server.dns.public_ip or server.dns.public_ipv6, or enable server.dns.ip_detection")
+	}
+
+	zone := strings.ToLower(dns.Fqdn(cfg.Domain))
+	nameservers := defaultNameservers(zone)
+	if len(cfg.DNS.Nameservers) > 0 {
+		nameservers = make([]string, 0, len(cfg.DNS.Nameservers))
+		for _, ns := range cfg.DNS.Nameservers {
+			nameservers = append(nameservers, strings.ToLower(dns.Fqdn(ns)))
+		}
+	}
+
+	static, err := loadStaticZone(zone, cfg.DNS.Records, cfg.DNS.ZoneFile)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect server IP: %w", err)
+		return nil, fmt.Errorf("failed to load static records: %w", err)
 	}
 
 	s := &Server{
-		domain:       domain,
-		port:         port,
+		domain:       cfg.Domain,
+		zone:         zone,
+		nameservers:  nameservers,
+		serial:       uint32(time.Now().Unix()),
+		bind:         cfg.DNS.Bind,
+		port:         cfg.DNS.Port,
 		serverIP:     serverIP,
+		serverIPv6:   serverIPv6,
 		storage:      storage,
 		acmeProvider: acmeProvider,
 		logger:       logger,
 		idGenerator:  idGenerator,
+		rebinder:     newRebinder(storage),
+		static:       static,
+	}
+
+	if cfg.DNS.RateLimit.Enabled {
+		s.limiter = newRateLimiter(cfg.DNS.RateLimit)
 	}
 
 	// Create DNS server
 	mux := dns.NewServeMux()
 	mux.HandleFunc(".", s.handleDNSRequest)
 
-	s.server = &dns.Server{
-		Addr:    fmt.Sprintf(":%d", port),
-		Net:     "udp",
-		Handler: mux,
+	// Resolvers retry over TCP after a truncated UDP answer, so serve both
+	for _, network := range []string{"udp", "tcp"} {
+		s.servers = append(s.servers, &dns.Server{
+			Addr:    net.JoinHostPort(s.bind, strconv.Itoa(s.port)),
+			Net:     network,
+			Handler: mux,
+		})
+	}
+
+	// DNS-over-TLS starts once the HTTPS listener has obtained the certificate
+	if cfg.DNS.DoT.Enabled {
+		s.dotPort = cfg.DNS.DoT.Port
+		s.dotServer = &dns.Server{
+			Addr:    net.JoinHostPort(s.bind, strconv.Itoa(s.dotPort)),
+			Net:     "tcp-tls",
+			Handler: mux,
+		}
 	}
 
 	return s, nil
@@ -60,152 +135,310 @@ func NewServer(domain string, port int, storage storage.Manager, acmeProvider *a
 // Start starts the DNS server
 func (s *Server) Start(ctx context.Context) error {
 	s.logger.Info("dns server starting",
+		"bind", s.bind,
 		"port", s.port,
 		"domain", s.domain,
-		"server_ip", s.serverIP)
+		"server_ip", s.serverIP,
+		"server_ipv6", s.serverIPv6)
 
-	errChan := make(chan error, 1)
+	errChan := make(chan error, len(s.servers)+1)
 
-	go func() {
-		if err := s.server.ListenAndServe(); err != nil {
-			errChan <- fmt.Errorf("dns server error: %w", err)
-		}
-	}()
+	for _, server := range s.servers {
+		go func(server *dns.Server) {
+			if err := server.ListenAndServe(); err != nil {
+				errChan <- fmt.Errorf("dns server error (%s): %w", server.Net, err)
+			}
+		}(server)
+	}
+
+	if s.dotServer != nil {
+		go s.startDoT(ctx, errChan)
+	}
 
 	// Wait for context cancellation or error
 	select {
 	case <-ctx.Done():
 		s.logger.Info("dns server shutting down")
-		return s.server.Shutdown()
+		return s.shutdown()
 	case err := <-errChan:
+		s.shutdown()
 		return err
 	}
 }
 
+// shutdown stops every listener and returns the first error
+func (s *Server) shutdown() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var firstErr error
+	for _, server := range s.servers {
+		// Shutdown fails for listeners that never started, which is expected after a bind error
+		if err := server.Shutdown(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
 // handleDNSRequest handles incoming DNS queries
 func (s *Server) handleDNSRequest(w dns.ResponseWriter, r *dns.Msg) {
 	m := new(dns.Msg)
 	m.SetReply(r)
-	m.Authoritative = true
+	// Authoritative only, recursion is never offered
+	m.RecursionAvailable = false
+
+	// Every real resolver sends exactly one question (RFC 9619)
+	if len(r.Question) != 1 {
+		m.Rcode = dns.RcodeFormatError
+		s.writeResponse(w, r, m)
+		return
+	}
 
-	// Process each question
-	for _, q := range r.Question {
-		s.logger.Debug("dns query received",
-			"qname", q.Name,
-			"qtype", dns.TypeToString[q.Qtype],
-			"client", w.RemoteAddr().String())
-
-		// Check if this query is for our domain or a subdomain
-		qnameLower := strings.ToLower(strings.TrimSuffix(q.Name, "."))
-		domainLower := strings.ToLower(s.domain)
-		isOurDomain := qnameLower == domainLower || strings.HasSuffix(qnameLower, "."+domainLower)
-
-		// Normalize qname for case-insensitive comparison
-		qnameLowerForCheck := strings.ToLower(q.Name)
-
-		// Only respond to queries for our domain (except ACME challenges)
-		if !isOurDomain && !(q.Qtype == dns.TypeTXT && strings.HasPrefix(qnameLowerForCheck, "_acme-challenge.")) {
-			// Not our domain, skip this question
-			s.logger.Debug("skipping query for external domain", "qname", q.Name)
-			continue
-		}
+	q := r.Question[0]
+	s.logger.Debug("dns query received",
+		"qname", q.Name,
+		"qtype", dns.TypeToString[q.Qtype],
+		"client", w.RemoteAddr().String())
+
+	// Normalize qname for case-insensitive comparison
+	qname := strings.ToLower(dns.Fqdn(q.Name))
+	inZone := dns.IsSubDomain(s.zone, qname)
+	isACME := q.Qtype == dns.TypeTXT && strings.HasPrefix(qname, "_acme-challenge.")
+
+	// Names under a registered hook, whose sub-labels select its custom records
+	var hook *storage.Hook
+	var sub string
+	if inZone {
+		hook, sub = s.lookupHook(qname)
+	}
 
-		// Check if this is an ACME TXT challenge (case-insensitive)
-		if q.Qtype == dns.TypeTXT && strings.HasPrefix(qnameLowerForCheck, "_acme-challenge.") {
-			s.logger.Info("acme challenge request detected", "qname", q.Name)
-			if err := s.handleACMETXTChallenge(q.Name, m); err != nil {
-				s.logger.Error("failed to handle acme challenge", "error", err, "qname", q.Name)
+	// Rate limit UDP responses, over TCP the client address cannot be spoofed
+	rateLimited, record := "", true
+	if s.limiter != nil && transport(w) == "udp" {
+		verdict := s.limiter.check(w.RemoteAddr(), s.rateLimitName(qname, hook), time.Now())
+		record = verdict.record
+		if verdict.limited {
+			rateLimited = "dropped"
+			if verdict.slip {
+				rateLimited = "slipped"
 			}
-			// Don't continue processing - just return the ACME response
-			continue
+			w = &limitedResponseWriter{ResponseWriter: w, slip: verdict.slip}
+		}
+	}
+
+	// Only answer for our zone (except ACME challenges delegated to us)
+	if !inZone && !isACME {
+		s.logger.Debug("refusing query for external domain", "qname", q.Name)
+		m.Rcode = dns.RcodeRefused
+		s.writeResponse(w, r, m)
+		return
+	}
+
+	m.Authoritative = true
+
+	if isACME {
+		s.logger.Info("acme challenge request detected", "qname", q.Name)
+		if err := s.handleACMETXTChallenge(q.Name, m); err != nil {
+			s.logger.Error("failed to handle acme challenge", "error", err, "qname", q.Name)
 		}
+		if inZone {
+			s.addNoData(m)
+		}
+		s.writeResponse(w, r, m)
+		return
+	}
 
-		// Extract hook ID from domain
-		hookID := s.extractHookID(q.Name)
-		if hookID != "" {
-			// Log interaction
-			sourceIP := extractIP(w.RemoteAddr().String())
-			interaction := storage.DNSInteraction(
-				s.idGenerator(),
-				sourceIP,
-				q.Name,
-				dns.TypeToString[q.Qtype],
-			)
+	// Static names are not hooks, and the apex keeps its defaults for the
+	// types without static records
+	if s.answerStatic(q, qname, m) {
+		s.writeResponse(w, r, m)
+		return
+	}
+
+	// Extract hook ID from domain, sub-labels such as payload123.<hook> are
+	// logged under their hook as tags
+	hookID, tags := s.extractHookID(q.Name)
+	if slices.Contains(s.nameservers, qname) {
+		// Resolvers look up the zone's nameservers, which are not hooks
+		hookID, tags = "", nil
+	}
+
+	// Decoding hooks collect the payload chunk carried by the sub-labels
+	if hook != nil && hook.Exfil != nil && sub != "" {
+		s.collectExfil(hook, dns.Fqdn(q.Name), sub)
+	}
+
+	// Rebinding hooks pick their A/AAAA answer per query, which is recorded so
+	// the client can tell which address each lookup received
+	rebinding := hook != nil && hook.Rebind != nil && (q.Qtype == dns.TypeA || q.Qtype == dns.TypeAAAA)
+	var rebindRR dns.RR
+	if rebinding {
+		rebindRR = s.rebindAnswer(q, hook)
+	}
+
+	var interaction *storage.Interaction
+	if hookID != "" && !record {
+		s.limiter.dropInteraction()
+	} else if hookID != "" {
+		sourceIP := extractIP(w.RemoteAddr().String())
+		interaction = storage.DNSInteraction(
+			s.idGenerator(),
+			sourceIP,
+			q.Name,
+			dns.TypeToString[q.Qtype],
+		)
+		interaction.Tags = tags
+		captureQuery(interaction.Data, w, r)
+		if rebindRR != nil {
+			interaction.Data["answer"] = rebindAddress(rebindRR)
+		}
+		if rateLimited != "" {
+			interaction.Data["rate_limited"] = rateLimited
+		}
+	}
 
+	// Log the interaction once the response is final, so it records the
+	// answer sent, but before sending it so a client polling right after the
+	// lookup finds it
+	reply := func() {
+		s.prepareResponse(w, r, m)
+		if interaction != nil {
+			captureResponse(interaction.Data, m)
 			if err := s.storage.AddInteraction(hookID, interaction); err != nil {
 				s.logger.Error("failed to store dns interaction", "error", err)
 			}
 		}
+		s.sendResponse(w, m)
+	}
 
-		// Respond based on query type
-		switch q.Qtype {
-		case dns.TypeA:
-			rr := &dns.A{
-				Hdr: dns.RR_Header{
-					Name:   q.Name,
-					Rrtype: dns.TypeA,
-					Class:  dns.ClassINET,
-					Ttl:    60,
-				},
-				A: net.ParseIP(s.serverIP),
-			}
-			m.Answer = append(m.Answer, rr)
-
-		case dns.TypeAAAA:
-			// Respond with empty answer for IPv6
-			// Could add IPv6 support here if needed
-
-		case dns.TypeTXT:
-			s.logger.Info("responding with default TXT record",
-				"qname", q.Name,
-				"is_acme", strings.HasPrefix(q.Name, "_acme-challenge."),
-				"response", "hookd interaction server")
-			rr := &dns.TXT{
-				Hdr: dns.RR_Header{
-					Name:   q.Name,
-					Rrtype: dns.TypeTXT,
-					Class:  dns.ClassINET,
-					Ttl:    60,
-				},
-				Txt: []string{"hookd interaction server"},
-			}
-			m.Answer = append(m.Answer, rr)
-
-		case dns.TypeNS:
-			rr := &dns.NS{
-				Hdr: dns.RR_Header{
-					Name:   q.Name,
-					Rrtype: dns.TypeNS,
-					Class:  dns.ClassINET,
-					Ttl:    60,
-				},
-				Ns: s.domain + ".",
-			}
-			m.Answer = append(m.Answer, rr)
-
-		case dns.TypeMX:
-			rr := &dns.MX{
-				Hdr: dns.RR_Header{
-					Name:   q.Name,
-					Rrtype: dns.TypeMX,
-					Class:  dns.ClassINET,
-					Ttl:    60,
-				},
-				Preference: 10,
-				Mx:         s.domain + ".",
-			}
-			m.Answer = append(m.Answer, rr)
+	if rebinding {
+		if rebindRR != nil {
+			m.Answer = append(m.Answer, rebindRR)
+		} else {
+			m.Ns = append(m.Ns, s.soa())
+		}
+		reply()
+		return
+	}
+
+	if hook != nil {
+		if records := matchingRecords(hook.DNSRecords, sub); len(records) > 0 {
+			s.answerCustom(q, m, records)
+			reply()
+			return
+		}
+	}
+
+	if !s.nameExists(qname) {
+		m.Rcode = dns.RcodeNameError
+		m.Ns = append(m.Ns, s.soa())
+		reply()
+		return
+	}
+
+	isApex := qname == s.zone
+
+	// Respond based on query type
+	switch q.Qtype {
+	case dns.TypeA, dns.TypeAAAA:
+		// Empty answer when the server has no address of that family
+		m.Answer = append(m.Answer, s.addressRecords(q.Name, q.Qtype)...)
+
+	case dns.TypeTXT:
+		s.logger.Info("responding with default TXT record",
+			"qname", q.Name,
+			"response", "hookd interaction server")
+		rr := &dns.TXT{
+			Hdr: dns.RR_Header{
+				Name:   q.Name,
+				Rrtype: dns.TypeTXT,
+				Class:  dns.ClassINET,
+				Ttl:    recordTTL,
+			},
+			Txt: []string{"hookd interaction server"},
+		}
+		m.Answer = append(m.Answer, rr)
+
+	case dns.TypeSOA:
+		if isApex {
+			m.Answer = append(m.Answer, s.soa())
+		}
+
+	case dns.TypeNS:
+		// Hook names are not delegated, only the apex has NS records
+		if isApex {
+			m.Answer = append(m.Answer, s.nsRecords()...)
+			m.Extra = append(m.Extra, s.glueRecords()...)
+		}
+
+	case dns.TypeMX:
+		rr := &dns.MX{
+			Hdr: dns.RR_Header{
+				Name:   q.Name,
+				Rrtype: dns.TypeMX,
+				Class:  dns.ClassINET,
+				Ttl:    recordTTL,
+			},
+			Preference: 10,
+			Mx:         s.zone,
+		}
+		m.Answer = append(m.Answer, rr)
+	}
 
-		default:
-			// For other types, return empty answer
+	s.addNoData(m)
+	reply()
+}
+
+// writeResponse sends a reply, echoing EDNS0 and truncating UDP answers that
+// exceed the client's buffer size so it can retry over TCP
+func (s *Server) writeResponse(w dns.ResponseWriter, r *dns.Msg, m *dns.Msg) {
+	s.prepareResponse(w, r, m)
+	s.sendResponse(w, m)
+}
+
+// prepareResponse applies EDNS0 and truncation to a reply before it is sent
+func (s *Server) prepareResponse(w dns.ResponseWriter, r *dns.Msg, m *dns.Msg) {
+	size := dns.MinMsgSize
+	if opt := r.IsEdns0(); opt != nil {
+		if opt.Version() != 0 {
+			// Only EDNS version 0 exists (RFC 6891)
+			m.Answer, m.Ns, m.Extra = nil, nil, nil
+			m.Rcode = dns.RcodeBadVers
 		}
+
+		m.SetEdns0(ednsBufferSize, opt.Do())
+		size = max(int(opt.UDPSize()), dns.MinMsgSize)
 	}
 
+	if transport(w) == "udp" {
+		m.Truncate(min(size, ednsBufferSize))
+	} else {
+		m.Truncate(dns.MaxMsgSize)
+	}
+}
+
+// sendResponse writes a prepared reply
+func (s *Server) sendResponse(w dns.ResponseWriter, m *dns.Msg) {
 	if err := w.WriteMsg(m); err != nil {
 		s.logger.Error("failed to write dns response", "error", err)
 	}
 }
 
+// transport returns the network a query arrived on ("udp", "tcp", "dot" or "doh")
+func transport(w dns.ResponseWriter) string {
+	if _, ok := w.(*dohResponseWriter); ok {
+		return "doh"
+	}
+	if stater, ok := w.(dns.ConnectionStater); ok && stater.ConnectionState() != nil {
+		return "dot"
+	}
+	if _, ok := w.RemoteAddr().(*net.TCPAddr); ok {
+		return "tcp"
+	}
+	return "udp"
+}
+
 // handleACMETXTChallenge handles ACME DNS-01 challenge TXT queries
 func (s *Server) handleACMETXTChallenge(qname string, m *dns.Msg) error {
 	// Normalize the query name to lowercase without trailing dot
@@ -278,40 +511,68 @@ func (s *Server) handleACMETXTChallenge(qname string, m *dns.Msg) error {
 	return nil
 }
 
-// extractHookID extracts the hook ID from a domain name
-// Example: abc123.hookd.jomar.ovh -> abc123
-func (s *Server) extractHookID(qname string) string {
-	// Remove trailing dot
-	qname = strings.TrimSuffix(qname, ".")
-
-	// Check if it's a subdomain of our domain
-	suffix := "." + s.domain
-	if !strings.HasSuffix(qname, suffix) {
-		return ""
+// IsReservedLabel reports whether label, used as a hook ID, would be shadowed
+// by a name the server answers itself: a nameserver or a static name
+func (s *Server) IsReservedLabel(label string) bool {
+	name := strings.ToLower(label) + "." + s.zone
+	for _, ns := range s.nameservers {
+		if ns == name || strings.HasSuffix(ns, "."+name) {
+			return true
+		}
 	}
 
-	// Extract the subdomain part
-	subdomain := strings.TrimSuffix(qname, suffix)
+	_, ok := s.static.records[name]
+	return ok || s.static.parents[name]
+}
 
-	// Handle multi-level subdomains (take the first part)
-	parts := strings.Split(subdomain, ".")
-	if len(parts) == 0 {
-		return ""
+// extractHookID extracts the hook ID from a domain name: the label directly
+// left of the domain. Labels further left are returned as correlation tags,
+// so payload123.abc123.<domain> belongs to hook abc123 with tag payload123
+func (s *Server) extractHookID(qname string) (string, []string) {
+	qname = strings.ToLower(strings.TrimSuffix(qname, "."))
+
+	// Check if it's a subdomain of our domain
+	subdomain, ok := strings.CutSuffix(qname, "."+strings.ToLower(s.domain))
+	if !ok || subdomain == "" {
+		return "", nil
 	}
 
-	return parts[0]
+	labels := strings.Split(subdomain, ".")
+	last := len(labels) - 1
+	if last == 0 {
+		return labels[0], nil
+	}
+	return labels[last], labels[:last]
 }
 
-// getOutboundIP gets the preferred outbound IP of this machine
-func getOutboundIP() (string, error) {
-	conn, err := net.Dial("udp", "8.8.8.8:80")
-	if err != nil {
-		return "", err
+// resolvePublicIP returns the configured address, or detects one with the
+// given strategies when none is set
+func resolvePublicIP(configured, family string, strategies []string, logger *slog.Logger) (string, error) {
+	ip, source := configured, "config"
+	if ip == "" {
+		detected, strategy, err := detectPublicIP(context.Background(), family, strategies)
+		if err != nil {
+			return "", err
+		}
+
+		// A detected private address is the NAT case detection cannot solve,
+		// serving it would send every callback elsewhere
+		if parsed := net.ParseIP(detected); parsed.IsPrivate() || parsed.IsLoopback() {
+			return "", fmt.Errorf("%s detected %s, which is not publicly routable: set it explicitly", strategy, detected)
+		}
+		ip, source = detected, strategy
+	}
+
+	// A private address is only reachable from the same network as this server
+	if parsed := net.ParseIP(ip); parsed.IsPrivate() || parsed.IsLoopback() {
+		logger.Warn("public ip is not publicly routable, remote callbacks will not reach this server",
+			"ip", ip,
+			"source", source)
+	} else {
+		logger.Info("public ip resolved", "ip", ip, "source", source)
 	}
-	defer conn.Close()
 
-	localAddr := conn.LocalAddr().(*net.UDPAddr)
-	return localAddr.IP.String(), nil
+	return ip, nil
 }
 
 // extractIP extracts the IP address from a remote address string
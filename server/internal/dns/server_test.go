@@ -12,16 +12,30 @@ import (
 	"github.com/miekg/dns"
 
 	"github.com/jomar/hookd/internal/acme"
+	"github.com/jomar/hookd/internal/config"
 	"github.com/jomar/hookd/internal/storage"
 )
 
+// testConfig returns a server config for example.com with the DNS server on
+// port and a fixed public IPv4, so tests never depend on IP detection
+func testConfig(port int) config.ServerConfig {
+	return config.ServerConfig{
+		Domain: "example.com",
+		DNS: config.DNSConfig{
+			Enabled:  true,
+			Port:     port,
+			PublicIP: "203.0.113.10",
+		},
+	}
+}
+
 func TestNewServer(t *testing.T) {
 	idGen := func() string { return "test-id" }
 	manager := storage.NewMemoryManager(idGen)
 	acmeProvider := acme.NewProvider(slog.Default())
 	logger := slog.Default()
 
-	server, err := NewServer("example.com", 5353, manager, acmeProvider, logger, idGen)
+	server, err := NewServer(testConfig(5353), manager, acmeProvider, logger, idGen)
 	if err != nil {
 		t.Fatalf("failed to create server: %v", err)
 	}
@@ -46,21 +60,27 @@ func TestServer_ExtractHookID(t *testing.T) {
 		name     string
 		qname    string
 		expected string
+		tags     []string
 	}{
-		{"valid subdomain", "abc123.example.com.", "abc123"},
-		{"no trailing dot", "abc123.example.com", "abc123"},
-		{"exact domain", "example.com.", ""},
-		{"multi-level subdomain", "sub.abc123.example.com.", "sub"},
-		{"external domain", "other.com.", ""},
-		{"no subdomain", "example.com", ""},
+		{"valid subdomain", "abc123.example.com.", "abc123", nil},
+		{"no trailing dot", "abc123.example.com", "abc123", nil},
+		{"exact domain", "example.com.", "", nil},
+		{"multi-level subdomain", "sub.abc123.example.com.", "abc123", []string{"sub"}},
+		{"several tags", "Payload123.Param.ABC123.example.com.", "abc123", []string{"payload123", "param"}},
+		{"external domain", "other.com.", "", nil},
+		{"suffix without dot", "abc123example.com.", "", nil},
+		{"no subdomain", "example.com", "", nil},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := server.extractHookID(tt.qname)
+			result, tags := server.extractHookID(tt.qname)
 			if result != tt.expected {
 				t.Errorf("extractHookID(%q) = %q, want %q", tt.qname, result, tt.expected)
 			}
+			if strings.Join(tags, ".") != strings.Join(tt.tags, ".") {
+				t.Errorf("extractHookID(%q) tags = %v, want %v", tt.qname, tags, tt.tags)
+			}
 		})
 	}
 }
@@ -86,29 +106,13 @@ func TestExtractIP(t *testing.T) {
 	}
 }
 
-func TestGetOutboundIP(t *testing.T) {
-	ip, err := getOutboundIP()
-	if err != nil {
-		t.Fatalf("failed to get outbound IP: %v", err)
-	}
-
-	if ip == "" {
-		t.Error("expected non-empty IP")
-	}
-
-	// Verify it's a valid IP
-	if net.ParseIP(ip) == nil {
-		t.Errorf("invalid IP address: %s", ip)
-	}
-}
-
 func TestServer_HandleDNSRequest_TypeA(t *testing.T) {
 	idGen := func() string { return "test-id" }
 	manager := storage.NewMemoryManager(idGen)
 	acmeProvider := acme.NewProvider(slog.Default())
 	logger := slog.Default()
 
-	server, _ := NewServer("example.com", 5353, manager, acmeProvider, logger, idGen)
+	server, _ := NewServer(testConfig(5353), manager, acmeProvider, logger, idGen)
 
 	// Create a hook
 	hook := manager.CreateHook("example.com")
@@ -150,13 +154,103 @@ func TestServer_HandleDNSRequest_TypeA(t *testing.T) {
 	}
 }
 
+func TestNewServer_PublicIPs(t *testing.T) {
+	idGen := func() string { return "test-id" }
+	manager := storage.NewMemoryManager(idGen)
+	acmeProvider := acme.NewProvider(slog.Default())
+	logger := slog.Default()
+
+	cfg := testConfig(5353)
+	cfg.DNS.PublicIPv6 = "2001:db8::10"
+	cfg.DNS.Bind = "::1"
+
+	server, err := NewServer(cfg, manager, acmeProvider, logger, idGen)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	if server.serverIP != "203.0.113.10" {
+		t.Errorf("expected server IP 203.0.113.10, got %s", server.serverIP)
+	}
+
+	if server.serverIPv6 != "2001:db8::10" {
+		t.Errorf("expected server IPv6 2001:db8::10, got %s", server.serverIPv6)
+	}
+
+	for _, srv := range server.servers {
+		if srv.Addr != "[::1]:5353" {
+			t.Errorf("expected %s listener on [::1]:5353, got %s", srv.Net, srv.Addr)
+		}
+	}
+}
+
+func TestNewServer_NoPublicIP(t *testing.T) {
+	idGen := func() string { return "test-id" }
+	manager := storage.NewMemoryManager(idGen)
+	acmeProvider := acme.NewProvider(slog.Default())
+	logger := slog.Default()
+
+	cfg := testConfig(5353)
+	cfg.DNS.PublicIP = ""
+
+	if _, err := NewServer(cfg, manager, acmeProvider, logger, idGen); err == nil {
+		t.Error("expected error without public IP or detection strategy")
+	}
+}
+
+func TestServer_HandleDNSRequest_TypeAAAA(t *testing.T) {
+	idGen := func() string { return "test-id" }
+	manager := storage.NewMemoryManager(idGen)
+	acmeProvider := acme.NewProvider(slog.Default())
+	logger := slog.Default()
+
+	t.Run("with IPv6", func(t *testing.T) {
+		cfg := testConfig(5353)
+		cfg.DNS.PublicIPv6 = "2001:db8::10"
+		server, _ := NewServer(cfg, manager, acmeProvider, logger, idGen)
+
+		m := new(dns.Msg)
+		m.SetQuestion("test.example.com.", dns.TypeAAAA)
+
+		w := &mockResponseWriter{remoteAddr: &net.UDPAddr{IP: net.ParseIP("2001:db8::1"), Port: 12345}}
+		server.handleDNSRequest(w, m)
+
+		if len(w.msg.Answer) != 1 {
+			t.Fatalf("expected 1 answer, got %d", len(w.msg.Answer))
+		}
+
+		aaaaRecord, ok := w.msg.Answer[0].(*dns.AAAA)
+		if !ok {
+			t.Fatal("expected AAAA record")
+		}
+
+		if aaaaRecord.AAAA.String() != "2001:db8::10" {
+			t.Errorf("expected IP 2001:db8::10, got %s", aaaaRecord.AAAA.String())
+		}
+	})
+
+	t.Run("without IPv6", func(t *testing.T) {
+		server, _ := NewServer(testConfig(5353), manager, acmeProvider, logger, idGen)
+
+		m := new(dns.Msg)
+		m.SetQuestion("test.example.com.", dns.TypeAAAA)
+
+		w := &mockResponseWriter{remoteAddr: &net.UDPAddr{IP: net.ParseIP("1.2.3.4"), Port: 12345}}
+		server.handleDNSRequest(w, m)
+
+		if len(w.msg.Answer) != 0 {
+			t.Errorf("expected 0 answers, got %d", len(w.msg.Answer))
+		}
+	})
+}
+
 func TestServer_HandleDNSRequest_TypeTXT(t *testing.T) {
 	idGen := func() string { return "test-id" }
 	manager := storage.NewMemoryManager(idGen)
 	acmeProvider := acme.NewProvider(slog.Default())
 	logger := slog.Default()
 
-	server, _ := NewServer("example.com", 5353, manager, acmeProvider, logger, idGen)
+	server, _ := NewServer(testConfig(5353), manager, acmeProvider, logger, idGen)
 
 	// Create DNS query for TXT record
 	m := new(dns.Msg)
@@ -190,7 +284,7 @@ func TestServer_HandleDNSRequest_ExternalDomain(t *testing.T) {
 	acmeProvider := acme.NewProvider(slog.Default())
 	logger := slog.Default()
 
-	server, _ := NewServer("example.com", 5353, manager, acmeProvider, logger, idGen)
+	server, _ := NewServer(testConfig(5353), manager, acmeProvider, logger, idGen)
 
 	// Create DNS query for external domain
 	m := new(dns.Msg)
@@ -208,6 +302,14 @@ func TestServer_HandleDNSRequest_ExternalDomain(t *testing.T) {
 	if len(w.msg.Answer) != 0 {
 		t.Fatalf("expected 0 answers for external domain, got %d", len(w.msg.Answer))
 	}
+
+	if w.msg.Rcode != dns.RcodeRefused {
+		t.Errorf("expected REFUSED, got %s", dns.RcodeToString[w.msg.Rcode])
+	}
+
+	if w.msg.Authoritative {
+		t.Error("expected AA flag to be clear for external domain")
+	}
 }
 
 func TestServer_HandleDNSRequest_TypeNS(t *testing.T) {
@@ -216,7 +318,7 @@ func TestServer_HandleDNSRequest_TypeNS(t *testing.T) {
 	acmeProvider := acme.NewProvider(slog.Default())
 	logger := slog.Default()
 
-	server, _ := NewServer("example.com", 5353, manager, acmeProvider, logger, idGen)
+	server, _ := NewServer(testConfig(5353), manager, acmeProvider, logger, idGen)
 
 	m := new(dns.Msg)
 	m.SetQuestion("example.com.", dns.TypeNS)
@@ -225,17 +327,154 @@ func TestServer_HandleDNSRequest_TypeNS(t *testing.T) {
 
 	server.handleDNSRequest(w, m)
 
-	if len(w.msg.Answer) != 1 {
-		t.Fatalf("expected 1 answer, got %d", len(w.msg.Answer))
+	if len(w.msg.Answer) != 2 {
+		t.Fatalf("expected 2 answers, got %d", len(w.msg.Answer))
 	}
 
-	nsRecord, ok := w.msg.Answer[0].(*dns.NS)
-	if !ok {
-		t.Fatal("expected NS record")
+	for i, want := range []string{"ns1.example.com.", "ns2.example.com."} {
+		nsRecord, ok := w.msg.Answer[i].(*dns.NS)
+		if !ok {
+			t.Fatal("expected NS record")
+		}
+
+		if nsRecord.Ns != want {
+			t.Errorf("expected NS %s, got %s", want, nsRecord.Ns)
+		}
+	}
+
+	// Glue A records for both in-zone nameservers
+	if len(w.msg.Extra) != 2 {
+		t.Fatalf("expected 2 glue records, got %d", len(w.msg.Extra))
+	}
+
+	for _, rr := range w.msg.Extra {
+		a, ok := rr.(*dns.A)
+		if !ok {
+			t.Fatalf("expected glue A record, got %T", rr)
+		}
+
+		if a.A.String() != server.serverIP {
+			t.Errorf("expected glue IP %s, got %s", server.serverIP, a.A.String())
+		}
+	}
+}
+
+func TestServer_HandleDNSRequest_Zone(t *testing.T) {
+	idGen := func() string { return "test-id" }
+	manager := storage.NewMemoryManager(idGen)
+	acmeProvider := acme.NewProvider(slog.Default())
+	logger := slog.Default()
+
+	cfg := testConfig(5353)
+	cfg.DNS.PublicIPv6 = "2001:db8::10"
+	cfg.DNS.Nameservers = []string{"ns1.example.com", "ns.example.net"}
+	server, _ := NewServer(cfg, manager, acmeProvider, logger, idGen)
+
+	tests := []struct {
+		name      string
+		qname     string
+		qtype     uint16
+		rcode     int
+		answers   int
+		authority bool // SOA expected in the authority section
+		extra     int
+	}{
+		{"apex SOA", "example.com.", dns.TypeSOA, dns.RcodeSuccess, 1, false, 0},
+		{"apex NS with glue for in-zone nameserver only", "EXAMPLE.com.", dns.TypeNS, dns.RcodeSuccess, 2, false, 2},
+		{"apex A", "example.com.", dns.TypeA, dns.RcodeSuccess, 1, false, 0},
+		{"hook NS is nodata", "abc123.example.com.", dns.TypeNS, dns.RcodeSuccess, 0, true, 0},
+		{"hook SOA is nodata", "abc123.example.com.", dns.TypeSOA, dns.RcodeSuccess, 0, true, 0},
+		{"unsupported type is nodata", "abc123.example.com.", dns.TypeCAA, dns.RcodeSuccess, 0, true, 0},
+		{"underscore label is nxdomain", "_dmarc.example.com.", dns.TypeTXT, dns.RcodeNameError, 0, true, 0},
+		{"acme without records is nodata", "_acme-challenge.example.com.", dns.TypeTXT, dns.RcodeSuccess, 0, true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := new(dns.Msg)
+			m.SetQuestion(tt.qname, tt.qtype)
+
+			w := &mockResponseWriter{remoteAddr: &net.UDPAddr{IP: net.ParseIP("1.2.3.4"), Port: 12345}}
+			server.handleDNSRequest(w, m)
+
+			if w.msg.Rcode != tt.rcode {
+				t.Errorf("expected rcode %s, got %s", dns.RcodeToString[tt.rcode], dns.RcodeToString[w.msg.Rcode])
+			}
+
+			if !w.msg.Authoritative {
+				t.Error("expected AA flag to be set")
+			}
+
+			if w.msg.RecursionAvailable {
+				t.Error("expected RA flag to be clear")
+			}
+
+			if len(w.msg.Answer) != tt.answers {
+				t.Errorf("expected %d answers, got %d", tt.answers, len(w.msg.Answer))
+			}
+
+			if len(w.msg.Extra) != tt.extra {
+				t.Errorf("expected %d additional records, got %d", tt.extra, len(w.msg.Extra))
+			}
+
+			hasSOA := len(w.msg.Ns) == 1 && w.msg.Ns[0].Header().Rrtype == dns.TypeSOA
+			if hasSOA != tt.authority {
+				t.Errorf("expected SOA in authority = %v, got %v", tt.authority, w.msg.Ns)
+			}
+		})
 	}
 
-	if !strings.HasPrefix(nsRecord.Ns, server.domain) {
-		t.Errorf("expected NS to contain domain %s, got %s", server.domain, nsRecord.Ns)
+	t.Run("SOA fields", func(t *testing.T) {
+		soa := server.soa()
+		if soa.Hdr.Name != "example.com." {
+			t.Errorf("expected SOA owner example.com., got %s", soa.Hdr.Name)
+		}
+
+		if soa.Ns != "ns1.example.com." {
+			t.Errorf("expected primary NS ns1.example.com., got %s", soa.Ns)
+		}
+
+		if soa.Mbox != "hostmaster.example.com." {
+			t.Errorf("expected mbox hostmaster.example.com., got %s", soa.Mbox)
+		}
+
+		if soa.Minttl != recordTTL {
+			t.Errorf("expected negative TTL %d, got %d", recordTTL, soa.Minttl)
+		}
+	})
+
+	t.Run("multiple questions", func(t *testing.T) {
+		m := new(dns.Msg)
+		m.SetQuestion("example.com.", dns.TypeA)
+		m.Question = append(m.Question, dns.Question{Name: "example.com.", Qtype: dns.TypeAAAA, Qclass: dns.ClassINET})
+
+		w := &mockResponseWriter{remoteAddr: &net.UDPAddr{IP: net.ParseIP("1.2.3.4"), Port: 12345}}
+		server.handleDNSRequest(w, m)
+
+		if w.msg.Rcode != dns.RcodeFormatError {
+			t.Errorf("expected FORMERR, got %s", dns.RcodeToString[w.msg.Rcode])
+		}
+	})
+}
+
+func TestIsHostLabel(t *testing.T) {
+	tests := []struct {
+		label string
+		want  bool
+	}{
+		{"abc123", true},
+		{"my-hook", true},
+		{"_dmarc", false},
+		{"-abc", false},
+		{"abc-", false},
+		{"", false},
+		{strings.Repeat("a", 64), false},
+	}
+
+	for _, tt := range tests {
+		if got := isHostLabel(tt.label); got != tt.want {
+			t.Errorf("isHostLabel(%q) = %v, want %v", tt.label, got, tt.want)
+		}
 	}
 }
 
@@ -245,7 +484,7 @@ func TestServer_HandleDNSRequest_TypeMX(t *testing.T) {
 	acmeProvider := acme.NewProvider(slog.Default())
 	logger := slog.Default()
 
-	server, _ := NewServer("example.com", 5353, manager, acmeProvider, logger, idGen)
+	server, _ := NewServer(testConfig(5353), manager, acmeProvider, logger, idGen)
 
 	m := new(dns.Msg)
 	m.SetQuestion("example.com.", dns.TypeMX)
@@ -274,7 +513,7 @@ func TestServer_HandleACMETXTChallenge(t *testing.T) {
 	acmeProvider := acme.NewProvider(slog.Default())
 	logger := slog.Default()
 
-	server, _ := NewServer("example.com", 5353, manager, acmeProvider, logger, idGen)
+	server, _ := NewServer(testConfig(5353), manager, acmeProvider, logger, idGen)
 
 	t.Run("valid ACME challenge", func(t *testing.T) {
 		// Add ACME record to provider
@@ -333,6 +572,148 @@ func TestServer_HandleACMETXTChallenge(t *testing.T) {
 	})
 }
 
+func TestServer_HandleDNSRequest_Transport(t *testing.T) {
+	ids := []string{"udp-hook", "tcp-hook"}
+	idGen := func() string {
+		id := ids[0]
+		ids = ids[1:]
+		return id
+	}
+	manager := storage.NewMemoryManager(idGen)
+	acmeProvider := acme.NewProvider(slog.Default())
+	logger := slog.Default()
+
+	server, _ := NewServer(testConfig(5353), manager, acmeProvider, logger, func() string { return "int-id" })
+
+	tests := []struct {
+		name       string
+		remoteAddr net.Addr
+		want       string
+	}{
+		{"udp", &net.UDPAddr{IP: net.ParseIP("1.2.3.4"), Port: 12345}, "udp"},
+		{"tcp", &net.TCPAddr{IP: net.ParseIP("1.2.3.4"), Port: 12345}, "tcp"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hook := manager.CreateHook("example.com")
+
+			m := new(dns.Msg)
+			m.SetQuestion(hook.ID+".example.com.", dns.TypeA)
+
+			w := &mockResponseWriter{remoteAddr: tt.remoteAddr}
+			server.handleDNSRequest(w, m)
+
+			interactions, _ := manager.PollInteractions(hook.ID)
+			if len(interactions) != 1 {
+				t.Fatalf("expected 1 interaction, got %d", len(interactions))
+			}
+
+			if got := interactions[0].Data["transport"]; got != tt.want {
+				t.Errorf("expected transport %s, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestServer_WriteResponse_EDNS(t *testing.T) {
+	idGen := func() string { return "test-id" }
+	manager := storage.NewMemoryManager(idGen)
+	acmeProvider := acme.NewProvider(slog.Default())
+	logger := slog.Default()
+
+	server, _ := NewServer(testConfig(5353), manager, acmeProvider, logger, idGen)
+
+	// bigResponse builds an answer larger than any UDP buffer size used below
+	bigResponse := func(r *dns.Msg) *dns.Msg {
+		m := new(dns.Msg)
+		m.SetReply(r)
+		for i := 0; i < 50; i++ {
+			m.Answer = append(m.Answer, &dns.TXT{
+				Hdr: dns.RR_Header{Name: r.Question[0].Name, Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: 60},
+				Txt: []string{strings.Repeat("a", 100)},
+			})
+		}
+		return m
+	}
+
+	udpAddr := &net.UDPAddr{IP: net.ParseIP("1.2.3.4"), Port: 12345}
+	tcpAddr := &net.TCPAddr{IP: net.ParseIP("1.2.3.4"), Port: 12345}
+
+	t.Run("udp without edns truncates to 512 bytes", func(t *testing.T) {
+		r := new(dns.Msg)
+		r.SetQuestion("test.example.com.", dns.TypeTXT)
+
+		w := &mockResponseWriter{remoteAddr: udpAddr}
+		server.writeResponse(w, r, bigResponse(r))
+
+		if !w.msg.Truncated {
+			t.Error("expected TC bit to be set")
+		}
+		if w.msg.Len() > dns.MinMsgSize {
+			t.Errorf("expected response <= %d bytes, got %d", dns.MinMsgSize, w.msg.Len())
+		}
+		if w.msg.IsEdns0() != nil {
+			t.Error("expected no OPT record when query has none")
+		}
+	})
+
+	t.Run("udp with edns honours buffer size", func(t *testing.T) {
+		r := new(dns.Msg)
+		r.SetQuestion("test.example.com.", dns.TypeTXT)
+		r.SetEdns0(4096, false)
+
+		w := &mockResponseWriter{remoteAddr: udpAddr}
+		server.writeResponse(w, r, bigResponse(r))
+
+		if !w.msg.Truncated {
+			t.Error("expected TC bit to be set")
+		}
+		if w.msg.Len() > ednsBufferSize {
+			t.Errorf("expected response <= %d bytes, got %d", ednsBufferSize, w.msg.Len())
+		}
+		opt := w.msg.IsEdns0()
+		if opt == nil {
+			t.Fatal("expected OPT record in response")
+		}
+		if opt.UDPSize() != ednsBufferSize {
+			t.Errorf("expected advertised size %d, got %d", ednsBufferSize, opt.UDPSize())
+		}
+	})
+
+	t.Run("tcp is not truncated", func(t *testing.T) {
+		r := new(dns.Msg)
+		r.SetQuestion("test.example.com.", dns.TypeTXT)
+
+		w := &mockResponseWriter{remoteAddr: tcpAddr}
+		server.writeResponse(w, r, bigResponse(r))
+
+		if w.msg.Truncated {
+			t.Error("expected TC bit to be clear")
+		}
+		if len(w.msg.Answer) != 50 {
+			t.Errorf("expected 50 answers, got %d", len(w.msg.Answer))
+		}
+	})
+
+	t.Run("unsupported edns version", func(t *testing.T) {
+		r := new(dns.Msg)
+		r.SetQuestion("test.example.com.", dns.TypeTXT)
+		r.SetEdns0(4096, false)
+		r.IsEdns0().SetVersion(1)
+
+		w := &mockResponseWriter{remoteAddr: udpAddr}
+		server.writeResponse(w, r, bigResponse(r))
+
+		if w.msg.Rcode != dns.RcodeBadVers {
+			t.Errorf("expected BADVERS, got %s", dns.RcodeToString[w.msg.Rcode])
+		}
+		if len(w.msg.Answer) != 0 {
+			t.Errorf("expected 0 answers, got %d", len(w.msg.Answer))
+		}
+	})
+}
+
 // Mock DNS ResponseWriter for testing
 type mockResponseWriter struct {
 	remoteAddr net.Addr
@@ -375,7 +756,7 @@ func TestServer_Start(t *testing.T) {
 	logger := slog.Default()
 
 	// Use high port to avoid permission issues
-	server, err := NewServer("example.com", 15353, manager, acmeProvider, logger, idGen)
+	server, err := NewServer(testConfig(15353), manager, acmeProvider, logger, idGen)
 	if err != nil {
 		t.Fatalf("failed to create server: %v", err)
 	}
@@ -405,6 +786,17 @@ func TestServer_Start(t *testing.T) {
 		t.Error("expected DNS answer, got none")
 	}
 
+	// The same port must also answer over TCP
+	tcpClient := &dns.Client{Net: "tcp"}
+	r, _, err = tcpClient.Exchange(m, "127.0.0.1:15353")
+	if err != nil {
+		t.Fatalf("failed to query DNS server over tcp: %v", err)
+	}
+
+	if len(r.Answer) == 0 {
+		t.Error("expected DNS answer over tcp, got none")
+	}
+
 	// Stop server
 	cancel()
 
@@ -424,7 +816,7 @@ func TestServer_StartContextCancelled(t *testing.T) {
 	acmeProvider := acme.NewProvider(slog.Default())
 	logger := slog.Default()
 
-	server, err := NewServer("example.com", 15354, manager, acmeProvider, logger, idGen)
+	server, err := NewServer(testConfig(15354), manager, acmeProvider, logger, idGen)
 	if err != nil {
 		t.Fatalf("failed to create server: %v", err)
 	}
@@ -449,3 +841,52 @@ func TestServer_StartContextCancelled(t *testing.T) {
 		t.Fatal("server did not stop after context cancellation")
 	}
 }
+
+func TestServer_HandleDNSRequest_Orphans(t *testing.T) {
+	manager := storage.NewMemoryManager(func() string { return "abc123" })
+	manager.SetOrphans(storage.NewOrphanStore(time.Hour, 10, 1<<20))
+	acmeProvider := acme.NewProvider(slog.Default())
+	logger := slog.Default()
+
+	server, _ := NewServer(testConfig(5353), manager, acmeProvider, logger, func() string { return "int-id" })
+
+	query(server, "tag.expired.example.com.", dns.TypeA)
+	query(server, "ns1.example.com.", dns.TypeA)
+
+	// Nameserver lookups are not hook interactions
+	orphans := manager.Orphans().Search(nil, nil, 0)
+	if len(orphans) != 1 {
+		t.Fatalf("expected 1 orphan, got %d", len(orphans))
+	}
+
+	if orphans[0].HookID != "expired" || len(orphans[0].Tags) != 1 || orphans[0].Tags[0] != "tag" {
+		t.Errorf("expected orphan of hook expired tagged tag, got %+v", orphans[0])
+	}
+}
+
+func TestNewServer_PublicIPSkipsDetection(t *testing.T) {
+	original := ipDetectors
+	defer func() { ipDetectors = original }()
+
+	ipDetectors = map[string]ipDetector{
+		"fixed": func(_ context.Context, family string) (string, error) {
+			if family == "ip6" {
+				return "2001:db8::10", nil
+			}
+			return "203.0.113.20", nil
+		},
+	}
+
+	manager := storage.NewMemoryManager(func() string { return "test-id" })
+	cfg := testConfig(5353)
+	cfg.DNS.IPDetection = []string{"fixed"}
+
+	server, err := NewServer(cfg, manager, acme.NewProvider(slog.Default()), slog.Default(), func() string { return "int-id" })
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	if server.serverIP != "203.0.113.10" || server.serverIPv6 != "" {
+		t.Errorf("expected the configured IPv4 only, got %q and %q", server.serverIP, server.serverIPv6)
+	}
+}
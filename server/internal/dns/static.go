@@ -0,0 +1,180 @@
+package dns
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/miekg/dns"
+
+	"github.com/jomar/hookd/internal/config"
+)
+
+// staticTTL is the TTL of static records that do not set one. They only
+// change with the configuration, unlike hook answers
+const staticTTL = 3600
+
+// staticZone holds the records published from the configuration, keyed by
+// lowercase owner name
+type staticZone struct {
+	records map[string][]dns.RR
+	parents map[string]bool // empty non-terminals, names with records only below them
+}
+
+// loadStaticZone parses the static records of the configuration and of the
+// zone file, if any. Names are relative to zone, the SOA and NS records of
+// the apex are skipped since hookd serves its own
+func loadStaticZone(zone string, records []config.StaticRecord, zoneFile string) (*staticZone, error) {
+	z := &staticZone{
+		records: make(map[string][]dns.RR),
+		parents: make(map[string]bool),
+	}
+
+	for _, rec := range records {
+		rr, err := staticRR(zone, rec)
+		if err != nil {
+			return nil, err
+		}
+		if err := z.add(zone, rr); err != nil {
+			return nil, err
+		}
+	}
+
+	if zoneFile != "" {
+		if err := z.loadFile(zone, zoneFile); err != nil {
+			return nil, err
+		}
+	}
+
+	for name, rrs := range z.records {
+		for _, rr := range rrs {
+			if rr.Header().Rrtype == dns.TypeCNAME && len(rrs) > 1 {
+				return nil, fmt.Errorf("static record %s: a CNAME cannot coexist with other records", name)
+			}
+		}
+
+		// Every name between a record and the apex exists, even without records
+		for parent := name; parent != zone; {
+			i := strings.Index(parent, ".")
+			parent = parent[i+1:]
+			if _, ok := z.records[parent]; !ok && parent != zone {
+				z.parents[parent] = true
+			}
+		}
+	}
+
+	return z, nil
+}
+
+// loadFile adds the records of an RFC 1035 zone file, whose $ORIGIN defaults
+// to zone
+func (z *staticZone) loadFile(zone, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open zone file: %w", err)
+	}
+	defer f.Close()
+
+	parser := dns.NewZoneParser(f, zone, path)
+	parser.SetDefaultTTL(staticTTL)
+	for rr, ok := parser.Next(); ok; rr, ok = parser.Next() {
+		if err := z.add(zone, rr); err != nil {
+			return err
+		}
+	}
+
+	if err := parser.Err(); err != nil {
+		return fmt.Errorf("failed to parse zone file: %w", err)
+	}
+	return nil
+}
+
+// add validates and stores a static record
+func (z *staticZone) add(zone string, rr dns.RR) error {
+	hdr := rr.Header()
+	hdr.Name = strings.ToLower(hdr.Name)
+
+	if !dns.IsSubDomain(zone, hdr.Name) {
+		return fmt.Errorf("static record %s is outside the zone %s", hdr.Name, zone)
+	}
+	if hdr.Class != dns.ClassINET {
+		return fmt.Errorf("static record %s: only class IN is supported", hdr.Name)
+	}
+
+	switch hdr.Rrtype {
+	case dns.TypeSOA, dns.TypeNS:
+		if hdr.Name != zone {
+			return fmt.Errorf("static record %s: %s records are only allowed at the apex", hdr.Name, dns.TypeToString[hdr.Rrtype])
+		}
+		// The zone file's own SOA and NS, replaced by hookd's
+		return nil
+	case dns.TypeCNAME:
+		if hdr.Name == zone {
+			return fmt.Errorf("static record %s: a CNAME is not allowed at the apex", hdr.Name)
+		}
+	}
+
+	z.records[hdr.Name] = append(z.records[hdr.Name], rr)
+	return nil
+}
+
+// staticRR builds the resource record of a configured static record
+func staticRR(zone string, rec config.StaticRecord) (dns.RR, error) {
+	name := strings.ToLower(rec.Name)
+	switch {
+	case name == "@":
+		name = zone
+	case !strings.HasSuffix(name, "."):
+		name = name + "." + zone
+	}
+
+	ttl := rec.TTL
+	if ttl == 0 {
+		ttl = staticTTL
+	}
+
+	rtype := strings.ToUpper(rec.Type)
+	value := rec.Value
+	if rtype == "TXT" && !strings.HasPrefix(value, `"`) {
+		// Plain text values are quoted, so spaces do not split them
+		value = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+	}
+
+	rr, err := dns.NewRR(fmt.Sprintf("%s %d IN %s %s", name, ttl, rtype, value))
+	if err != nil {
+		return nil, fmt.Errorf("invalid static record %s %s: %w", rec.Name, rec.Type, err)
+	}
+	if rr == nil {
+		return nil, fmt.Errorf("invalid static record %s %s: empty value", rec.Name, rec.Type)
+	}
+	return rr, nil
+}
+
+// answerStatic answers a query for a static name and reports whether it did.
+// Static names are served from their records only, while the apex only takes
+// the types it defines and keeps its defaults for the others
+func (s *Server) answerStatic(q dns.Question, qname string, m *dns.Msg) bool {
+	records, ok := s.static.records[qname]
+	if !ok {
+		if !s.static.parents[qname] {
+			return false
+		}
+		// Empty non-terminal, the name exists but has no data
+		s.addNoData(m)
+		return true
+	}
+
+	answer := matchingRRs(q.Qtype, records)
+	if len(answer) == 0 && qname == s.zone {
+		return false
+	}
+
+	for _, rr := range answer {
+		rr = dns.Copy(rr)
+		rr.Header().Name = q.Name
+		m.Answer = append(m.Answer, rr)
+	}
+
+	s.addNoData(m)
+	return true
+}
@@ -0,0 +1,239 @@
+package dns
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/miekg/dns"
+
+	"github.com/jomar/hookd/internal/acme"
+	"github.com/jomar/hookd/internal/config"
+	"github.com/jomar/hookd/internal/storage"
+)
+
+func TestLoadStaticZone(t *testing.T) {
+	t.Run("configured records", func(t *testing.T) {
+		z, err := loadStaticZone("example.com.", []config.StaticRecord{
+			{Name: "@", Type: "txt", Value: "google-site-verification=abc def"},
+			{Name: "www", Type: "A", Value: "192.0.2.1", TTL: 300},
+			{Name: "_dmarc.example.com.", Type: "TXT", Value: `"v=DMARC1; p=none"`},
+			{Name: "sel._domainkey", Type: "TXT", Value: strings.Repeat("k", 300)},
+		}, "")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		apex := z.records["example.com."]
+		if len(apex) != 1 || apex[0].(*dns.TXT).Txt[0] != "google-site-verification=abc def" {
+			t.Errorf("unexpected apex records: %v", apex)
+		}
+		if apex[0].Header().Ttl != staticTTL {
+			t.Errorf("expected default TTL %d, got %d", staticTTL, apex[0].Header().Ttl)
+		}
+
+		www := z.records["www.example.com."]
+		if len(www) != 1 || www[0].Header().Ttl != 300 {
+			t.Errorf("unexpected www records: %v", www)
+		}
+
+		if txt := z.records["_dmarc.example.com."][0].(*dns.TXT).Txt; txt[0] != "v=DMARC1; p=none" {
+			t.Errorf("unexpected dmarc record: %v", txt)
+		}
+
+		// Long values are split into 255 byte strings
+		if txt := z.records["sel._domainkey.example.com."][0].(*dns.TXT).Txt; len(txt) != 2 {
+			t.Errorf("expected a long TXT value split in 2 strings, got %d", len(txt))
+		}
+
+		if !z.parents["_domainkey.example.com."] {
+			t.Error("expected _domainkey.example.com. to be an empty non-terminal")
+		}
+	})
+
+	t.Run("zone file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "example.com.zone")
+		zoneFile := `$TTL 600
+@       IN SOA ns1 hostmaster 1 3600 600 604800 60
+@       IN NS  ns1
+@       IN MX  10 mail
+@       IN CAA 0 issue "letsencrypt.org"
+mail    IN A   192.0.2.25
+docs    IN CNAME example.net.
+`
+		if err := os.WriteFile(path, []byte(zoneFile), 0o600); err != nil {
+			t.Fatalf("failed to write zone file: %v", err)
+		}
+
+		z, err := loadStaticZone("example.com.", nil, path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		apex := z.records["example.com."]
+		if len(apex) != 2 {
+			t.Fatalf("expected MX and CAA at the apex (SOA and NS skipped), got %v", apex)
+		}
+		if apex[0].Header().Ttl != 600 {
+			t.Errorf("expected $TTL 600, got %d", apex[0].Header().Ttl)
+		}
+		if mx := apex[0].(*dns.MX); mx.Mx != "mail.example.com." {
+			t.Errorf("expected relative names completed with the zone, got %s", mx.Mx)
+		}
+		if len(z.records["mail.example.com."]) != 1 || len(z.records["docs.example.com."]) != 1 {
+			t.Errorf("unexpected records: %v", z.records)
+		}
+	})
+
+	errorCases := []struct {
+		name    string
+		records []config.StaticRecord
+	}{
+		{"outside the zone", []config.StaticRecord{{Name: "www.example.net.", Type: "A", Value: "192.0.2.1"}}},
+		{"cname at the apex", []config.StaticRecord{{Name: "@", Type: "CNAME", Value: "example.net."}}},
+		{"cname with other records", []config.StaticRecord{
+			{Name: "www", Type: "CNAME", Value: "example.net."},
+			{Name: "www", Type: "TXT", Value: "hello"},
+		}},
+		{"delegation", []config.StaticRecord{{Name: "sub", Type: "NS", Value: "ns.example.net."}}},
+		{"invalid value", []config.StaticRecord{{Name: "www", Type: "A", Value: "not-an-ip"}}},
+		{"unknown type", []config.StaticRecord{{Name: "www", Type: "BOGUS", Value: "x"}}},
+	}
+	for _, tt := range errorCases {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := loadStaticZone("example.com.", tt.records, ""); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+
+	t.Run("missing zone file", func(t *testing.T) {
+		if _, err := loadStaticZone("example.com.", nil, filepath.Join(t.TempDir(), "missing.zone")); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestServer_HandleDNSRequest_Static(t *testing.T) {
+	idGen := func() string { return "www" }
+	manager := storage.NewMemoryManager(idGen)
+	acmeProvider := acme.NewProvider(slog.Default())
+	logger := slog.Default()
+
+	cfg := testConfig(5353)
+	cfg.DNS.Records = []config.StaticRecord{
+		{Name: "@", Type: "TXT", Value: "site-verification=abc"},
+		{Name: "www", Type: "A", Value: "192.0.2.80"},
+		{Name: "docs", Type: "CNAME", Value: "example.net."},
+		{Name: "sel._domainkey", Type: "TXT", Value: "v=DKIM1; p=abc"},
+	}
+
+	server, err := NewServer(cfg, manager, acmeProvider, logger, func() string { return "int-id" })
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	// A hook named like a static name is shadowed by it
+	hook := manager.CreateHook("example.com")
+
+	t.Run("static name", func(t *testing.T) {
+		resp := query(server, "WWW.example.com.", dns.TypeA)
+		if len(resp.Answer) != 1 || resp.Answer[0].(*dns.A).A.String() != "192.0.2.80" {
+			t.Fatalf("expected the static address, got %v", resp.Answer)
+		}
+		if resp.Answer[0].Header().Name != "WWW.example.com." {
+			t.Errorf("expected the query name case, got %s", resp.Answer[0].Header().Name)
+		}
+
+		interactions, _ := manager.PollInteractions(hook.ID)
+		if len(interactions) != 0 {
+			t.Errorf("expected no interaction for a static name, got %d", len(interactions))
+		}
+	})
+
+	t.Run("static name without the type", func(t *testing.T) {
+		resp := query(server, "www.example.com.", dns.TypeMX)
+		if resp.Rcode != dns.RcodeSuccess || len(resp.Answer) != 0 || len(resp.Ns) != 1 {
+			t.Errorf("expected NODATA with the SOA, got %v", resp)
+		}
+	})
+
+	t.Run("cname", func(t *testing.T) {
+		resp := query(server, "docs.example.com.", dns.TypeA)
+		if len(resp.Answer) != 1 || resp.Answer[0].Header().Rrtype != dns.TypeCNAME {
+			t.Errorf("expected the CNAME, got %v", resp.Answer)
+		}
+	})
+
+	t.Run("empty non-terminal", func(t *testing.T) {
+		resp := query(server, "_domainkey.example.com.", dns.TypeTXT)
+		if resp.Rcode != dns.RcodeSuccess || len(resp.Answer) != 0 {
+			t.Errorf("expected NODATA, got %v", resp)
+		}
+	})
+
+	t.Run("apex static type", func(t *testing.T) {
+		resp := query(server, "example.com.", dns.TypeTXT)
+		if len(resp.Answer) != 1 || resp.Answer[0].(*dns.TXT).Txt[0] != "site-verification=abc" {
+			t.Errorf("expected the static TXT record, got %v", resp.Answer)
+		}
+	})
+
+	t.Run("apex keeps its defaults", func(t *testing.T) {
+		resp := query(server, "example.com.", dns.TypeA)
+		if len(resp.Answer) != 1 || resp.Answer[0].(*dns.A).A.String() != "203.0.113.10" {
+			t.Errorf("expected the server address, got %v", resp.Answer)
+		}
+
+		resp = query(server, "example.com.", dns.TypeNS)
+		if len(resp.Answer) != 2 {
+			t.Errorf("expected the NS records, got %v", resp.Answer)
+		}
+	})
+}
+
+func TestNewServer_InvalidStaticRecords(t *testing.T) {
+	manager := storage.NewMemoryManager(func() string { return "abc123" })
+
+	cfg := testConfig(5353)
+	cfg.DNS.Records = []config.StaticRecord{{Name: "www", Type: "A", Value: "not-an-ip"}}
+
+	if _, err := NewServer(cfg, manager, acme.NewProvider(slog.Default()), slog.Default(), func() string { return "int-id" }); err == nil {
+		t.Error("expected an error for an invalid static record")
+	}
+}
+
+func TestServer_IsReservedLabel(t *testing.T) {
+	manager := storage.NewMemoryManager(func() string { return "abc123" })
+
+	cfg := testConfig(5353)
+	cfg.DNS.Records = []config.StaticRecord{
+		{Name: "www", Type: "A", Value: "192.0.2.80"},
+		{Name: "sel._domainkey", Type: "TXT", Value: "v=DKIM1; p=abc"},
+		{Name: "a.b", Type: "TXT", Value: "nested"},
+	}
+
+	server, err := NewServer(cfg, manager, acme.NewProvider(slog.Default()), slog.Default(), func() string { return "int-id" })
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	tests := []struct {
+		label string
+		want  bool
+	}{
+		{"ns1", true},
+		{"NS2", true},
+		{"www", true},
+		{"b", true}, // an empty non-terminal is answered statically too
+		{"abc123", false},
+		{"ns3", false},
+	}
+	for _, tt := range tests {
+		if got := server.IsReservedLabel(tt.label); got != tt.want {
+			t.Errorf("IsReservedLabel(%s) = %v, want %v", tt.label, got, tt.want)
+		}
+	}
+}
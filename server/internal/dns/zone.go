@@ -0,0 +1,149 @@
+package dns
+
+import (
+	"net"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// recordTTL is the TTL of every record served, kept short so hook answers and
+// ACME challenges never linger in resolver caches
+const recordTTL = 60
+
+// SOA timers (RFC 1912 recommendations). The minimum doubles as the negative
+// caching TTL (RFC 2308)
+const (
+	soaRefresh = 3600
+	soaRetry   = 600
+	soaExpire  = 604800
+	soaMinimum = recordTTL
+)
+
+// soa returns the SOA record of the zone apex
+func (s *Server) soa() *dns.SOA {
+	return &dns.SOA{
+		Hdr: dns.RR_Header{
+			Name:   s.zone,
+			Rrtype: dns.TypeSOA,
+			Class:  dns.ClassINET,
+			Ttl:    recordTTL,
+		},
+		Ns:      s.nameservers[0],
+		Mbox:    "hostmaster." + s.zone,
+		Serial:  s.serial,
+		Refresh: soaRefresh,
+		Retry:   soaRetry,
+		Expire:  soaExpire,
+		Minttl:  soaMinimum,
+	}
+}
+
+// addNoData adds the SOA to a reply without answers, so resolvers can cache
+// the NODATA answer (RFC 2308)
+func (s *Server) addNoData(m *dns.Msg) {
+	if len(m.Answer) == 0 {
+		m.Ns = append(m.Ns, s.soa())
+	}
+}
+
+// matchingRRs returns the records of the queried type or, if there are none,
+// the CNAME among them: a CNAME answers every type and resolvers follow it
+// themselves
+func matchingRRs(qtype uint16, rrs []dns.RR) []dns.RR {
+	var answer, cname []dns.RR
+	for _, rr := range rrs {
+		switch rr.Header().Rrtype {
+		case qtype:
+			answer = append(answer, rr)
+		case dns.TypeCNAME:
+			cname = append(cname, rr)
+		}
+	}
+
+	if len(answer) == 0 {
+		return cname
+	}
+	return answer
+}
+
+// nsRecords returns the NS records of the zone apex
+func (s *Server) nsRecords() []dns.RR {
+	records := make([]dns.RR, 0, len(s.nameservers))
+	for _, ns := range s.nameservers {
+		records = append(records, &dns.NS{
+			Hdr: dns.RR_Header{
+				Name:   s.zone,
+				Rrtype: dns.TypeNS,
+				Class:  dns.ClassINET,
+				Ttl:    recordTTL,
+			},
+			Ns: ns,
+		})
+	}
+	return records
+}
+
+// glueRecords returns the A/AAAA records of the nameservers inside the zone,
+// which resolvers cannot look up without them
+func (s *Server) glueRecords() []dns.RR {
+	var records []dns.RR
+	for _, ns := range s.nameservers {
+		if !dns.IsSubDomain(s.zone, ns) {
+			continue
+		}
+		records = append(records, s.addressRecords(ns, dns.TypeA)...)
+		records = append(records, s.addressRecords(ns, dns.TypeAAAA)...)
+	}
+	return records
+}
+
+// addressRecords returns the server's A or AAAA record for name, or nothing
+// when the server has no address of that family
+func (s *Server) addressRecords(name string, qtype uint16) []dns.RR {
+	hdr := dns.RR_Header{
+		Name:   name,
+		Rrtype: qtype,
+		Class:  dns.ClassINET,
+		Ttl:    recordTTL,
+	}
+
+	switch {
+	case qtype == dns.TypeA && s.serverIP != "":
+		return []dns.RR{&dns.A{Hdr: hdr, A: net.ParseIP(s.serverIP)}}
+	case qtype == dns.TypeAAAA && s.serverIPv6 != "":
+		return []dns.RR{&dns.AAAA{Hdr: hdr, AAAA: net.ParseIP(s.serverIPv6)}}
+	}
+	return nil
+}
+
+// nameExists reports whether an in-zone name is served. Every hostname under
+// the zone answers so any hook can be reached, but names whose label next to
+// the zone can never be a hook ID (e.g. "_dmarc") do not exist
+func (s *Server) nameExists(qname string) bool {
+	if qname == s.zone {
+		return true
+	}
+
+	relative := strings.TrimSuffix(qname, "."+s.zone)
+	label := relative[strings.LastIndex(relative, ".")+1:]
+	return label == "_acme-challenge" || isHostLabel(label)
+}
+
+// isHostLabel reports whether label is a valid hostname label (RFC 1123)
+func isHostLabel(label string) bool {
+	if len(label) == 0 || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+		return false
+	}
+	for _, c := range label {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// defaultNameservers derives ns1/ns2 names inside the zone
+func defaultNameservers(zone string) []string {
+	return []string{"ns1." + zone, "ns2." + zone}
+}
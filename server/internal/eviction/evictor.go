@@ -103,16 +103,15 @@ func (e *Evictor) evictByTTL() {
 	}
 }
 
-// evictByHookTTL removes hooks older than the configured hook TTL
+// evictByHookTTL removes hooks past their expiry time
 func (e *Evictor) evictByHookTTL() {
 	now := time.Now().UTC()
-	cutoff := now.Add(-e.config.HookTTL)
 
 	allHooks := e.storage.GetAllHooks()
 	totalEvicted := 0
 
 	for _, hook := range allHooks {
-		if hook.CreatedAt.Before(cutoff) {
+		if e.HookExpiresAt(hook).Before(now) {
 			e.storage.DeleteHook(hook.ID)
 			totalEvicted++
 		}
@@ -177,11 +176,22 @@ func (e *Evictor) evictByMemory() {
 	totalEvicted := 0
 	hooksEvicted := 0
 
+	// Orphans go first, they belong to no registered hook. The oldest are
+	// dropped a quarter at a time until memory is below target
+	if orphans := e.storage.Orphans(); orphans != nil {
+		for stats.Memory.HeapInuseMB >= target {
+			dropped := orphans.DropOldest(max(orphans.Len()/4, 1))
+			if dropped == 0 {
+				break
+			}
+			totalEvicted += dropped
+			runtime.GC()
+			stats = e.storage.Stats()
+		}
+	}
+
 	// Get all hooks sorted by creation time (oldest first)
 	hooks := e.storage.GetAllHooks()
-	if len(hooks) == 0 {
-		return
-	}
 
 	// Sort hooks by CreatedAt (oldest first)
 	sortedHooks := make([]*storage.Hook, len(hooks))
@@ -234,6 +244,28 @@ func (e *Evictor) evictByMemory() {
 	}
 }
 
+// HookTTL returns the configured hook TTL
+func (e *Evictor) HookTTL() time.Duration {
+	return e.config.HookTTL
+}
+
+// HookTTLFor returns the TTL of a hook: its own TTL if set, otherwise the configured hook TTL
+func (e *Evictor) HookTTLFor(hook *storage.Hook) time.Duration {
+	if hook.TTL > 0 {
+		return time.Duration(hook.TTL) * time.Second
+	}
+	return e.config.HookTTL
+}
+
+// HookExpiresAt returns when a hook will be evicted: its renewal expiry if it
+// was renewed, otherwise its creation time plus its TTL
+func (e *Evictor) HookExpiresAt(hook *storage.Hook) time.Time {
+	if !hook.ExpiresAt.IsZero() {
+		return hook.ExpiresAt
+	}
+	return hook.CreatedAt.Add(e.HookTTLFor(hook))
+}
+
 // GetMetrics returns eviction metrics
 func (e *Evictor) GetMetrics() Metrics {
 	return *e.metrics
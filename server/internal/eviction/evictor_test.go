@@ -2,6 +2,7 @@ package eviction
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"os"
 	"testing"
@@ -146,7 +147,11 @@ func TestEvictor_GetMetrics(t *testing.T) {
 }
 
 func TestEvictor_EvictByMemory(t *testing.T) {
-	idGen := func() string { return "test-id" }
+	idCounter := 0
+	idGen := func() string {
+		idCounter++
+		return fmt.Sprintf("test-id-%d", idCounter)
+	}
 	manager := storage.NewMemoryManager(idGen)
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
 
@@ -251,3 +256,76 @@ func TestEvictor_EvictByMemory_NoHooks(t *testing.T) {
 		t.Errorf("expected 0 memory evictions with no hooks, got %d", evictor.metrics.EvictionsMemory)
 	}
 }
+
+func TestEvictor_EvictByHookTTL_PerHook(t *testing.T) {
+	counter := 0
+	idGen := func() string {
+		counter++
+		return fmt.Sprintf("hook%d", counter)
+	}
+	manager := storage.NewMemoryManager(idGen)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	cfg := config.EvictionConfig{
+		InteractionTTL:  time.Hour,
+		HookTTL:         50 * time.Millisecond,
+		MaxPerHook:      1000,
+		MaxMemoryMB:     1800,
+		CleanupInterval: time.Minute,
+	}
+
+	evictor := NewEvictor(manager, cfg, logger)
+
+	expired := manager.CreateHook("example.com")
+	renewed := manager.CreateHook("example.com")
+	manager.RenewHook(renewed.ID, time.Now().Add(time.Hour))
+	longLived, _ := manager.CreateHookWithOptions("example.com", storage.HookOptions{TTL: time.Hour})
+
+	time.Sleep(100 * time.Millisecond)
+	evictor.evictByHookTTL()
+
+	if _, exists := manager.GetHook(longLived.ID); !exists {
+		t.Error("expected hook with its own TTL to be kept")
+	}
+
+	if _, exists := manager.GetHook(expired.ID); exists {
+		t.Error("expected expired hook to be evicted")
+	}
+
+	if _, exists := manager.GetHook(renewed.ID); !exists {
+		t.Error("expected renewed hook to be kept")
+	}
+
+	if evictor.metrics.EvictionsHookTTL != 1 {
+		t.Errorf("expected 1 hook TTL eviction, got %d", evictor.metrics.EvictionsHookTTL)
+	}
+}
+
+func TestEvictor_EvictByMemory_Orphans(t *testing.T) {
+	manager := storage.NewMemoryManager(func() string { return "test-id" })
+	orphans := storage.NewOrphanStore(time.Hour, 100, 1<<20)
+	manager.SetOrphans(orphans)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	cfg := config.EvictionConfig{
+		InteractionTTL:  1 * time.Hour,
+		MaxPerHook:      1000,
+		MaxMemoryMB:     1, // Very low limit
+		CleanupInterval: 10 * time.Second,
+	}
+
+	evictor := NewEvictor(manager, cfg, logger)
+
+	for i := 0; i < 3; i++ {
+		manager.AddInteraction(fmt.Sprintf("unknown%d", i), storage.DNSInteraction(fmt.Sprintf("int%d", i), "1.2.3.4", "test.com", "A"))
+	}
+
+	evictor.evictByMemory()
+
+	if orphans.Len() != 0 {
+		t.Errorf("expected orphans to be dropped under memory pressure, got %d", orphans.Len())
+	}
+	if evictor.metrics.EvictionsMemory != 3 {
+		t.Errorf("expected 3 memory evictions, got %d", evictor.metrics.EvictionsMemory)
+	}
+}
@@ -1,16 +1,30 @@
 package http
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"log/slog"
+	"net"
 	"net/http"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/jomar/hookd/internal/dns"
 	"github.com/jomar/hookd/internal/eviction"
 	"github.com/jomar/hookd/internal/storage"
 )
 
+// maxPollWait caps how long a long-polling request may block
+const maxPollWait = 120 * time.Second
+
 // APIHandler handles API endpoints
 type APIHandler struct {
 	storage     storage.Manager
@@ -18,6 +32,8 @@ type APIHandler struct {
 	domain      string
 	logger      *slog.Logger
 	idGenerator func() string
+	dnsMetrics  func() dns.RateLimitMetrics // nil when the DNS server is disabled
+	reservedID  func(id string) bool        // nil when the DNS server is disabled
 }
 
 // NewAPIHandler creates a new API handler
@@ -41,15 +57,14 @@ func (h *APIHandler) HandleRegister(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Parse request body (optional)
-	var req struct {
-		Count int `json:"count,omitempty"`
-	}
+	var req registerRequest
 
 	// Only parse body if content-type is JSON and body exists
 	if r.ContentLength > 0 {
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			// If body parsing fails, treat as count=1
-			req.Count = 1
+			// If body parsing fails, treat as count=1. The decoder may have
+			// filled some fields before failing, none of them are used
+			req = registerRequest{Count: 1}
 		}
 	}
 
@@ -58,9 +73,21 @@ func (h *APIHandler) HandleRegister(w http.ResponseWriter, r *http.Request) {
 		req.Count = 1
 	}
 
+	opts, err := h.hookOptions(req)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
 	// Single hook case
 	if req.Count == 1 {
-		hook := h.storage.CreateHook(h.domain)
+		hook, err := h.storage.CreateHookWithOptions(h.domain, opts)
+		if err != nil {
+			h.respondCreateError(w, err)
+			return
+		}
 		h.logger.Info("hook created", "id", hook.ID, "client", r.RemoteAddr)
 		respondJSON(w, http.StatusOK, hook)
 		return
@@ -69,7 +96,11 @@ func (h *APIHandler) HandleRegister(w http.ResponseWriter, r *http.Request) {
 	// Multiple hooks case
 	hooks := make([]interface{}, req.Count)
 	for i := 0; i < req.Count; i++ {
-		hook := h.storage.CreateHook(h.domain)
+		hook, err := h.storage.CreateHookWithOptions(h.domain, opts)
+		if err != nil {
+			h.respondCreateError(w, err)
+			return
+		}
 		hooks[i] = hook
 		h.logger.Debug("hook created", "id", hook.ID, "index", i+1, "total", req.Count, "client", r.RemoteAddr)
 	}
@@ -81,6 +112,21 @@ func (h *APIHandler) HandleRegister(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// respondCreateError reports a failed hook creation
+func (h *APIHandler) respondCreateError(w http.ResponseWriter, err error) {
+	if errors.Is(err, storage.ErrHookExists) {
+		respondJSON(w, http.StatusConflict, map[string]string{
+			"error": "Hook ID already taken",
+		})
+		return
+	}
+
+	h.logger.Error("failed to create hook", "error", err)
+	respondJSON(w, http.StatusInternalServerError, map[string]string{
+		"error": "Internal server error",
+	})
+}
+
 // HandlePollBatch handles POST /poll (batch polling)
 func (h *APIHandler) HandlePollBatch(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -90,16 +136,25 @@ func (h *APIHandler) HandlePollBatch(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse request body as array of hook IDs
-	var hookIDs []string
+	// Parse request body: either an array of hook IDs or an object with options
+	var raw json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+		return
+	}
 
-	if err := json.NewDecoder(r.Body).Decode(&hookIDs); err != nil {
+	var req pollBatchRequest
+	if err := req.decode(raw); err != nil {
 		respondJSON(w, http.StatusBadRequest, map[string]string{
 			"error": "Invalid request body",
 		})
 		return
 	}
 
+	hookIDs := req.HookIDs
+
 	// Validate request
 	if len(hookIDs) == 0 {
 		respondJSON(w, http.StatusBadRequest, map[string]string{
@@ -108,11 +163,51 @@ func (h *APIHandler) HandlePollBatch(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The wait option may come from the body or the query string
+	waitParam := req.Wait
+	if waitParam == "" {
+		waitParam = r.URL.Query().Get("wait")
+	}
+
+	wait, err := parseWait(waitParam)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Invalid wait duration",
+		})
+		return
+	}
+
+	opts, err := parsePollOptions(r.URL.Query())
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	// Subscribe before the first poll so no interaction can slip in unnoticed
+	var events <-chan storage.Event
+	if wait > 0 {
+		ch, unsubscribe := h.storage.Subscribe(hookIDs, 1)
+		defer unsubscribe()
+		events = ch
+	}
+
+	deadline := time.NewTimer(wait)
+	defer deadline.Stop()
+
 	// Poll interactions for all hooks
-	results := h.storage.PollInteractionsBatch(hookIDs)
+	var results map[string]*storage.PollResult
+	for {
+		results = h.storage.PollMatchingBatch(hookIDs, opts)
+		if wait == 0 || hasInteractions(results) || !waitForEvent(r.Context(), events, deadline.C) {
+			break
+		}
+	}
 
 	h.logger.Info("batch interactions polled",
 		"hook_count", len(hookIDs),
+		"wait", wait,
 		"client", r.RemoteAddr)
 
 	respondJSON(w, http.StatusOK, map[string]interface{}{
@@ -122,6 +217,12 @@ func (h *APIHandler) HandlePollBatch(w http.ResponseWriter, r *http.Request) {
 
 // HandlePoll handles GET /poll/:id
 func (h *APIHandler) HandlePoll(w http.ResponseWriter, r *http.Request) {
+	// Acknowledgements share the /poll/ prefix: /poll/abc123/ack
+	if parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/"); len(parts) == 3 && parts[2] == "ack" {
+		h.HandleAck(w, r)
+		return
+	}
+
 	if r.Method != http.MethodGet {
 		respondJSON(w, http.StatusMethodNotAllowed, map[string]string{
 			"error": "Method not allowed",
@@ -149,12 +250,93 @@ func (h *APIHandler) HandlePoll(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Poll interactions (atomic read-and-delete)
-	interactions, err := h.storage.PollInteractions(hookID)
+	wait, err := parseWait(r.URL.Query().Get("wait"))
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Invalid wait duration",
+		})
+		return
+	}
+
+	// Cursor mode returns interactions after the cursor without deleting them,
+	// otherwise interactions are read and deleted atomically
+	cursorParam := r.URL.Query().Get("cursor")
+	var cursor uint64
+	if cursorParam != "" {
+		cursor, err = strconv.ParseUint(cursorParam, 10, 64)
+		if err != nil {
+			respondJSON(w, http.StatusBadRequest, map[string]string{
+				"error": "Invalid cursor",
+			})
+			return
+		}
+	}
+
+	opts, err := parsePollOptions(r.URL.Query())
 	if err != nil {
-		h.logger.Error("failed to poll interactions", "error", err, "hook_id", hookID)
-		respondJSON(w, http.StatusInternalServerError, map[string]string{
-			"error": "Internal server error",
+		respondJSON(w, http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	// The cursor moves past skipped interactions, which the ack would delete
+	if cursorParam != "" && !opts.Filter.IsEmpty() {
+		respondJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Filters cannot be combined with cursor",
+		})
+		return
+	}
+
+	fetch := func() ([]*storage.Interaction, uint64, bool, error) {
+		if cursorParam != "" {
+			return h.storage.PeekMatching(hookID, cursor, opts)
+		}
+		interactions, hasMore, err := h.storage.PollMatching(hookID, opts)
+		return interactions, 0, hasMore, err
+	}
+
+	// Subscribe before the first poll so no interaction can slip in unnoticed
+	var events <-chan storage.Event
+	if wait > 0 {
+		ch, unsubscribe := h.storage.Subscribe([]string{hookID}, 1)
+		defer unsubscribe()
+		events = ch
+	}
+
+	deadline := time.NewTimer(wait)
+	defer deadline.Stop()
+
+	var interactions []*storage.Interaction
+	var next uint64
+	var hasMore bool
+	for {
+		interactions, next, hasMore, err = fetch()
+		if err != nil {
+			h.logger.Error("failed to poll interactions", "error", err, "hook_id", hookID)
+			respondJSON(w, http.StatusInternalServerError, map[string]string{
+				"error": "Internal server error",
+			})
+			return
+		}
+
+		if wait == 0 || len(interactions) > 0 || !waitForEvent(r.Context(), events, deadline.C) {
+			break
+		}
+	}
+
+	if cursorParam != "" {
+		h.logger.Info("interactions peeked",
+			"hook_id", hookID,
+			"cursor", cursor,
+			"count", len(interactions),
+			"wait", wait,
+			"client", r.RemoteAddr)
+
+		respondJSON(w, http.StatusOK, map[string]interface{}{
+			"interactions": interactions,
+			"cursor":       next,
+			"has_more":     hasMore,
 		})
 		return
 	}
@@ -162,10 +344,64 @@ func (h *APIHandler) HandlePoll(w http.ResponseWriter, r *http.Request) {
 	h.logger.Info("interactions polled",
 		"hook_id", hookID,
 		"count", len(interactions),
+		"wait", wait,
 		"client", r.RemoteAddr)
 
 	respondJSON(w, http.StatusOK, map[string]interface{}{
 		"interactions": interactions,
+		"has_more":     hasMore,
+	})
+}
+
+// HandleAck handles POST /poll/:id/ack
+func (h *APIHandler) HandleAck(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondJSON(w, http.StatusMethodNotAllowed, map[string]string{
+			"error": "Method not allowed",
+		})
+		return
+	}
+
+	// Path format: /poll/abc123/ack
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) != 3 || parts[1] == "" {
+		respondJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Invalid path format",
+		})
+		return
+	}
+
+	hookID := parts[1]
+
+	var req struct {
+		Cursor *uint64 `json:"cursor"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Cursor == nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+		return
+	}
+
+	if _, exists := h.storage.GetHook(hookID); !exists {
+		respondJSON(w, http.StatusNotFound, map[string]string{
+			"error": "Hook not found",
+		})
+		return
+	}
+
+	acked := h.storage.AckInteractions(hookID, *req.Cursor)
+
+	h.logger.Info("interactions acknowledged",
+		"hook_id", hookID,
+		"cursor", *req.Cursor,
+		"count", acked,
+		"client", r.RemoteAddr)
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"acknowledged": acked,
+		"cursor":       *req.Cursor,
 	})
 }
 
@@ -213,9 +449,207 @@ func (h *APIHandler) HandleMetrics(w http.ResponseWriter, r *http.Request) {
 		},
 	}
 
+	if h.dnsMetrics != nil {
+		rateLimit := h.dnsMetrics()
+		metrics["dns"] = map[string]interface{}{
+			"rate_limit": map[string]interface{}{
+				"responses_dropped":    rateLimit.ResponsesDropped,
+				"responses_slipped":    rateLimit.ResponsesSlipped,
+				"interactions_dropped": rateLimit.InteractionsDropped,
+			},
+		}
+	}
+
 	respondJSON(w, http.StatusOK, metrics)
 }
 
+// pollBatchRequest represents the body of POST /poll
+type pollBatchRequest struct {
+	HookIDs []string `json:"hook_ids"`
+	Wait    string   `json:"wait,omitempty"`
+}
+
+// decode accepts either a plain array of hook IDs or a request object
+func (p *pollBatchRequest) decode(raw json.RawMessage) error {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		return json.Unmarshal(trimmed, &p.HookIDs)
+	}
+	return json.Unmarshal(trimmed, p)
+}
+
+// parseWait parses a long-polling duration ("30s" or plain seconds) and caps it
+func parseWait(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	wait, err := parseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+
+	if wait > maxPollWait {
+		wait = maxPollWait
+	}
+
+	return wait, nil
+}
+
+// parsePollOptions builds poll options from the filter parameters and the
+// limit and max_bytes query parameters
+func parsePollOptions(query url.Values) (storage.PollOptions, error) {
+	filter, err := parseFilter(query)
+	if err != nil {
+		return storage.PollOptions{}, err
+	}
+
+	limit, err := parsePageParam(query.Get("limit"), 0)
+	if err != nil {
+		return storage.PollOptions{}, fmt.Errorf("Invalid limit")
+	}
+
+	maxBytes, err := parsePageParam(query.Get("max_bytes"), 0)
+	if err != nil {
+		return storage.PollOptions{}, fmt.Errorf("Invalid max_bytes")
+	}
+
+	return storage.PollOptions{Filter: filter, Limit: limit, MaxBytes: maxBytes}, nil
+}
+
+// parseFilter builds an interaction filter from the poll query parameters:
+// type, qtype, method, path (regular expression), source (IPs or CIDRs),
+// since and until (RFC 3339). List parameters are comma-separated.
+// It returns nil when no filter is given.
+func parseFilter(query url.Values) (*storage.Filter, error) {
+	filter := &storage.Filter{}
+
+	for _, t := range splitList(query["type"]) {
+		switch storage.InteractionType(strings.ToLower(t)) {
+		case storage.InteractionTypeDNS, storage.InteractionTypeHTTP:
+			filter.Types = append(filter.Types, storage.InteractionType(strings.ToLower(t)))
+		default:
+			return nil, fmt.Errorf("Invalid type: %s", t)
+		}
+	}
+
+	filter.QTypes = splitList(query["qtype"])
+	filter.Methods = splitList(query["method"])
+
+	if path := query.Get("path"); path != "" {
+		re, err := regexp.Compile(path)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid path regex")
+		}
+		filter.Path = re
+	}
+
+	for _, source := range splitList(query["source"]) {
+		prefix, err := parseSource(source)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid source: %s", source)
+		}
+		filter.Sources = append(filter.Sources, prefix)
+	}
+
+	if since := query.Get("since"); since != "" {
+		parsed, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid since: expected RFC 3339 time")
+		}
+		filter.Since = parsed
+	}
+
+	if until := query.Get("until"); until != "" {
+		parsed, err := time.Parse(time.RFC3339, until)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid until: expected RFC 3339 time")
+		}
+		filter.Until = parsed
+	}
+
+	if filter.IsEmpty() {
+		return nil, nil
+	}
+
+	return filter, nil
+}
+
+// parseSource parses an IP address or CIDR into a prefix
+func parseSource(value string) (netip.Prefix, error) {
+	if strings.Contains(value, "/") {
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		return prefix.Masked(), nil
+	}
+
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// splitList flattens repeated and comma-separated query values
+func splitList(values []string) []string {
+	var items []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
+
+// parseDuration parses a non-negative duration given as "30s" or plain seconds
+func parseDuration(value string) (time.Duration, error) {
+	var d time.Duration
+	if seconds, err := strconv.Atoi(value); err == nil {
+		d = time.Duration(seconds) * time.Second
+	} else {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return 0, err
+		}
+		d = parsed
+	}
+
+	if d < 0 {
+		return 0, fmt.Errorf("duration must not be negative")
+	}
+
+	return d, nil
+}
+
+// waitForEvent blocks until an interaction event arrives.
+// It returns false when the deadline passes or the request context is done
+// (client gone or server shutting down).
+func waitForEvent(ctx context.Context, events <-chan storage.Event, deadline <-chan time.Time) bool {
+	select {
+	case <-events:
+		return true
+	case <-deadline:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// hasInteractions reports whether any batch result holds interactions
+func hasInteractions(results map[string]*storage.PollResult) bool {
+	for _, result := range results {
+		if len(result.Interactions) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
 // respondJSON writes a JSON response
 func respondJSON(w http.ResponseWriter, statusCode int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
@@ -249,7 +683,7 @@ func NewCaptureHandler(storage storage.Manager, domain string, logger *slog.Logg
 func (h *CaptureHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Extract hook ID from Host header
 	host := r.Host
-	hookID := h.extractHookID(host)
+	hookID, tags := h.extractHookID(host)
 
 	if hookID == "" {
 		// Not a valid hook subdomain
@@ -283,6 +717,7 @@ func (h *CaptureHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		headers,
 		string(body),
 	)
+	interaction.Tags = tags
 
 	// Store interaction
 	if err := h.storage.AddInteraction(hookID, interaction); err != nil {
@@ -295,44 +730,48 @@ func (h *CaptureHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		"path", r.URL.Path,
 		"client", sourceIP)
 
+	// Serve the hook's configured response, once the interaction is stored so
+	// delayed responses can be observed while they wait
+	if hook, exists := h.storage.GetHook(hookID); exists {
+		if resp := matchResponse(hook.HTTPResponses, r.Method, r.URL.Path); resp != nil {
+			writeHookResponse(w, r, resp)
+			return
+		}
+	}
+
 	// Respond with 200 OK
 	w.WriteHeader(http.StatusOK)
 }
 
-// extractHookID extracts the hook ID from a host header
-// Example: abc123.hookd.jomar.ovh -> abc123
-func (h *CaptureHandler) extractHookID(host string) string {
+// extractHookID extracts the hook ID from a host header: the label directly
+// left of the domain. Labels further left are returned as correlation tags,
+// so payload123.abc123.<domain> belongs to hook abc123 with tag payload123
+func (h *CaptureHandler) extractHookID(host string) (string, []string) {
 	// Remove port if present
 	if idx := strings.Index(host, ":"); idx != -1 {
 		host = host[:idx]
 	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
 
 	// Check if it's a subdomain of our domain
-	suffix := "." + h.domain
-	if !strings.HasSuffix(host, suffix) {
-		// Check if it's the exact domain (no subdomain)
-		if host == h.domain {
-			return ""
-		}
-		return ""
+	subdomain, ok := strings.CutSuffix(host, "."+strings.ToLower(h.domain))
+	if !ok || subdomain == "" {
+		return "", nil
 	}
 
-	// Extract the subdomain part
-	subdomain := strings.TrimSuffix(host, suffix)
-
-	// Handle multi-level subdomains (take the first part)
-	parts := strings.Split(subdomain, ".")
-	if len(parts) == 0 {
-		return ""
+	labels := strings.Split(subdomain, ".")
+	last := len(labels) - 1
+	if last == 0 {
+		return labels[0], nil
 	}
-
-	return parts[0]
+	return labels[last], labels[:last]
 }
 
 // extractIP extracts the IP address from a remote address string
 func extractIP(remoteAddr string) string {
-	if idx := strings.LastIndex(remoteAddr, ":"); idx != -1 {
-		return remoteAddr[:idx]
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
 	}
-	return remoteAddr
+	return host
 }
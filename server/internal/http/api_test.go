@@ -2,20 +2,29 @@ package http
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/jomar/hookd/internal/config"
+	"github.com/jomar/hookd/internal/dns"
 	"github.com/jomar/hookd/internal/eviction"
 	"github.com/jomar/hookd/internal/storage"
 )
 
 func TestAPIHandler_HandleRegister(t *testing.T) {
-	idGen := func() string { return "test-id" }
+	idCounter := 0
+	idGen := func() string {
+		idCounter++
+		return fmt.Sprintf("test-id-%d", idCounter)
+	}
 	manager := storage.NewMemoryManager(idGen)
 	evictorCfg := config.EvictionConfig{
 		CleanupInterval: 60,
@@ -165,6 +174,23 @@ func TestAPIHandler_HandleRegister(t *testing.T) {
 		}
 	})
 
+	t.Run("partially decoded body", func(t *testing.T) {
+		// The id decodes before the numeric ttl fails, it must not be used
+		body := bytes.NewBufferString(`{"id": "partial", "ttl": 3600}`)
+		req := httptest.NewRequest(http.MethodPost, "/register", body)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		handler.HandleRegister(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("expected status 200 (defaults to 1), got %d", w.Code)
+		}
+		if _, exists := manager.GetHook("partial"); exists {
+			t.Error("expected the requested id to be ignored")
+		}
+	})
+
 	t.Run("method not allowed", func(t *testing.T) {
 		req := httptest.NewRequest(http.MethodGet, "/register", nil)
 		w := httptest.NewRecorder()
@@ -274,6 +300,273 @@ func TestAPIHandler_HandlePoll(t *testing.T) {
 	})
 }
 
+func TestAPIHandler_HandlePoll_Cursor(t *testing.T) {
+	idGen := func() string { return "test-id" }
+	manager := storage.NewMemoryManager(idGen)
+	evictorCfg := config.EvictionConfig{
+		CleanupInterval: 60,
+		InteractionTTL:  3600,
+		MaxPerHook:      100,
+		MaxMemoryMB:     100,
+	}
+	evictor := eviction.NewEvictor(manager, evictorCfg, slog.Default())
+	logger := slog.Default()
+
+	handler := NewAPIHandler(manager, evictor, "example.com", logger, idGen)
+
+	hook := manager.CreateHook("example.com")
+	manager.AddInteraction(hook.ID, storage.DNSInteraction("int-1", "1.2.3.4", "test.com", "A"))
+	manager.AddInteraction(hook.ID, storage.DNSInteraction("int-2", "1.2.3.4", "test.com", "A"))
+
+	t.Run("poll with cursor does not delete", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			req := httptest.NewRequest(http.MethodGet, "/poll/"+hook.ID+"?cursor=0", nil)
+			w := httptest.NewRecorder()
+
+			handler.HandlePoll(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d", w.Code)
+			}
+
+			var response struct {
+				Interactions []storage.Interaction `json:"interactions"`
+				Cursor       uint64                `json:"cursor"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+
+			if len(response.Interactions) != 2 {
+				t.Errorf("expected 2 interactions, got %d", len(response.Interactions))
+			}
+
+			if response.Cursor != 2 {
+				t.Errorf("expected cursor 2, got %d", response.Cursor)
+			}
+		}
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/poll/"+hook.ID+"?cursor=abc", nil)
+		w := httptest.NewRecorder()
+
+		handler.HandlePoll(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("ack drops interactions up to cursor", func(t *testing.T) {
+		body := bytes.NewBufferString(`{"cursor": 1}`)
+		req := httptest.NewRequest(http.MethodPost, "/poll/"+hook.ID+"/ack", body)
+		w := httptest.NewRecorder()
+
+		handler.HandlePoll(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+
+		var response map[string]interface{}
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+
+		if response["acknowledged"] != float64(1) {
+			t.Errorf("expected 1 acknowledged, got %v", response["acknowledged"])
+		}
+
+		interactions, _, _ := manager.PeekInteractions(hook.ID, 0)
+		if len(interactions) != 1 || interactions[0].ID != "int-2" {
+			t.Errorf("expected only int-2 to remain, got %v", interactions)
+		}
+	})
+
+	t.Run("ack missing cursor", func(t *testing.T) {
+		body := bytes.NewBufferString(`{}`)
+		req := httptest.NewRequest(http.MethodPost, "/poll/"+hook.ID+"/ack", body)
+		w := httptest.NewRecorder()
+
+		handler.HandlePoll(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("ack hook not found", func(t *testing.T) {
+		body := bytes.NewBufferString(`{"cursor": 1}`)
+		req := httptest.NewRequest(http.MethodPost, "/poll/nonexistent/ack", body)
+		w := httptest.NewRecorder()
+
+		handler.HandlePoll(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", w.Code)
+		}
+	})
+
+	t.Run("ack method not allowed", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/poll/"+hook.ID+"/ack", nil)
+		w := httptest.NewRecorder()
+
+		handler.HandlePoll(w, req)
+
+		if w.Code != http.StatusMethodNotAllowed {
+			t.Errorf("expected status 405, got %d", w.Code)
+		}
+	})
+}
+
+func TestAPIHandler_HandlePoll_Wait(t *testing.T) {
+	counter := 0
+	idGen := func() string {
+		counter++
+		return fmt.Sprintf("test-id-%d", counter)
+	}
+	manager := storage.NewMemoryManager(idGen)
+	evictorCfg := config.EvictionConfig{
+		CleanupInterval: 60,
+		InteractionTTL:  3600,
+		MaxPerHook:      100,
+		MaxMemoryMB:     100,
+	}
+	evictor := eviction.NewEvictor(manager, evictorCfg, slog.Default())
+	logger := slog.Default()
+
+	handler := NewAPIHandler(manager, evictor, "example.com", logger, idGen)
+
+	hook := manager.CreateHook("example.com")
+
+	t.Run("returns when an interaction arrives", func(t *testing.T) {
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			manager.AddInteraction(hook.ID, storage.DNSInteraction("int-1", "1.2.3.4", "test.com", "A"))
+		}()
+
+		req := httptest.NewRequest(http.MethodGet, "/poll/"+hook.ID+"?wait=5s", nil)
+		w := httptest.NewRecorder()
+
+		start := time.Now()
+		handler.HandlePoll(w, req)
+
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("expected poll to return early, took %v", elapsed)
+		}
+
+		var response map[string]interface{}
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+
+		if interactions := response["interactions"].([]interface{}); len(interactions) != 1 {
+			t.Errorf("expected 1 interaction, got %d", len(interactions))
+		}
+	})
+
+	t.Run("times out with no interactions", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/poll/"+hook.ID+"?wait=100ms", nil)
+		w := httptest.NewRecorder()
+
+		start := time.Now()
+		handler.HandlePoll(w, req)
+
+		if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+			t.Errorf("expected poll to wait, returned after %v", elapsed)
+		}
+
+		if w.Code != http.StatusOK {
+			t.Errorf("expected status 200, got %d", w.Code)
+		}
+	})
+
+	t.Run("released on context cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		req := httptest.NewRequest(http.MethodGet, "/poll/"+hook.ID+"?wait=60s", nil).WithContext(ctx)
+		w := httptest.NewRecorder()
+
+		time.AfterFunc(50*time.Millisecond, cancel)
+
+		start := time.Now()
+		handler.HandlePoll(w, req)
+
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("expected poll to be released, took %v", elapsed)
+		}
+	})
+
+	t.Run("invalid wait", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/poll/"+hook.ID+"?wait=soon", nil)
+		w := httptest.NewRecorder()
+
+		handler.HandlePoll(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("batch wait from request object", func(t *testing.T) {
+		other := manager.CreateHook("example.com")
+
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			manager.AddInteraction(other.ID, storage.DNSInteraction("int-2", "1.2.3.4", "test.com", "A"))
+		}()
+
+		body := bytes.NewBufferString(`{"hook_ids": ["` + hook.ID + `", "` + other.ID + `"], "wait": "5s"}`)
+		req := httptest.NewRequest(http.MethodPost, "/poll", body)
+		w := httptest.NewRecorder()
+
+		start := time.Now()
+		handler.HandlePollBatch(w, req)
+
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("expected batch poll to return early, took %v", elapsed)
+		}
+
+		var response struct {
+			Results map[string]storage.PollResult `json:"results"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+
+		if len(response.Results[other.ID].Interactions) != 1 {
+			t.Errorf("expected 1 interaction for %s, got %d", other.ID, len(response.Results[other.ID].Interactions))
+		}
+	})
+}
+
+func TestParseWait(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected time.Duration
+		wantErr  bool
+	}{
+		{"", 0, false},
+		{"30s", 30 * time.Second, false},
+		{"15", 15 * time.Second, false},
+		{"1h", maxPollWait, false},
+		{"-1s", 0, true},
+		{"soon", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			wait, err := parseWait(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseWait(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if wait != tt.expected {
+				t.Errorf("parseWait(%q) = %v, want %v", tt.value, wait, tt.expected)
+			}
+		})
+	}
+}
+
 func TestAPIHandler_HandleMetrics(t *testing.T) {
 	idGen := func() string { return "test-id" }
 	manager := storage.NewMemoryManager(idGen)
@@ -353,6 +646,35 @@ func TestAPIHandler_HandleMetrics(t *testing.T) {
 		}
 	})
 
+	t.Run("dns rate limiting", func(t *testing.T) {
+		handler.dnsMetrics = func() dns.RateLimitMetrics {
+			return dns.RateLimitMetrics{ResponsesDropped: 3, ResponsesSlipped: 2, InteractionsDropped: 1}
+		}
+		defer func() { handler.dnsMetrics = nil }()
+
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleMetrics(w, req)
+
+		var response map[string]interface{}
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+
+		dnsSection, ok := response["dns"].(map[string]interface{})
+		if !ok {
+			t.Fatal("expected dns section in response")
+		}
+		rateLimit := dnsSection["rate_limit"].(map[string]interface{})
+		expected := map[string]float64{"responses_dropped": 3, "responses_slipped": 2, "interactions_dropped": 1}
+		for field, want := range expected {
+			if rateLimit[field] != want {
+				t.Errorf("expected dns.rate_limit.%s = %v, got %v", field, want, rateLimit[field])
+			}
+		}
+	})
+
 	t.Run("method not allowed", func(t *testing.T) {
 		req := httptest.NewRequest(http.MethodPost, "/metrics", nil)
 		w := httptest.NewRecorder()
@@ -400,6 +722,24 @@ func TestCaptureHandler_ServeHTTP(t *testing.T) {
 		}
 	})
 
+	t.Run("correlation tags", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Host = "payload123." + hook.ID + ".example.com"
+
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		interactions, _ := manager.PollInteractions(hook.ID)
+		if len(interactions) != 1 {
+			t.Fatalf("expected 1 interaction, got %d", len(interactions))
+		}
+
+		if tags := interactions[0].Tags; len(tags) != 1 || tags[0] != "payload123" {
+			t.Errorf("expected tag payload123, got %v", tags)
+		}
+	})
+
 	t.Run("invalid subdomain", func(t *testing.T) {
 		req := httptest.NewRequest(http.MethodGet, "/", nil)
 		req.Host = "invalid.com"
@@ -434,21 +774,26 @@ func TestCaptureHandler_ExtractHookID(t *testing.T) {
 		name     string
 		host     string
 		expected string
+		tags     []string
 	}{
-		{"valid subdomain", "abc123.example.com", "abc123"},
-		{"with port", "abc123.example.com:8080", "abc123"},
-		{"exact domain", "example.com", ""},
-		{"invalid domain", "other.com", ""},
-		{"multi-level subdomain", "sub.abc123.example.com", "sub"},
-		{"no subdomain", "example.com:80", ""},
+		{"valid subdomain", "abc123.example.com", "abc123", nil},
+		{"with port", "abc123.example.com:8080", "abc123", nil},
+		{"exact domain", "example.com", "", nil},
+		{"invalid domain", "other.com", "", nil},
+		{"multi-level subdomain", "sub.abc123.example.com", "abc123", []string{"sub"}},
+		{"several tags", "Payload123.Param.ABC123.example.com:443", "abc123", []string{"payload123", "param"}},
+		{"no subdomain", "example.com:80", "", nil},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := handler.extractHookID(tt.host)
+			result, tags := handler.extractHookID(tt.host)
 			if result != tt.expected {
 				t.Errorf("extractHookID(%q) = %q, want %q", tt.host, result, tt.expected)
 			}
+			if strings.Join(tags, ".") != strings.Join(tt.tags, ".") {
+				t.Errorf("extractHookID(%q) tags = %v, want %v", tt.host, tags, tt.tags)
+			}
 		})
 	}
 }
@@ -460,7 +805,8 @@ func TestExtractIP(t *testing.T) {
 		expected   string
 	}{
 		{"ipv4 with port", "192.168.1.1:12345", "192.168.1.1"},
-		{"ipv6 with port", "[::1]:8080", "[::1]"},
+		{"ipv6 with port", "[::1]:8080", "::1"},
+		{"ipv6 global with port", "[2001:db8::1]:443", "2001:db8::1"},
 		{"no port", "192.168.1.1", "192.168.1.1"},
 	}
 
@@ -740,3 +1086,251 @@ func TestAPIHandler_HandlePollBatch(t *testing.T) {
 		}
 	})
 }
+
+func TestAPIHandler_HandlePoll_Filters(t *testing.T) {
+	idCounter := 0
+	idGen := func() string {
+		idCounter++
+		return fmt.Sprintf("test-id-%d", idCounter)
+	}
+	manager := storage.NewMemoryManager(idGen)
+	evictorCfg := config.EvictionConfig{
+		CleanupInterval: 60,
+		InteractionTTL:  3600,
+		MaxPerHook:      100,
+		MaxMemoryMB:     100,
+	}
+	evictor := eviction.NewEvictor(manager, evictorCfg, slog.Default())
+	handler := NewAPIHandler(manager, evictor, "example.com", slog.Default(), idGen)
+
+	hook := manager.CreateHook("example.com")
+	manager.AddInteraction(hook.ID, storage.DNSInteraction("dns-a", "8.8.8.8", "test.com", "A"))
+	manager.AddInteraction(hook.ID, storage.DNSInteraction("dns-txt", "10.0.0.5", "test.com", "TXT"))
+	manager.AddInteraction(hook.ID, storage.HTTPInteraction("http-get", "10.0.0.6", "GET", "/api/cb", nil, ""))
+	manager.AddInteraction(hook.ID, storage.HTTPInteraction("http-post", "10.0.0.7", "POST", "/other", nil, ""))
+
+	poll := func(t *testing.T, query string) (int, []string) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/poll/"+hook.ID+query, nil)
+		w := httptest.NewRecorder()
+
+		handler.HandlePoll(w, req)
+
+		var response struct {
+			Interactions []storage.Interaction `json:"interactions"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+
+		ids := make([]string, 0, len(response.Interactions))
+		for _, interaction := range response.Interactions {
+			ids = append(ids, interaction.ID)
+		}
+		return w.Code, ids
+	}
+
+	t.Run("cursor mode rejects filters", func(t *testing.T) {
+		// Acknowledging the cursor would delete the skipped interactions
+		code, _ := poll(t, "?cursor=0&type=http&path=%5E/api/")
+		if code != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", code)
+		}
+	})
+
+	t.Run("cursor mode with limit", func(t *testing.T) {
+		code, ids := poll(t, "?cursor=0&limit=1")
+		if code != http.StatusOK || len(ids) != 1 || ids[0] != "dns-a" {
+			t.Errorf("expected dns-a, got %d %v", code, ids)
+		}
+	})
+
+	t.Run("source network and qtype", func(t *testing.T) {
+		code, ids := poll(t, "?source=10.0.0.0/8&qtype=TXT")
+		if code != http.StatusOK || len(ids) != 1 || ids[0] != "dns-txt" {
+			t.Errorf("expected dns-txt, got %d %v", code, ids)
+		}
+	})
+
+	t.Run("method list", func(t *testing.T) {
+		code, ids := poll(t, "?method=get,post")
+		if code != http.StatusOK || len(ids) != 2 {
+			t.Errorf("expected 2 HTTP interactions, got %d %v", code, ids)
+		}
+	})
+
+	t.Run("non-matching interactions stay queued", func(t *testing.T) {
+		_, ids := poll(t, "")
+		if len(ids) != 1 || ids[0] != "dns-a" {
+			t.Errorf("expected dns-a to remain, got %v", ids)
+		}
+	})
+
+	invalidTests := []struct {
+		name  string
+		query string
+	}{
+		{"unknown type", "?type=smtp"},
+		{"bad regex", "?path=%5B"},
+		{"bad source", "?source=not-an-ip"},
+		{"bad since", "?since=yesterday"},
+		{"bad until", "?until=2025-13-01"},
+	}
+
+	for _, tt := range invalidTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code, _ := poll(t, tt.query); code != http.StatusBadRequest {
+				t.Errorf("expected status 400, got %d", code)
+			}
+		})
+	}
+
+	t.Run("batch with filters", func(t *testing.T) {
+		manager.AddInteraction(hook.ID, storage.DNSInteraction("dns-aaaa", "1.1.1.1", "test.com", "AAAA"))
+		manager.AddInteraction(hook.ID, storage.HTTPInteraction("http-put", "1.1.1.1", "PUT", "/", nil, ""))
+
+		body := bytes.NewBufferString(`["` + hook.ID + `"]`)
+		req := httptest.NewRequest(http.MethodPost, "/poll?type=dns", body)
+		w := httptest.NewRecorder()
+
+		handler.HandlePollBatch(w, req)
+
+		var response struct {
+			Results map[string]storage.PollResult `json:"results"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+
+		result := response.Results[hook.ID]
+		if len(result.Interactions) != 1 || result.Interactions[0].ID != "dns-aaaa" {
+			t.Errorf("expected dns-aaaa, got %v", result.Interactions)
+		}
+	})
+}
+
+func TestParseFilter(t *testing.T) {
+	t.Run("no parameters", func(t *testing.T) {
+		filter, err := parseFilter(url.Values{"wait": {"10s"}})
+		if err != nil || filter != nil {
+			t.Errorf("expected nil filter, got %+v, %v", filter, err)
+		}
+	})
+
+	t.Run("all parameters", func(t *testing.T) {
+		query := url.Values{
+			"type":   {"DNS"},
+			"qtype":  {"A,AAAA"},
+			"source": {"192.0.2.1", "10.0.0.0/8"},
+			"since":  {"2025-10-01T10:00:00Z"},
+			"until":  {"2025-10-01T11:00:00Z"},
+		}
+
+		filter, err := parseFilter(query)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(filter.Types) != 1 || filter.Types[0] != storage.InteractionTypeDNS {
+			t.Errorf("unexpected types: %v", filter.Types)
+		}
+
+		if len(filter.QTypes) != 2 || len(filter.Sources) != 2 {
+			t.Errorf("unexpected qtypes %v or sources %v", filter.QTypes, filter.Sources)
+		}
+
+		if filter.Sources[0].Bits() != 32 {
+			t.Errorf("expected a single IP to become a /32, got %s", filter.Sources[0])
+		}
+
+		if filter.Until.Sub(filter.Since) != time.Hour {
+			t.Errorf("unexpected time range %v - %v", filter.Since, filter.Until)
+		}
+	})
+}
+
+func TestAPIHandler_HandlePoll_Limits(t *testing.T) {
+	idCounter := 0
+	idGen := func() string {
+		idCounter++
+		return fmt.Sprintf("test-id-%d", idCounter)
+	}
+	manager := storage.NewMemoryManager(idGen)
+	evictorCfg := config.EvictionConfig{
+		CleanupInterval: 60,
+		InteractionTTL:  3600,
+		MaxPerHook:      100,
+		MaxMemoryMB:     100,
+	}
+	evictor := eviction.NewEvictor(manager, evictorCfg, slog.Default())
+	handler := NewAPIHandler(manager, evictor, "example.com", slog.Default(), idGen)
+
+	hook := manager.CreateHook("example.com")
+	for i := 0; i < 3; i++ {
+		manager.AddInteraction(hook.ID, storage.DNSInteraction(fmt.Sprintf("int%d", i), "1.2.3.4", "test.com", "A"))
+	}
+
+	poll := func(t *testing.T, query string) (int, int, bool) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/poll/"+hook.ID+query, nil)
+		w := httptest.NewRecorder()
+
+		handler.HandlePoll(w, req)
+
+		var response struct {
+			Interactions []storage.Interaction `json:"interactions"`
+			HasMore      bool                  `json:"has_more"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+		return w.Code, len(response.Interactions), response.HasMore
+	}
+
+	t.Run("cursor mode", func(t *testing.T) {
+		code, count, hasMore := poll(t, "?cursor=0&limit=2")
+		if code != http.StatusOK || count != 2 || !hasMore {
+			t.Errorf("expected 2 interactions and has_more, got %d %d %v", code, count, hasMore)
+		}
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		code, count, hasMore := poll(t, "?limit=2")
+		if code != http.StatusOK || count != 2 || !hasMore {
+			t.Errorf("expected 2 interactions and has_more, got %d %d %v", code, count, hasMore)
+		}
+
+		_, count, hasMore = poll(t, "?limit=2")
+		if count != 1 || hasMore {
+			t.Errorf("expected the remaining interaction, got %d %v", count, hasMore)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, query := range []string{"?limit=-1", "?limit=abc", "?max_bytes=-5"} {
+			if code, _, _ := poll(t, query); code != http.StatusBadRequest {
+				t.Errorf("%s: expected status 400, got %d", query, code)
+			}
+		}
+	})
+
+	t.Run("batch", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			manager.AddInteraction(hook.ID, storage.DNSInteraction(fmt.Sprintf("batch%d", i), "1.2.3.4", "test.com", "A"))
+		}
+
+		body := bytes.NewBufferString(`["` + hook.ID + `"]`)
+		req := httptest.NewRequest(http.MethodPost, "/poll?limit=1", body)
+		w := httptest.NewRecorder()
+
+		handler.HandlePollBatch(w, req)
+
+		var response struct {
+			Results map[string]storage.PollResult `json:"results"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+
+		result := response.Results[hook.ID]
+		if len(result.Interactions) != 1 || !result.HasMore {
+			t.Errorf("expected 1 interaction and has_more, got %+v", result)
+		}
+	})
+}
@@ -0,0 +1,234 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jomar/hookd/internal/storage"
+)
+
+const (
+	// maxHookDNSRecords caps the custom DNS records of a single hook
+	maxHookDNSRecords = 32
+
+	// maxDNSRecordTTL caps the TTL of custom DNS records (one day)
+	maxDNSRecordTTL = 86400
+
+	// maxTXTRecordLength caps TXT values, which are split into 255 byte strings
+	maxTXTRecordLength = 1024
+)
+
+// dnsRecordsRequest is the body of PUT /hooks/:id/dns
+type dnsRecordsRequest struct {
+	Records []storage.DNSRecord `json:"records"`
+}
+
+// handleHookDNS handles the custom DNS records of a hook:
+// GET /hooks/:id/dns, PUT /hooks/:id/dns (replace all) and DELETE /hooks/:id/dns
+func (h *APIHandler) handleHookDNS(w http.ResponseWriter, r *http.Request, hookID string) {
+	var records []storage.DNSRecord
+
+	switch r.Method {
+	case http.MethodGet:
+		hook, exists := h.storage.GetHook(hookID)
+		if !exists {
+			respondJSON(w, http.StatusNotFound, map[string]string{
+				"error": "Hook not found",
+			})
+			return
+		}
+
+		respondDNSRecords(w, hook.DNSRecords)
+		return
+
+	case http.MethodPut:
+		var req dnsRecordsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondJSON(w, http.StatusBadRequest, map[string]string{
+				"error": "Invalid request body",
+			})
+			return
+		}
+
+		var err error
+		records, err = normalizeDNSRecords(req.Records)
+		if err != nil {
+			respondJSON(w, http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
+	case http.MethodDelete:
+		// Clearing the records restores the default answers
+
+	default:
+		respondJSON(w, http.StatusMethodNotAllowed, map[string]string{
+			"error": "Method not allowed",
+		})
+		return
+	}
+
+	hook, exists := h.storage.SetDNSRecords(hookID, records)
+	if !exists {
+		respondJSON(w, http.StatusNotFound, map[string]string{
+			"error": "Hook not found",
+		})
+		return
+	}
+
+	h.logger.Info("hook dns records updated", "id", hookID, "records", len(hook.DNSRecords), "client", r.RemoteAddr)
+	respondDNSRecords(w, hook.DNSRecords)
+}
+
+// respondDNSRecords writes the custom DNS records of a hook
+func respondDNSRecords(w http.ResponseWriter, records []storage.DNSRecord) {
+	if records == nil {
+		records = []storage.DNSRecord{}
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"records": records,
+	})
+}
+
+// normalizeDNSRecords validates custom DNS records and converts them to their
+// canonical form: uppercase types, lowercase names and fully qualified targets
+func normalizeDNSRecords(records []storage.DNSRecord) ([]storage.DNSRecord, error) {
+	if len(records) > maxHookDNSRecords {
+		return nil, fmt.Errorf("Too many records (max %d)", maxHookDNSRecords)
+	}
+
+	normalized := make([]storage.DNSRecord, 0, len(records))
+	typesByName := make(map[string][]string)
+
+	for i, rec := range records {
+		rec.Type = strings.ToUpper(rec.Type)
+		rec.Name = strings.ToLower(strings.TrimSuffix(rec.Name, "."))
+
+		if rec.Name != "" && rec.Name != "*" && !isRecordName(rec.Name) {
+			return nil, fmt.Errorf("Invalid record %d: name must be a sub-label such as \"www\" or \"*\"", i)
+		}
+
+		if rec.TTL < 0 || rec.TTL > maxDNSRecordTTL {
+			return nil, fmt.Errorf("Invalid record %d: ttl must be between 0 and %d", i, maxDNSRecordTTL)
+		}
+
+		value, err := normalizeDNSRecordValue(rec)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid record %d: %v", i, err)
+		}
+		rec.Value = value
+
+		if rec.Type != "MX" {
+			rec.Priority = 0
+		}
+
+		typesByName[rec.Name] = append(typesByName[rec.Name], rec.Type)
+		normalized = append(normalized, rec)
+	}
+
+	// A CNAME cannot coexist with any other record of the same name (RFC 1034)
+	for name, types := range typesByName {
+		for _, t := range types {
+			if t == "CNAME" && len(types) > 1 {
+				return nil, fmt.Errorf("Invalid records: CNAME for %q cannot be combined with other records", name)
+			}
+		}
+	}
+
+	return normalized, nil
+}
+
+// normalizeDNSRecordValue validates the value of a record according to its type
+func normalizeDNSRecordValue(rec storage.DNSRecord) (string, error) {
+	switch rec.Type {
+	case "A":
+		ip := net.ParseIP(rec.Value)
+		if ip == nil || ip.To4() == nil {
+			return "", fmt.Errorf("value must be an IPv4 address")
+		}
+		return ip.String(), nil
+
+	case "AAAA":
+		ip := net.ParseIP(rec.Value)
+		if ip == nil || ip.To4() != nil {
+			return "", fmt.Errorf("value must be an IPv6 address")
+		}
+		return ip.String(), nil
+
+	case "CNAME", "MX":
+		host := strings.ToLower(strings.TrimSuffix(rec.Value, "."))
+		if !isRecordName(host) {
+			return "", fmt.Errorf("value must be a hostname")
+		}
+		if rec.Type == "MX" && (rec.Priority < 0 || rec.Priority > 65535) {
+			return "", fmt.Errorf("priority must be between 0 and 65535")
+		}
+		return host + ".", nil
+
+	case "TXT":
+		if rec.Value == "" || len(rec.Value) > maxTXTRecordLength {
+			return "", fmt.Errorf("value must be between 1 and %d characters", maxTXTRecordLength)
+		}
+		return rec.Value, nil
+
+	case "CAA":
+		// <flags> <tag> <value>, e.g. 0 issue "letsencrypt.org"
+		fields := strings.SplitN(strings.TrimSpace(rec.Value), " ", 3)
+		if len(fields) != 3 {
+			return "", fmt.Errorf("value must be \"<flags> <tag> <value>\"")
+		}
+
+		flags, err := strconv.ParseUint(fields[0], 10, 8)
+		if err != nil {
+			return "", fmt.Errorf("CAA flags must be between 0 and 255")
+		}
+
+		tag := strings.ToLower(fields[1])
+		if !isCAATag(tag) {
+			return "", fmt.Errorf("CAA tag must be alphanumeric")
+		}
+
+		value := strings.Trim(strings.TrimSpace(fields[2]), `"`)
+		return fmt.Sprintf("%d %s %s", flags, tag, value), nil
+
+	default:
+		return "", fmt.Errorf("type must be one of: A, AAAA, CNAME, TXT, MX, CAA")
+	}
+}
+
+// isRecordName reports whether s is a dotted sequence of DNS labels. Labels
+// may start with an underscore so service names such as "_dmarc" are allowed
+func isRecordName(s string) bool {
+	if len(s) == 0 || len(s) > 253 {
+		return false
+	}
+
+	for _, label := range strings.Split(s, ".") {
+		if !isDNSLabel(strings.TrimPrefix(label, "_")) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isCAATag reports whether s is a valid CAA property tag (RFC 8659)
+func isCAATag(s string) bool {
+	if len(s) == 0 || len(s) > 15 {
+		return false
+	}
+
+	for _, c := range s {
+		if !((c >= 'a' && c <= 'z') || (c >= '0' && c <= '9')) {
+			return false
+		}
+	}
+
+	return true
+}
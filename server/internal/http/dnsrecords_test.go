@@ -0,0 +1,163 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jomar/hookd/internal/storage"
+)
+
+func TestAPIHandler_HandleHookDNS(t *testing.T) {
+	handler, manager := newHooksTestHandler()
+	hook := manager.CreateHook("example.com")
+
+	t.Run("put", func(t *testing.T) {
+		body := bytes.NewBufferString(`{"records": [
+			{"type": "a", "value": "10.0.0.1"},
+			{"name": "WWW", "type": "CNAME", "value": "Target.Example.net"},
+			{"name": "*", "type": "MX", "value": "mail.example.net", "priority": 5, "ttl": 300},
+			{"type": "CAA", "value": "0 issue \"letsencrypt.org\""}
+		]}`)
+		req := httptest.NewRequest(http.MethodPut, "/hooks/"+hook.ID+"/dns", body)
+		w := httptest.NewRecorder()
+
+		handler.HandleHook(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+
+		var response dnsRecordsRequest
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+
+		expected := []storage.DNSRecord{
+			{Type: "A", Value: "10.0.0.1"},
+			{Name: "www", Type: "CNAME", Value: "target.example.net."},
+			{Name: "*", Type: "MX", Value: "mail.example.net.", Priority: 5, TTL: 300},
+			{Type: "CAA", Value: "0 issue letsencrypt.org"},
+		}
+		if len(response.Records) != len(expected) {
+			t.Fatalf("expected %d records, got %d", len(expected), len(response.Records))
+		}
+		for i := range expected {
+			if response.Records[i] != expected[i] {
+				t.Errorf("record %d: expected %+v, got %+v", i, expected[i], response.Records[i])
+			}
+		}
+
+		stored, _ := manager.GetHook(hook.ID)
+		if len(stored.DNSRecords) != len(expected) {
+			t.Errorf("expected %d stored records, got %d", len(expected), len(stored.DNSRecords))
+		}
+	})
+
+	t.Run("get", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/hooks/"+hook.ID+"/dns", nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleHook(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+
+		var response dnsRecordsRequest
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+
+		if len(response.Records) != 4 {
+			t.Errorf("expected 4 records, got %d", len(response.Records))
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodDelete, "/hooks/"+hook.ID+"/dns", nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleHook(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+
+		stored, _ := manager.GetHook(hook.ID)
+		if len(stored.DNSRecords) != 0 {
+			t.Errorf("expected records to be cleared, got %v", stored.DNSRecords)
+		}
+	})
+
+	t.Run("unknown hook", func(t *testing.T) {
+		body := bytes.NewBufferString(`{"records": []}`)
+		req := httptest.NewRequest(http.MethodPut, "/hooks/nonexistent/dns", body)
+		w := httptest.NewRecorder()
+
+		handler.HandleHook(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", w.Code)
+		}
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/hooks/"+hook.ID+"/dns", nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleHook(w, req)
+
+		if w.Code != http.StatusMethodNotAllowed {
+			t.Errorf("expected status 405, got %d", w.Code)
+		}
+	})
+}
+
+func TestNormalizeDNSRecords_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		record storage.DNSRecord
+	}{
+		{"unknown type", storage.DNSRecord{Type: "SRV", Value: "x"}},
+		{"ipv6 in A", storage.DNSRecord{Type: "A", Value: "2001:db8::1"}},
+		{"ipv4 in AAAA", storage.DNSRecord{Type: "AAAA", Value: "10.0.0.1"}},
+		{"invalid CNAME target", storage.DNSRecord{Type: "CNAME", Value: "not a host"}},
+		{"invalid name", storage.DNSRecord{Name: "a..b", Type: "A", Value: "10.0.0.1"}},
+		{"negative ttl", storage.DNSRecord{Type: "A", Value: "10.0.0.1", TTL: -1}},
+		{"MX priority out of range", storage.DNSRecord{Type: "MX", Value: "mail.example.net", Priority: 70000}},
+		{"empty TXT", storage.DNSRecord{Type: "TXT"}},
+		{"CAA without tag", storage.DNSRecord{Type: "CAA", Value: "0 issue"}},
+		{"CAA flags out of range", storage.DNSRecord{Type: "CAA", Value: "256 issue letsencrypt.org"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := normalizeDNSRecords([]storage.DNSRecord{tt.record}); err == nil {
+				t.Errorf("expected %+v to be rejected", tt.record)
+			}
+		})
+	}
+
+	t.Run("CNAME with other records", func(t *testing.T) {
+		records := []storage.DNSRecord{
+			{Name: "www", Type: "CNAME", Value: "target.example.net"},
+			{Name: "www", Type: "TXT", Value: "hello"},
+		}
+		if _, err := normalizeDNSRecords(records); err == nil {
+			t.Error("expected CNAME combined with TXT to be rejected")
+		}
+	})
+
+	t.Run("too many records", func(t *testing.T) {
+		records := make([]storage.DNSRecord, maxHookDNSRecords+1)
+		for i := range records {
+			records[i] = storage.DNSRecord{Type: "TXT", Value: "x"}
+		}
+		if _, err := normalizeDNSRecords(records); err == nil {
+			t.Error("expected too many records to be rejected")
+		}
+	})
+}
@@ -0,0 +1,80 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/jomar/hookd/internal/storage"
+)
+
+// handleHookExfil handles the DNS exfiltration decoding of a hook:
+// GET /hooks/:id/exfil (reassembled payload), PUT /hooks/:id/exfil (enable and
+// start over) and DELETE /hooks/:id/exfil
+func (h *APIHandler) handleHookExfil(w http.ResponseWriter, r *http.Request, hookID string) {
+	var exfil *storage.ExfilConfig
+
+	switch r.Method {
+	case http.MethodGet:
+		hook, exists := h.storage.GetHook(hookID)
+		if !exists {
+			respondJSON(w, http.StatusNotFound, map[string]string{
+				"error": "Hook not found",
+			})
+			return
+		}
+
+		if hook.Exfil == nil {
+			respondJSON(w, http.StatusNotFound, map[string]string{
+				"error": "Exfiltration decoding not enabled",
+			})
+			return
+		}
+
+		chunks, _ := h.storage.ExfilChunks(hookID)
+		respondJSON(w, http.StatusOK, storage.ReassembleExfil(hook.Exfil.Encoding, chunks))
+		return
+
+	case http.MethodPut:
+		var req storage.ExfilConfig
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondJSON(w, http.StatusBadRequest, map[string]string{
+				"error": "Invalid request body",
+			})
+			return
+		}
+
+		req.Encoding = strings.ToLower(req.Encoding)
+		switch req.Encoding {
+		case storage.ExfilEncodingHex, storage.ExfilEncodingBase32, storage.ExfilEncodingBase64URL:
+		default:
+			respondJSON(w, http.StatusBadRequest, map[string]string{
+				"error": "Invalid encoding: must be hex, base32 or base64url",
+			})
+			return
+		}
+		exfil = &req
+
+	case http.MethodDelete:
+		// Disabling decoding drops the collected chunks
+
+	default:
+		respondJSON(w, http.StatusMethodNotAllowed, map[string]string{
+			"error": "Method not allowed",
+		})
+		return
+	}
+
+	hook, exists := h.storage.SetExfil(hookID, exfil)
+	if !exists {
+		respondJSON(w, http.StatusNotFound, map[string]string{
+			"error": "Hook not found",
+		})
+		return
+	}
+
+	h.logger.Info("hook exfiltration decoding updated", "id", hookID, "enabled", hook.Exfil != nil, "client", r.RemoteAddr)
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"exfil": hook.Exfil,
+	})
+}
@@ -0,0 +1,92 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jomar/hookd/internal/storage"
+)
+
+func TestAPIHandler_HandleHookExfil(t *testing.T) {
+	handler, manager := newHooksTestHandler()
+	hook := manager.CreateHook("example.com")
+
+	t.Run("get while disabled", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/hooks/"+hook.ID+"/exfil", nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleHook(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", w.Code)
+		}
+	})
+
+	t.Run("invalid encoding", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPut, "/hooks/"+hook.ID+"/exfil", bytes.NewBufferString(`{"encoding": "rot13"}`))
+		w := httptest.NewRecorder()
+
+		handler.HandleHook(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("put", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPut, "/hooks/"+hook.ID+"/exfil", bytes.NewBufferString(`{"encoding": "HEX"}`))
+		w := httptest.NewRecorder()
+
+		handler.HandleHook(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+
+		stored, _ := manager.GetHook(hook.ID)
+		if stored.Exfil == nil || stored.Exfil.Encoding != storage.ExfilEncodingHex {
+			t.Errorf("expected hex decoding to be enabled, got %+v", stored.Exfil)
+		}
+	})
+
+	t.Run("get", func(t *testing.T) {
+		manager.AddExfilChunk(hook.ID, storage.ExfilChunk{Seq: 0, Data: "7768"})
+		manager.AddExfilChunk(hook.ID, storage.ExfilChunk{Seq: 1, Data: "6f616d69"})
+
+		req := httptest.NewRequest(http.MethodGet, "/hooks/"+hook.ID+"/exfil", nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleHook(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+
+		var payload storage.ExfilPayload
+		if err := json.NewDecoder(w.Body).Decode(&payload); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+
+		if payload.Text != "whoami" || string(payload.Data) != "whoami" || payload.Chunks != 2 {
+			t.Errorf("unexpected payload: %+v", payload)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodDelete, "/hooks/"+hook.ID+"/exfil", nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleHook(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+
+		if chunks, _ := manager.ExfilChunks(hook.ID); len(chunks) != 0 {
+			t.Errorf("expected chunks to be dropped, got %v", chunks)
+		}
+	})
+}
@@ -0,0 +1,314 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jomar/hookd/internal/storage"
+)
+
+const (
+	// defaultHookListLimit is the page size used when ?limit= is not given
+	defaultHookListLimit = 100
+
+	// maxHookListLimit caps the page size of GET /hooks
+	maxHookListLimit = 1000
+
+	// Limits on the free-form metadata attached to a hook
+	maxHookLabels         = 32
+	maxHookLabelLength    = 256
+	maxHookDescriptionLen = 1024
+
+	// maxHookPrefixLength keeps "prefix-" plus a generated ID within a 63 character DNS label
+	maxHookPrefixLength = 32
+)
+
+// hookInfo describes a hook together with its lifecycle state
+type hookInfo struct {
+	*storage.Hook
+	ExpiresAt           time.Time `json:"expires_at"`
+	PendingInteractions int       `json:"pending_interactions"`
+}
+
+// HandleListHooks handles GET /hooks?limit=&offset=
+// Hooks are ordered by creation time, oldest first.
+func (h *APIHandler) HandleListHooks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondJSON(w, http.StatusMethodNotAllowed, map[string]string{
+			"error": "Method not allowed",
+		})
+		return
+	}
+
+	limit, err := parsePageParam(r.URL.Query().Get("limit"), defaultHookListLimit)
+	if err != nil || limit == 0 {
+		respondJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Invalid limit",
+		})
+		return
+	}
+	if limit > maxHookListLimit {
+		limit = maxHookListLimit
+	}
+
+	offset, err := parsePageParam(r.URL.Query().Get("offset"), 0)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Invalid offset",
+		})
+		return
+	}
+
+	hooks := h.storage.GetAllHooks()
+	sort.Slice(hooks, func(i, j int) bool {
+		if hooks[i].CreatedAt.Equal(hooks[j].CreatedAt) {
+			return hooks[i].ID < hooks[j].ID
+		}
+		return hooks[i].CreatedAt.Before(hooks[j].CreatedAt)
+	})
+
+	total := len(hooks)
+	page := make([]hookInfo, 0, limit)
+	for i := offset; i < total && len(page) < limit; i++ {
+		page = append(page, h.hookInfo(hooks[i]))
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"hooks":  page,
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// HandleHook handles the /hooks/:id endpoints:
+// GET /hooks/:id, DELETE /hooks/:id, POST /hooks/:id/renew, /hooks/:id/dns,
+// /hooks/:id/rebind, /hooks/:id/exfil and /hooks/:id/response
+func (h *APIHandler) HandleHook(w http.ResponseWriter, r *http.Request) {
+	// Path format: /hooks/abc123 or /hooks/abc123/{renew,dns,rebind,exfil,response}
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[1] == "" {
+		respondJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Invalid path format",
+		})
+		return
+	}
+
+	hookID := parts[1]
+
+	if len(parts) == 3 {
+		switch parts[2] {
+		case "renew":
+			h.handleRenewHook(w, r, hookID)
+		case "dns":
+			h.handleHookDNS(w, r, hookID)
+		case "rebind":
+			h.handleHookRebind(w, r, hookID)
+		case "exfil":
+			h.handleHookExfil(w, r, hookID)
+		case "response":
+			h.handleHookResponse(w, r, hookID)
+		default:
+			respondJSON(w, http.StatusNotFound, map[string]string{
+				"error": "Not found",
+			})
+		}
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		hook, exists := h.storage.GetHook(hookID)
+		if !exists {
+			respondJSON(w, http.StatusNotFound, map[string]string{
+				"error": "Hook not found",
+			})
+			return
+		}
+
+		respondJSON(w, http.StatusOK, h.hookInfo(hook))
+
+	case http.MethodDelete:
+		if _, exists := h.storage.GetHook(hookID); !exists {
+			respondJSON(w, http.StatusNotFound, map[string]string{
+				"error": "Hook not found",
+			})
+			return
+		}
+
+		h.storage.DeleteHook(hookID)
+		h.logger.Info("hook deleted", "id", hookID, "client", r.RemoteAddr)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		respondJSON(w, http.StatusMethodNotAllowed, map[string]string{
+			"error": "Method not allowed",
+		})
+	}
+}
+
+// handleRenewHook handles POST /hooks/:id/renew
+// The hook expiry is moved to now plus its TTL, or the requested one (at most eviction.hook_ttl).
+func (h *APIHandler) handleRenewHook(w http.ResponseWriter, r *http.Request, hookID string) {
+	if r.Method != http.MethodPost {
+		respondJSON(w, http.StatusMethodNotAllowed, map[string]string{
+			"error": "Method not allowed",
+		})
+		return
+	}
+
+	// Parse request body (optional)
+	var req struct {
+		TTL string `json:"ttl,omitempty"`
+	}
+
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondJSON(w, http.StatusBadRequest, map[string]string{
+				"error": "Invalid request body",
+			})
+			return
+		}
+	}
+
+	hook, exists := h.storage.GetHook(hookID)
+	if !exists {
+		respondJSON(w, http.StatusNotFound, map[string]string{
+			"error": "Hook not found",
+		})
+		return
+	}
+
+	// Renew by the hook's own TTL unless a shorter one is requested
+	ttl := h.evictor.HookTTLFor(hook)
+	if req.TTL != "" {
+		requested, err := parseDuration(req.TTL)
+		if err != nil || requested == 0 {
+			respondJSON(w, http.StatusBadRequest, map[string]string{
+				"error": "Invalid ttl",
+			})
+			return
+		}
+		ttl = min(requested, h.evictor.HookTTL())
+	}
+
+	hook, exists = h.storage.RenewHook(hookID, time.Now().Add(ttl))
+	if !exists {
+		respondJSON(w, http.StatusNotFound, map[string]string{
+			"error": "Hook not found",
+		})
+		return
+	}
+
+	h.logger.Info("hook renewed", "id", hookID, "expires_at", hook.ExpiresAt, "client", r.RemoteAddr)
+	respondJSON(w, http.StatusOK, h.hookInfo(hook))
+}
+
+// hookInfo builds the lifecycle view of a hook
+func (h *APIHandler) hookInfo(hook *storage.Hook) hookInfo {
+	return hookInfo{
+		Hook:                hook,
+		ExpiresAt:           h.evictor.HookExpiresAt(hook),
+		PendingInteractions: h.storage.PendingCount(hook.ID),
+	}
+}
+
+// registerRequest is the optional body of POST /register
+type registerRequest struct {
+	Count       int               `json:"count,omitempty"`
+	ID          string            `json:"id,omitempty"`
+	Prefix      string            `json:"prefix,omitempty"`
+	TTL         string            `json:"ttl,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Description string            `json:"description,omitempty"`
+}
+
+// hookOptions validates the optional settings of a new hook.
+// The TTL is capped by eviction.hook_ttl.
+func (h *APIHandler) hookOptions(req registerRequest) (storage.HookOptions, error) {
+	opts := storage.HookOptions{
+		// DNS names are case-insensitive, IDs are stored in lowercase
+		ID:          strings.ToLower(req.ID),
+		Prefix:      strings.ToLower(req.Prefix),
+		Labels:      req.Labels,
+		Description: req.Description,
+	}
+
+	if opts.ID != "" {
+		if req.Count > 1 {
+			return opts, fmt.Errorf("id cannot be combined with count")
+		}
+		if opts.Prefix != "" {
+			return opts, fmt.Errorf("id cannot be combined with prefix")
+		}
+		if !isDNSLabel(opts.ID) {
+			return opts, fmt.Errorf("Invalid id: must be a DNS label (letters, digits and hyphens, at most 63 characters)")
+		}
+		if h.reservedID != nil && h.reservedID(opts.ID) {
+			return opts, fmt.Errorf("Invalid id: %s is a nameserver or static name of the zone", opts.ID)
+		}
+	}
+
+	if opts.Prefix != "" && (len(opts.Prefix) > maxHookPrefixLength || !isDNSLabel(opts.Prefix)) {
+		return opts, fmt.Errorf("Invalid prefix: must be a DNS label of at most %d characters", maxHookPrefixLength)
+	}
+
+	ttl, labels, description := req.TTL, req.Labels, req.Description
+	if ttl != "" {
+		parsed, err := parseDuration(ttl)
+		if err != nil || parsed < time.Second {
+			return opts, fmt.Errorf("Invalid ttl")
+		}
+		opts.TTL = min(parsed, h.evictor.HookTTL())
+	}
+
+	if len(labels) > maxHookLabels {
+		return opts, fmt.Errorf("Too many labels (max %d)", maxHookLabels)
+	}
+
+	for k, v := range labels {
+		if k == "" || len(k) > maxHookLabelLength || len(v) > maxHookLabelLength {
+			return opts, fmt.Errorf("Invalid label %q", k)
+		}
+	}
+
+	if len(description) > maxHookDescriptionLen {
+		return opts, fmt.Errorf("Description too long (max %d)", maxHookDescriptionLen)
+	}
+
+	return opts, nil
+}
+
+// isDNSLabel reports whether s is a valid lowercase DNS label (RFC 1123)
+func isDNSLabel(s string) bool {
+	if len(s) == 0 || len(s) > 63 || s[0] == '-' || s[len(s)-1] == '-' {
+		return false
+	}
+
+	for _, c := range s {
+		if !((c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '-') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// parsePageParam parses a non-negative pagination parameter
+func parsePageParam(value string, fallback int) (int, error) {
+	if value == "" {
+		return fallback, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, strconv.ErrSyntax
+	}
+
+	return n, nil
+}
@@ -0,0 +1,494 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jomar/hookd/internal/config"
+	"github.com/jomar/hookd/internal/eviction"
+	"github.com/jomar/hookd/internal/storage"
+)
+
+func newHooksTestHandler() (*APIHandler, storage.Manager) {
+	counter := 0
+	idGen := func() string {
+		counter++
+		return fmt.Sprintf("hook%d", counter)
+	}
+	manager := storage.NewMemoryManager(idGen)
+	evictorCfg := config.EvictionConfig{
+		CleanupInterval: 60,
+		InteractionTTL:  3600,
+		HookTTL:         24 * time.Hour,
+		MaxPerHook:      100,
+		MaxMemoryMB:     100,
+	}
+	evictor := eviction.NewEvictor(manager, evictorCfg, slog.Default())
+
+	return NewAPIHandler(manager, evictor, "example.com", slog.Default(), idGen), manager
+}
+
+func TestAPIHandler_HandleListHooks(t *testing.T) {
+	handler, manager := newHooksTestHandler()
+	for i := 0; i < 5; i++ {
+		manager.CreateHook("example.com")
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedIDs    []string
+	}{
+		{"default page", "", http.StatusOK, []string{"hook1", "hook2", "hook3", "hook4", "hook5"}},
+		{"limit", "?limit=2", http.StatusOK, []string{"hook1", "hook2"}},
+		{"limit and offset", "?limit=2&offset=2", http.StatusOK, []string{"hook3", "hook4"}},
+		{"offset past end", "?offset=10", http.StatusOK, []string{}},
+		{"invalid limit", "?limit=abc", http.StatusBadRequest, nil},
+		{"zero limit", "?limit=0", http.StatusBadRequest, nil},
+		{"negative offset", "?offset=-1", http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/hooks"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			handler.HandleListHooks(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var response struct {
+				Hooks []hookInfo `json:"hooks"`
+				Total int        `json:"total"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+
+			if response.Total != 5 {
+				t.Errorf("expected total 5, got %d", response.Total)
+			}
+
+			if len(response.Hooks) != len(tt.expectedIDs) {
+				t.Fatalf("expected %d hooks, got %d", len(tt.expectedIDs), len(response.Hooks))
+			}
+
+			for i, id := range tt.expectedIDs {
+				if response.Hooks[i].ID != id {
+					t.Errorf("expected hook %d to be %s, got %s", i, id, response.Hooks[i].ID)
+				}
+			}
+		})
+	}
+
+	t.Run("method not allowed", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/hooks", nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleListHooks(w, req)
+
+		if w.Code != http.StatusMethodNotAllowed {
+			t.Errorf("expected status 405, got %d", w.Code)
+		}
+	})
+}
+
+func TestAPIHandler_HandleHook(t *testing.T) {
+	handler, manager := newHooksTestHandler()
+	hook := manager.CreateHook("example.com")
+	manager.AddInteraction(hook.ID, storage.DNSInteraction("int1", "1.2.3.4", "test.com", "A"))
+	manager.AddInteraction(hook.ID, storage.DNSInteraction("int2", "1.2.3.4", "test.com", "A"))
+
+	t.Run("get", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/hooks/"+hook.ID, nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleHook(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+
+		var response hookInfo
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+
+		if response.ID != hook.ID || response.DNS != hook.DNS {
+			t.Errorf("unexpected hook metadata: %+v", response.Hook)
+		}
+
+		if response.PendingInteractions != 2 {
+			t.Errorf("expected 2 pending interactions, got %d", response.PendingInteractions)
+		}
+
+		if !response.ExpiresAt.Equal(hook.CreatedAt.Add(24 * time.Hour)) {
+			t.Errorf("expected expiry at created_at + hook_ttl, got %v", response.ExpiresAt)
+		}
+
+		// Inspecting a hook must not consume its interactions
+		if stats := manager.Stats(); stats.InteractionsTotal != 2 {
+			t.Errorf("expected 2 interactions to remain, got %d", stats.InteractionsTotal)
+		}
+	})
+
+	t.Run("renew", func(t *testing.T) {
+		before := time.Now()
+		req := httptest.NewRequest(http.MethodPost, "/hooks/"+hook.ID+"/renew", nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleHook(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+
+		var response hookInfo
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+
+		if response.ExpiresAt.Before(before.Add(24 * time.Hour)) {
+			t.Errorf("expected expiry to be extended by hook_ttl, got %v", response.ExpiresAt)
+		}
+	})
+
+	t.Run("renew with ttl", func(t *testing.T) {
+		before := time.Now()
+		body := bytes.NewBufferString(`{"ttl": "1h"}`)
+		req := httptest.NewRequest(http.MethodPost, "/hooks/"+hook.ID+"/renew", body)
+		w := httptest.NewRecorder()
+
+		handler.HandleHook(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+
+		stored, _ := manager.GetHook(hook.ID)
+		if stored.ExpiresAt.Before(before.Add(time.Hour)) || stored.ExpiresAt.After(time.Now().Add(time.Hour)) {
+			t.Errorf("expected expiry one hour from now, got %v", stored.ExpiresAt)
+		}
+	})
+
+	t.Run("renew ttl is capped", func(t *testing.T) {
+		body := bytes.NewBufferString(`{"ttl": "720h"}`)
+		req := httptest.NewRequest(http.MethodPost, "/hooks/"+hook.ID+"/renew", body)
+		w := httptest.NewRecorder()
+
+		handler.HandleHook(w, req)
+
+		stored, _ := manager.GetHook(hook.ID)
+		if stored.ExpiresAt.After(time.Now().Add(24 * time.Hour)) {
+			t.Errorf("expected expiry capped at hook_ttl, got %v", stored.ExpiresAt)
+		}
+	})
+
+	t.Run("renew invalid ttl", func(t *testing.T) {
+		body := bytes.NewBufferString(`{"ttl": "soon"}`)
+		req := httptest.NewRequest(http.MethodPost, "/hooks/"+hook.ID+"/renew", body)
+		w := httptest.NewRecorder()
+
+		handler.HandleHook(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodDelete, "/hooks/"+hook.ID, nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleHook(w, req)
+
+		if w.Code != http.StatusNoContent {
+			t.Fatalf("expected status 204, got %d", w.Code)
+		}
+
+		if _, exists := manager.GetHook(hook.ID); exists {
+			t.Error("expected hook to be deleted")
+		}
+	})
+
+	errorTests := []struct {
+		name           string
+		method         string
+		path           string
+		expectedStatus int
+	}{
+		{"get nonexistent", http.MethodGet, "/hooks/nonexistent", http.StatusNotFound},
+		{"delete nonexistent", http.MethodDelete, "/hooks/nonexistent", http.StatusNotFound},
+		{"renew nonexistent", http.MethodPost, "/hooks/nonexistent/renew", http.StatusNotFound},
+		{"renew wrong method", http.MethodGet, "/hooks/nonexistent/renew", http.StatusMethodNotAllowed},
+		{"unknown subresource", http.MethodGet, "/hooks/nonexistent/other", http.StatusNotFound},
+		{"wrong method", http.MethodPut, "/hooks/nonexistent", http.StatusMethodNotAllowed},
+		{"missing id", http.MethodGet, "/hooks/", http.StatusBadRequest},
+		{"too many segments", http.MethodGet, "/hooks/a/b/c", http.StatusBadRequest},
+	}
+
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			w := httptest.NewRecorder()
+
+			handler.HandleHook(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+}
+
+func TestAPIHandler_HandleRegister_Options(t *testing.T) {
+	handler, manager := newHooksTestHandler()
+
+	t.Run("ttl, labels and description", func(t *testing.T) {
+		body := bytes.NewBufferString(`{"ttl": "2h", "labels": {"project": "acme", "scanner": "nuclei"}, "description": "login SSRF"}`)
+		req := httptest.NewRequest(http.MethodPost, "/register", body)
+		w := httptest.NewRecorder()
+
+		handler.HandleRegister(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+
+		var response storage.Hook
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+
+		if response.TTL != 7200 {
+			t.Errorf("expected ttl 7200, got %d", response.TTL)
+		}
+
+		if response.Labels["project"] != "acme" || response.Labels["scanner"] != "nuclei" {
+			t.Errorf("unexpected labels: %v", response.Labels)
+		}
+
+		if response.Description != "login SSRF" {
+			t.Errorf("unexpected description: %q", response.Description)
+		}
+
+		stored, _ := manager.GetHook(response.ID)
+		if stored.TTL != 7200 || stored.Labels["project"] != "acme" {
+			t.Errorf("expected options to be stored, got %+v", stored)
+		}
+	})
+
+	t.Run("ttl is capped by hook_ttl", func(t *testing.T) {
+		body := bytes.NewBufferString(`{"ttl": "720h"}`)
+		req := httptest.NewRequest(http.MethodPost, "/register", body)
+		w := httptest.NewRecorder()
+
+		handler.HandleRegister(w, req)
+
+		var response storage.Hook
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+
+		if response.TTL != int((24 * time.Hour).Seconds()) {
+			t.Errorf("expected ttl capped at 86400, got %d", response.TTL)
+		}
+	})
+
+	t.Run("options apply to every hook", func(t *testing.T) {
+		body := bytes.NewBufferString(`{"count": 2, "labels": {"project": "ci"}}`)
+		req := httptest.NewRequest(http.MethodPost, "/register", body)
+		w := httptest.NewRecorder()
+
+		handler.HandleRegister(w, req)
+
+		var response struct {
+			Hooks []storage.Hook `json:"hooks"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+
+		if len(response.Hooks) != 2 {
+			t.Fatalf("expected 2 hooks, got %d", len(response.Hooks))
+		}
+
+		for _, hook := range response.Hooks {
+			if hook.Labels["project"] != "ci" {
+				t.Errorf("expected label on hook %s, got %v", hook.ID, hook.Labels)
+			}
+		}
+	})
+
+	t.Run("renew uses the hook ttl", func(t *testing.T) {
+		hook, _ := manager.CreateHookWithOptions("example.com", storage.HookOptions{TTL: time.Hour})
+
+		req := httptest.NewRequest(http.MethodPost, "/hooks/"+hook.ID+"/renew", nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleHook(w, req)
+
+		stored, _ := manager.GetHook(hook.ID)
+		if stored.ExpiresAt.After(time.Now().Add(time.Hour)) {
+			t.Errorf("expected renewal by the hook ttl, got expiry %v", stored.ExpiresAt)
+		}
+	})
+
+	invalidTests := []struct {
+		name string
+		body string
+	}{
+		{"invalid ttl", `{"ttl": "soon"}`},
+		{"sub-second ttl", `{"ttl": "10ms"}`},
+		{"empty label key", `{"labels": {"": "x"}}`},
+		{"label too long", `{"labels": {"k": "` + strings.Repeat("a", maxHookLabelLength+1) + `"}}`},
+		{"description too long", `{"description": "` + strings.Repeat("a", maxHookDescriptionLen+1) + `"}`},
+	}
+
+	for _, tt := range invalidTests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/register", bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+
+			handler.HandleRegister(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected status 400, got %d", w.Code)
+			}
+		})
+	}
+}
+
+func TestAPIHandler_HandleRegister_IDs(t *testing.T) {
+	handler, manager := newHooksTestHandler()
+	handler.reservedID = func(id string) bool { return id == "ns1" || id == "www" }
+
+	t.Run("requested ID", func(t *testing.T) {
+		body := bytes.NewBufferString(`{"id": "ACME-login-01"}`)
+		req := httptest.NewRequest(http.MethodPost, "/register", body)
+		w := httptest.NewRecorder()
+
+		handler.HandleRegister(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+
+		var response storage.Hook
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+
+		if response.ID != "acme-login-01" {
+			t.Errorf("expected lowercase requested ID, got %s", response.ID)
+		}
+	})
+
+	t.Run("requested ID already taken", func(t *testing.T) {
+		body := bytes.NewBufferString(`{"id": "acme-login-01"}`)
+		req := httptest.NewRequest(http.MethodPost, "/register", body)
+		w := httptest.NewRecorder()
+
+		handler.HandleRegister(w, req)
+
+		if w.Code != http.StatusConflict {
+			t.Errorf("expected status 409, got %d", w.Code)
+		}
+	})
+
+	t.Run("prefix", func(t *testing.T) {
+		body := bytes.NewBufferString(`{"count": 2, "prefix": "acme"}`)
+		req := httptest.NewRequest(http.MethodPost, "/register", body)
+		w := httptest.NewRecorder()
+
+		handler.HandleRegister(w, req)
+
+		var response struct {
+			Hooks []storage.Hook `json:"hooks"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+
+		if len(response.Hooks) != 2 {
+			t.Fatalf("expected 2 hooks, got %d", len(response.Hooks))
+		}
+
+		for _, hook := range response.Hooks {
+			if !strings.HasPrefix(hook.ID, "acme-") {
+				t.Errorf("expected prefixed ID, got %s", hook.ID)
+			}
+			if _, exists := manager.GetHook(hook.ID); !exists {
+				t.Errorf("expected hook %s to be stored", hook.ID)
+			}
+		}
+	})
+
+	invalidTests := []struct {
+		name string
+		body string
+	}{
+		{"ID with dot", `{"id": "a.b"}`},
+		{"ID with underscore", `{"id": "a_b"}`},
+		{"ID with leading hyphen", `{"id": "-abc"}`},
+		{"ID too long", `{"id": "` + strings.Repeat("a", 64) + `"}`},
+		{"ID with count", `{"id": "abc", "count": 2}`},
+		{"ID with prefix", `{"id": "abc", "prefix": "x"}`},
+		{"nameserver ID", `{"id": "NS1"}`},
+		{"static name ID", `{"id": "www"}`},
+		{"prefix with trailing hyphen", `{"prefix": "acme-"}`},
+		{"prefix too long", `{"prefix": "` + strings.Repeat("a", maxHookPrefixLength+1) + `"}`},
+	}
+
+	for _, tt := range invalidTests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/register", bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+
+			handler.HandleRegister(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected status 400, got %d", w.Code)
+			}
+		})
+	}
+}
+
+func TestIsDNSLabel(t *testing.T) {
+	tests := []struct {
+		label    string
+		expected bool
+	}{
+		{"abc123", true},
+		{"acme-login-01", true},
+		{"a", true},
+		{strings.Repeat("a", 63), true},
+		{"", false},
+		{strings.Repeat("a", 64), false},
+		{"-abc", false},
+		{"abc-", false},
+		{"ABC", false},
+		{"a.b", false},
+		{"a_b", false},
+	}
+
+	for _, tt := range tests {
+		if got := isDNSLabel(tt.label); got != tt.expected {
+			t.Errorf("isDNSLabel(%q) = %v, want %v", tt.label, got, tt.expected)
+		}
+	}
+}
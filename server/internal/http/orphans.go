@@ -0,0 +1,117 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+const (
+	// defaultOrphanLimit and maxOrphanLimit bound GET /orphans pages
+	defaultOrphanLimit = 100
+	maxOrphanLimit     = 1000
+)
+
+// claimOrphansRequest is the body of POST /orphans/claim
+type claimOrphansRequest struct {
+	HookID string   `json:"hook_id"`       // hook receiving the orphans
+	IDs    []string `json:"ids,omitempty"` // orphan interaction IDs, all orphans that tried hook_id if empty
+}
+
+// HandleOrphans handles GET /orphans?hook_id=&limit= and the poll filters.
+// Orphans are ordered newest first.
+func (h *APIHandler) HandleOrphans(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondJSON(w, http.StatusMethodNotAllowed, map[string]string{
+			"error": "Method not allowed",
+		})
+		return
+	}
+
+	orphans := h.storage.Orphans()
+	if orphans == nil {
+		respondJSON(w, http.StatusNotFound, map[string]string{
+			"error": "Orphans not enabled",
+		})
+		return
+	}
+
+	query := r.URL.Query()
+
+	filter, err := parseFilter(query)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	limit, err := parsePageParam(query.Get("limit"), defaultOrphanLimit)
+	if err != nil || limit == 0 {
+		respondJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Invalid limit",
+		})
+		return
+	}
+	limit = min(limit, maxOrphanLimit)
+
+	hookIDs := splitList(query["hook_id"])
+	for i, id := range hookIDs {
+		hookIDs[i] = strings.ToLower(id)
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"orphans": orphans.Search(hookIDs, filter, limit),
+		"total":   orphans.Len(),
+	})
+}
+
+// HandleClaimOrphans handles POST /orphans/claim, moving orphans into a
+// registered hook, typically one re-registered with the ID they tried
+func (h *APIHandler) HandleClaimOrphans(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondJSON(w, http.StatusMethodNotAllowed, map[string]string{
+			"error": "Method not allowed",
+		})
+		return
+	}
+
+	orphans := h.storage.Orphans()
+	if orphans == nil {
+		respondJSON(w, http.StatusNotFound, map[string]string{
+			"error": "Orphans not enabled",
+		})
+		return
+	}
+
+	var req claimOrphansRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.HookID == "" {
+		respondJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body: hook_id is required",
+		})
+		return
+	}
+
+	if _, exists := h.storage.GetHook(req.HookID); !exists {
+		respondJSON(w, http.StatusNotFound, map[string]string{
+			"error": "Hook not found",
+		})
+		return
+	}
+
+	claimed := orphans.Claim(req.HookID, req.IDs)
+	for _, orphan := range claimed {
+		// Sequence numbers are assigned anew, timestamps are kept. A copy is
+		// stored, concurrent searches may still hold the orphan
+		interaction := *orphan.Interaction
+		if err := h.storage.AddInteraction(req.HookID, &interaction); err != nil {
+			h.logger.Error("failed to store claimed orphan", "error", err, "hook_id", req.HookID)
+		}
+	}
+
+	h.logger.Info("orphans claimed", "hook_id", req.HookID, "count", len(claimed), "client", r.RemoteAddr)
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"hook_id": req.HookID,
+		"claimed": len(claimed),
+	})
+}
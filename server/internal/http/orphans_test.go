@@ -0,0 +1,106 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jomar/hookd/internal/storage"
+)
+
+func TestAPIHandler_HandleOrphans(t *testing.T) {
+	manager := storage.NewMemoryManager(func() string { return "unused" })
+	manager.SetOrphans(storage.NewOrphanStore(time.Hour, 100, 1<<20))
+	handler := NewAPIHandler(manager, nil, "example.com", slog.Default(), func() string { return "int-id" })
+
+	manager.AddInteraction("expired1", storage.DNSInteraction("int1", "1.2.3.4", "expired1.example.com", "A"))
+	manager.AddInteraction("expired1", storage.HTTPInteraction("int2", "1.2.3.4", "GET", "/", nil, ""))
+	manager.AddInteraction("typo", storage.DNSInteraction("int3", "5.6.7.8", "typo.example.com", "A"))
+
+	t.Run("search", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/orphans?hook_id=EXPIRED1&type=dns", nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleOrphans(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+
+		var response struct {
+			Orphans []storage.Orphan `json:"orphans"`
+			Total   int              `json:"total"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+
+		if len(response.Orphans) != 1 || response.Orphans[0].HookID != "expired1" || response.Orphans[0].ID != "int1" {
+			t.Errorf("expected the dns orphan of expired1, got %+v", response.Orphans)
+		}
+		if response.Total != 3 {
+			t.Errorf("expected total 3, got %d", response.Total)
+		}
+	})
+
+	t.Run("invalid filter", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/orphans?type=smtp", nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleOrphans(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("claim into unregistered hook", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/orphans/claim", bytes.NewBufferString(`{"hook_id": "expired1"}`))
+		w := httptest.NewRecorder()
+
+		handler.HandleClaimOrphans(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", w.Code)
+		}
+	})
+
+	t.Run("claim", func(t *testing.T) {
+		hook, _ := manager.CreateHookWithOptions("example.com", storage.HookOptions{ID: "expired1"})
+
+		req := httptest.NewRequest(http.MethodPost, "/orphans/claim", bytes.NewBufferString(`{"hook_id": "expired1"}`))
+		w := httptest.NewRecorder()
+
+		handler.HandleClaimOrphans(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+
+		interactions, _ := manager.PollInteractions(hook.ID)
+		if len(interactions) != 2 || interactions[0].ID != "int1" || interactions[1].ID != "int2" {
+			t.Errorf("expected claimed orphans in the hook, got %v", interactions)
+		}
+
+		if manager.Orphans().Len() != 1 {
+			t.Errorf("expected 1 orphan left, got %d", manager.Orphans().Len())
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		handler, _ := newHooksTestHandler()
+
+		req := httptest.NewRequest(http.MethodGet, "/orphans", nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleOrphans(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", w.Code)
+		}
+	})
+}
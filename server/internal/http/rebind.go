@@ -0,0 +1,133 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/jomar/hookd/internal/storage"
+)
+
+const (
+	// maxRebindAnswers caps the answers a rebinding hook cycles through
+	maxRebindAnswers = 16
+
+	// maxRebindTTL caps the TTL of rebinding answers, which must expire
+	// quickly for resolvers to ask again
+	maxRebindTTL = 60
+)
+
+// handleHookRebind handles the DNS rebinding strategy of a hook:
+// GET /hooks/:id/rebind, PUT /hooks/:id/rebind and DELETE /hooks/:id/rebind
+func (h *APIHandler) handleHookRebind(w http.ResponseWriter, r *http.Request, hookID string) {
+	var rebind *storage.RebindConfig
+
+	switch r.Method {
+	case http.MethodGet:
+		hook, exists := h.storage.GetHook(hookID)
+		if !exists {
+			respondJSON(w, http.StatusNotFound, map[string]string{
+				"error": "Hook not found",
+			})
+			return
+		}
+
+		respondJSON(w, http.StatusOK, map[string]interface{}{
+			"rebind": hook.Rebind,
+		})
+		return
+
+	case http.MethodPut:
+		var req storage.RebindConfig
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondJSON(w, http.StatusBadRequest, map[string]string{
+				"error": "Invalid request body",
+			})
+			return
+		}
+
+		if err := normalizeRebind(&req); err != nil {
+			respondJSON(w, http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+		rebind = &req
+
+	case http.MethodDelete:
+		// Clearing the strategy restores the regular answers
+
+	default:
+		respondJSON(w, http.StatusMethodNotAllowed, map[string]string{
+			"error": "Method not allowed",
+		})
+		return
+	}
+
+	hook, exists := h.storage.SetRebind(hookID, rebind)
+	if !exists {
+		respondJSON(w, http.StatusNotFound, map[string]string{
+			"error": "Hook not found",
+		})
+		return
+	}
+
+	h.logger.Info("hook rebinding updated", "id", hookID, "enabled", hook.Rebind != nil, "client", r.RemoteAddr)
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"rebind": hook.Rebind,
+	})
+}
+
+// normalizeRebind validates a rebinding strategy, fills in defaults and drops
+// the settings that do not apply to its mode
+func normalizeRebind(rebind *storage.RebindConfig) error {
+	rebind.Mode = strings.ToLower(rebind.Mode)
+
+	switch rebind.Mode {
+	case storage.RebindModeCount:
+		if rebind.Count == 0 {
+			rebind.Count = 1
+		}
+		if rebind.Count < 1 {
+			return fmt.Errorf("Invalid count: must be at least 1")
+		}
+		rebind.Interval = 0
+
+	case storage.RebindModeTime:
+		if rebind.Interval < 1 {
+			return fmt.Errorf("Invalid interval: must be at least 1 second")
+		}
+		rebind.Count = 0
+
+	case storage.RebindModeRoundRobin:
+		rebind.Count, rebind.Interval = 0, 0
+
+	default:
+		return fmt.Errorf("Invalid mode: must be count, time or round_robin")
+	}
+
+	if len(rebind.Answers) == 0 || len(rebind.Answers) > maxRebindAnswers {
+		return fmt.Errorf("Invalid answers: between 1 and %d required", maxRebindAnswers)
+	}
+
+	for i, answer := range rebind.Answers {
+		if strings.EqualFold(answer, "self") {
+			rebind.Answers[i] = "self"
+			continue
+		}
+
+		ip := net.ParseIP(answer)
+		if ip == nil {
+			return fmt.Errorf("Invalid answer %d: must be an IP address or \"self\"", i)
+		}
+		rebind.Answers[i] = ip.String()
+	}
+
+	if rebind.TTL < 0 || rebind.TTL > maxRebindTTL {
+		return fmt.Errorf("Invalid ttl: must be between 0 and %d", maxRebindTTL)
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jomar/hookd/internal/storage"
+)
+
+func TestAPIHandler_HandleHookRebind(t *testing.T) {
+	handler, manager := newHooksTestHandler()
+	hook := manager.CreateHook("example.com")
+
+	t.Run("put", func(t *testing.T) {
+		body := bytes.NewBufferString(`{"mode": "count", "answers": ["SELF", "169.254.169.254"], "interval": 5, "ttl": 1}`)
+		req := httptest.NewRequest(http.MethodPut, "/hooks/"+hook.ID+"/rebind", body)
+		w := httptest.NewRecorder()
+
+		handler.HandleHook(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+
+		var response struct {
+			Rebind *storage.RebindConfig `json:"rebind"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+
+		rebind := response.Rebind
+		if rebind == nil || rebind.Count != 1 || rebind.Interval != 0 || rebind.TTL != 1 {
+			t.Fatalf("expected normalized count strategy, got %+v", rebind)
+		}
+		if rebind.Answers[0] != "self" || rebind.Answers[1] != "169.254.169.254" {
+			t.Errorf("unexpected answers: %v", rebind.Answers)
+		}
+
+		stored, _ := manager.GetHook(hook.ID)
+		if stored.Rebind == nil || stored.Rebind.Mode != storage.RebindModeCount {
+			t.Errorf("expected strategy to be stored, got %+v", stored.Rebind)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodDelete, "/hooks/"+hook.ID+"/rebind", nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleHook(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+
+		stored, _ := manager.GetHook(hook.ID)
+		if stored.Rebind != nil {
+			t.Errorf("expected strategy to be cleared, got %+v", stored.Rebind)
+		}
+	})
+
+	t.Run("unknown hook", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/hooks/nonexistent/rebind", nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleHook(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", w.Code)
+		}
+	})
+}
+
+func TestNormalizeRebind_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		rebind storage.RebindConfig
+	}{
+		{"unknown mode", storage.RebindConfig{Mode: "random", Answers: []string{"self"}}},
+		{"no answers", storage.RebindConfig{Mode: "round_robin"}},
+		{"invalid answer", storage.RebindConfig{Mode: "round_robin", Answers: []string{"localhost"}}},
+		{"negative count", storage.RebindConfig{Mode: "count", Answers: []string{"self"}, Count: -1}},
+		{"time without interval", storage.RebindConfig{Mode: "time", Answers: []string{"self"}}},
+		{"ttl too long", storage.RebindConfig{Mode: "round_robin", Answers: []string{"self"}, TTL: 300}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := normalizeRebind(&tt.rebind); err == nil {
+				t.Errorf("expected %+v to be rejected", tt.rebind)
+			}
+		})
+	}
+}
@@ -0,0 +1,244 @@
+package http
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jomar/hookd/internal/storage"
+)
+
+const (
+	// maxHookResponses caps the HTTP responses of a single hook
+	maxHookResponses = 16
+
+	// maxResponseBody caps the decoded body of a response
+	maxResponseBody = 256 * 1024
+
+	// maxResponseDelay caps the artificial delay of a response (one minute)
+	maxResponseDelay = 60000
+)
+
+// forbiddenResponseHeaders are managed by the server and cannot be configured
+var forbiddenResponseHeaders = map[string]bool{
+	"Connection":        true,
+	"Content-Length":    true,
+	"Transfer-Encoding": true,
+}
+
+// httpResponsesRequest is the body of PUT /hooks/:id/response
+type httpResponsesRequest struct {
+	Responses []storage.HTTPResponse `json:"responses"`
+}
+
+// handleHookResponse handles the HTTP responses of a hook:
+// GET /hooks/:id/response, PUT /hooks/:id/response (replace all) and
+// DELETE /hooks/:id/response
+func (h *APIHandler) handleHookResponse(w http.ResponseWriter, r *http.Request, hookID string) {
+	var responses []storage.HTTPResponse
+
+	switch r.Method {
+	case http.MethodGet:
+		hook, exists := h.storage.GetHook(hookID)
+		if !exists {
+			respondJSON(w, http.StatusNotFound, map[string]string{
+				"error": "Hook not found",
+			})
+			return
+		}
+
+		respondHTTPResponses(w, hook.HTTPResponses)
+		return
+
+	case http.MethodPut:
+		var req httpResponsesRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondJSON(w, http.StatusBadRequest, map[string]string{
+				"error": "Invalid request body",
+			})
+			return
+		}
+
+		var err error
+		responses, err = normalizeHTTPResponses(req.Responses)
+		if err != nil {
+			respondJSON(w, http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
+	case http.MethodDelete:
+		// Clearing the responses restores the empty 200
+
+	default:
+		respondJSON(w, http.StatusMethodNotAllowed, map[string]string{
+			"error": "Method not allowed",
+		})
+		return
+	}
+
+	hook, exists := h.storage.SetHTTPResponses(hookID, responses)
+	if !exists {
+		respondJSON(w, http.StatusNotFound, map[string]string{
+			"error": "Hook not found",
+		})
+		return
+	}
+
+	h.logger.Info("hook http responses updated", "id", hookID, "responses", len(hook.HTTPResponses), "client", r.RemoteAddr)
+	respondHTTPResponses(w, hook.HTTPResponses)
+}
+
+// respondHTTPResponses writes the HTTP responses of a hook
+func respondHTTPResponses(w http.ResponseWriter, responses []storage.HTTPResponse) {
+	if responses == nil {
+		responses = []storage.HTTPResponse{}
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"responses": responses,
+	})
+}
+
+// normalizeHTTPResponses validates HTTP responses and converts them to their
+// canonical form: uppercase methods, explicit status and canonical header names
+func normalizeHTTPResponses(responses []storage.HTTPResponse) ([]storage.HTTPResponse, error) {
+	if len(responses) > maxHookResponses {
+		return nil, fmt.Errorf("Too many responses (max %d)", maxHookResponses)
+	}
+
+	normalized := make([]storage.HTTPResponse, 0, len(responses))
+	for i, resp := range responses {
+		resp.Method = strings.ToUpper(resp.Method)
+		if resp.Method != "" && !isToken(resp.Method) {
+			return nil, fmt.Errorf("Invalid response %d: method must be an HTTP method such as GET", i)
+		}
+
+		if resp.Path != "" && !strings.HasPrefix(resp.Path, "/") {
+			return nil, fmt.Errorf("Invalid response %d: path must start with /", i)
+		}
+
+		if resp.Status == 0 {
+			resp.Status = http.StatusOK
+		}
+		if resp.Status < 200 || resp.Status > 599 {
+			return nil, fmt.Errorf("Invalid response %d: status must be between 200 and 599", i)
+		}
+
+		headers := make(map[string]string, len(resp.Headers))
+		for name, value := range resp.Headers {
+			if !isToken(name) || strings.ContainsAny(value, "\r\n") {
+				return nil, fmt.Errorf("Invalid response %d: invalid header %q", i, name)
+			}
+			name = http.CanonicalHeaderKey(name)
+			if forbiddenResponseHeaders[name] {
+				return nil, fmt.Errorf("Invalid response %d: header %s is set by the server", i, name)
+			}
+			headers[name] = value
+		}
+		resp.Headers = nil
+		if len(headers) > 0 {
+			resp.Headers = headers
+		}
+
+		if strings.ContainsAny(resp.ContentType, "\r\n") {
+			return nil, fmt.Errorf("Invalid response %d: invalid content_type", i)
+		}
+
+		size := len(resp.Body)
+		if resp.BodyBase64 != "" {
+			if resp.Body != "" {
+				return nil, fmt.Errorf("Invalid response %d: body and body_base64 are exclusive", i)
+			}
+			body, err := base64.StdEncoding.DecodeString(resp.BodyBase64)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid response %d: body_base64 must be standard base64", i)
+			}
+			size = len(body)
+		}
+		if size > maxResponseBody {
+			return nil, fmt.Errorf("Invalid response %d: body must not exceed %d bytes", i, maxResponseBody)
+		}
+
+		if resp.DelayMS < 0 || resp.DelayMS > maxResponseDelay {
+			return nil, fmt.Errorf("Invalid response %d: delay_ms must be between 0 and %d", i, maxResponseDelay)
+		}
+
+		normalized = append(normalized, resp)
+	}
+
+	return normalized, nil
+}
+
+// isToken reports whether s is an HTTP token (RFC 9110), as used by header
+// names and methods
+func isToken(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c > 0x7e || c <= ' ' || strings.ContainsRune(`"(),/:;<=>?@[\]{}`, c) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchResponse returns the first response matching a request, or nil
+func matchResponse(responses []storage.HTTPResponse, method, path string) *storage.HTTPResponse {
+	for i := range responses {
+		resp := &responses[i]
+		if resp.Method != "" && resp.Method != method {
+			continue
+		}
+
+		prefix, wildcard := strings.CutSuffix(resp.Path, "*")
+		switch {
+		case resp.Path == "":
+		case wildcard && strings.HasPrefix(path, prefix):
+		case resp.Path == path:
+		default:
+			continue
+		}
+
+		return resp
+	}
+	return nil
+}
+
+// writeHookResponse serves a configured response after its delay, unless the
+// client goes away first
+func writeHookResponse(w http.ResponseWriter, r *http.Request, resp *storage.HTTPResponse) {
+	if resp.DelayMS > 0 {
+		timer := time.NewTimer(time.Duration(resp.DelayMS) * time.Millisecond)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	body := []byte(resp.Body)
+	if resp.BodyBase64 != "" {
+		// Validated by the API
+		body, _ = base64.StdEncoding.DecodeString(resp.BodyBase64)
+	}
+
+	for name, value := range resp.Headers {
+		w.Header().Set(name, value)
+	}
+	if resp.ContentType != "" {
+		w.Header().Set("Content-Type", resp.ContentType)
+	}
+
+	w.WriteHeader(resp.Status)
+	if r.Method != http.MethodHead {
+		w.Write(body)
+	}
+}
@@ -0,0 +1,237 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jomar/hookd/internal/storage"
+)
+
+func TestAPIHandler_HandleHookResponse(t *testing.T) {
+	handler, manager := newHooksTestHandler()
+	hook := manager.CreateHook("example.com")
+
+	t.Run("put", func(t *testing.T) {
+		body := bytes.NewBufferString(`{"responses": [
+			{"method": "get", "path": "/login", "status": 401, "headers": {"www-authenticate": "Basic realm=\"hookd\""}},
+			{"content_type": "application/json", "body": "{\"ok\": true}"}
+		]}`)
+		req := httptest.NewRequest(http.MethodPut, "/hooks/"+hook.ID+"/response", body)
+		w := httptest.NewRecorder()
+
+		handler.HandleHook(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+
+		var response struct {
+			Responses []storage.HTTPResponse `json:"responses"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+
+		if len(response.Responses) != 2 {
+			t.Fatalf("expected 2 responses, got %d", len(response.Responses))
+		}
+		first, second := response.Responses[0], response.Responses[1]
+		if first.Method != "GET" || first.Headers["Www-Authenticate"] != `Basic realm="hookd"` {
+			t.Errorf("expected normalized method and header names, got %+v", first)
+		}
+		if second.Status != http.StatusOK {
+			t.Errorf("expected default status 200, got %d", second.Status)
+		}
+
+		stored, _ := manager.GetHook(hook.ID)
+		if len(stored.HTTPResponses) != 2 {
+			t.Errorf("expected responses to be stored, got %+v", stored.HTTPResponses)
+		}
+	})
+
+	t.Run("get", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/hooks/"+hook.ID+"/response", nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleHook(w, req)
+
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "/login") {
+			t.Errorf("expected the stored responses, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodDelete, "/hooks/"+hook.ID+"/response", nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleHook(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+		if !strings.Contains(w.Body.String(), `"responses":[]`) {
+			t.Errorf("expected an empty list, got %s", w.Body.String())
+		}
+
+		stored, _ := manager.GetHook(hook.ID)
+		if stored.HTTPResponses != nil {
+			t.Errorf("expected responses to be cleared, got %+v", stored.HTTPResponses)
+		}
+	})
+
+	t.Run("unknown hook", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPut, "/hooks/missing/response", bytes.NewBufferString(`{"responses": []}`))
+		w := httptest.NewRecorder()
+
+		handler.HandleHook(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", w.Code)
+		}
+	})
+
+	invalid := map[string]string{
+		"status":            `{"status": 99}`,
+		"path":              `{"path": "login"}`,
+		"method":            `{"method": "GE T"}`,
+		"header name":       `{"headers": {"X Bad": "1"}}`,
+		"header value":      `{"headers": {"X-Test": "a\r\nb"}}`,
+		"forbidden header":  `{"headers": {"content-length": "1"}}`,
+		"exclusive bodies":  `{"body": "a", "body_base64": "YQ=="}`,
+		"invalid base64":    `{"body_base64": "!!"}`,
+		"delay":             `{"delay_ms": 600000}`,
+		"body too large":    `{"body": "` + strings.Repeat("a", maxResponseBody+1) + `"}`,
+		"content type CRLF": `{"content_type": "text/html\r\nX-Injected: 1"}`,
+	}
+	for name, resp := range invalid {
+		t.Run("invalid "+name, func(t *testing.T) {
+			body := bytes.NewBufferString(`{"responses": [` + resp + `]}`)
+			req := httptest.NewRequest(http.MethodPut, "/hooks/"+hook.ID+"/response", body)
+			w := httptest.NewRecorder()
+
+			handler.HandleHook(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected status 400, got %d: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestMatchResponse(t *testing.T) {
+	responses := []storage.HTTPResponse{
+		{Method: "POST", Path: "/api/*", Status: 201},
+		{Path: "/redirect", Status: 302},
+		{Status: 200},
+	}
+
+	tests := []struct {
+		method, path string
+		want         int
+	}{
+		{"POST", "/api/users", 201},
+		{"GET", "/api/users", 200},
+		{"GET", "/redirect", 302},
+		{"GET", "/redirect/more", 200},
+		{"PUT", "/", 200},
+	}
+	for _, tt := range tests {
+		if got := matchResponse(responses, tt.method, tt.path); got == nil || got.Status != tt.want {
+			t.Errorf("%s %s: expected status %d, got %+v", tt.method, tt.path, tt.want, got)
+		}
+	}
+
+	if got := matchResponse(responses[:2], "GET", "/other"); got != nil {
+		t.Errorf("expected no match, got %+v", got)
+	}
+}
+
+func TestCaptureHandler_HookResponse(t *testing.T) {
+	manager := storage.NewMemoryManager(func() string { return "abc123" })
+	handler := NewCaptureHandler(manager, "example.com", slog.Default(), func() string { return "int-id" })
+	hook := manager.CreateHook("example.com")
+
+	manager.SetHTTPResponses(hook.ID, []storage.HTTPResponse{
+		{Path: "/redirect", Status: http.StatusFound, Headers: map[string]string{"Location": "http://169.254.169.254/"}},
+		{Path: "/binary", Status: http.StatusOK, ContentType: "application/octet-stream", BodyBase64: "AAEC"},
+		{Path: "/slow", Status: http.StatusOK, DelayMS: 100},
+		{Method: "GET", Status: http.StatusOK, ContentType: "application/json", Body: `{"ok":true}`},
+	})
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Host = hook.ID + ".example.com"
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("redirect", func(t *testing.T) {
+		w := serve(http.MethodGet, "/redirect")
+		if w.Code != http.StatusFound || w.Header().Get("Location") != "http://169.254.169.254/" {
+			t.Errorf("expected a redirect, got %d %v", w.Code, w.Header())
+		}
+	})
+
+	t.Run("base64 body", func(t *testing.T) {
+		w := serve(http.MethodGet, "/binary")
+		if !bytes.Equal(w.Body.Bytes(), []byte{0, 1, 2}) {
+			t.Errorf("expected the decoded body, got %v", w.Body.Bytes())
+		}
+	})
+
+	t.Run("json body", func(t *testing.T) {
+		w := serve(http.MethodGet, "/anything")
+		if w.Header().Get("Content-Type") != "application/json" || w.Body.String() != `{"ok":true}` {
+			t.Errorf("expected the JSON document, got %s %q", w.Header().Get("Content-Type"), w.Body.String())
+		}
+	})
+
+	t.Run("head omits the body", func(t *testing.T) {
+		w := serve(http.MethodHead, "/binary")
+		if w.Code != http.StatusOK || w.Body.Len() != 0 {
+			t.Errorf("expected no body, got %d %q", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		w := serve(http.MethodPost, "/anything")
+		if w.Code != http.StatusOK || w.Body.Len() != 0 {
+			t.Errorf("expected an empty 200, got %d %q", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("delay", func(t *testing.T) {
+		start := time.Now()
+		serve(http.MethodGet, "/slow")
+		if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+			t.Errorf("expected a delay of 100ms, responded after %v", elapsed)
+		}
+	})
+
+	t.Run("delay cancelled by the client", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		req := httptest.NewRequest(http.MethodGet, "/slow", nil).WithContext(ctx)
+		req.Host = hook.ID + ".example.com"
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Body.Len() != 0 || len(w.Header()) != 0 {
+			t.Errorf("expected nothing written, got %v %q", w.Header(), w.Body.String())
+		}
+	})
+
+	interactions, _ := manager.PollInteractions(hook.ID)
+	if len(interactions) != 7 {
+		t.Errorf("expected every request to be recorded, got %d", len(interactions))
+	}
+}
@@ -2,16 +2,19 @@ package http
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"log"
 	"log/slog"
 	"net"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/caddyserver/certmagic"
 	"github.com/jomar/hookd/internal/acme"
 	"github.com/jomar/hookd/internal/config"
+	"github.com/jomar/hookd/internal/dns"
 	"github.com/jomar/hookd/internal/eviction"
 	"github.com/jomar/hookd/internal/storage"
 )
@@ -24,8 +27,13 @@ type Server struct {
 	acmeProvider *acme.Provider
 	logger       *slog.Logger
 	idGenerator  func() string
+	dohHandler   http.Handler
+	dnsMetrics   func() dns.RateLimitMetrics
+	reservedID   func(id string) bool
 	httpServer   *http.Server
 	httpsServer  *http.Server
+	tlsConfig    *tls.Config   // set once the wildcard certificate is obtained
+	tlsReady     chan struct{} // closed when tlsConfig is set
 }
 
 // NewServer creates a new HTTP/HTTPS server
@@ -37,13 +45,46 @@ func NewServer(cfg config.ServerConfig, storage storage.Manager, evictor *evicti
 		acmeProvider: acmeProvider,
 		logger:       logger,
 		idGenerator:  idGenerator,
+		tlsReady:     make(chan struct{}),
 	}
 }
 
+// WaitTLSConfig blocks until the wildcard certificate is obtained and returns
+// the TLS config serving it, or nil if ctx ends first. Other listeners such
+// as DNS-over-TLS use it to share the certificate
+func (s *Server) WaitTLSConfig(ctx context.Context) *tls.Config {
+	select {
+	case <-s.tlsReady:
+		return s.tlsConfig
+	case <-ctx.Done():
+		return nil
+	}
+}
+
+// SetDoHHandler serves DNS-over-HTTPS queries at /dns-query on the HTTPS
+// listener, it must be called before Start
+func (s *Server) SetDoHHandler(handler http.HandlerFunc) {
+	s.dohHandler = handler
+}
+
+// SetDNSMetrics adds the DNS rate limiting counters to /metrics, it must be
+// called before Start
+func (s *Server) SetDNSMetrics(source func() dns.RateLimitMetrics) {
+	s.dnsMetrics = source
+}
+
+// SetReservedIDs refuses requested hook IDs the DNS server answers for
+// itself, it must be called before Start
+func (s *Server) SetReservedIDs(reserved func(id string) bool) {
+	s.reservedID = reserved
+}
+
 // Start starts the HTTP/HTTPS servers
 func (s *Server) Start(ctx context.Context) error {
 	// Create handlers
 	apiHandler := NewAPIHandler(s.storage, s.evictor, s.config.Domain, s.logger, s.idGenerator)
+	apiHandler.dnsMetrics = s.dnsMetrics
+	apiHandler.reservedID = s.reservedID
 	captureHandler := NewCaptureHandler(s.storage, s.config.Domain, s.logger, s.idGenerator)
 
 	// Create main mux
@@ -54,16 +95,32 @@ func (s *Server) Start(ctx context.Context) error {
 	mux.Handle("/register", authMW(http.HandlerFunc(apiHandler.HandleRegister)))
 	mux.Handle("/poll", authMW(http.HandlerFunc(apiHandler.HandlePollBatch)))
 	mux.Handle("/poll/", authMW(http.HandlerFunc(apiHandler.HandlePoll)))
+	mux.Handle("/stream", authMW(http.HandlerFunc(apiHandler.HandleStream)))
+	mux.Handle("/hooks", authMW(http.HandlerFunc(apiHandler.HandleListHooks)))
+	mux.Handle("/hooks/", authMW(http.HandlerFunc(apiHandler.HandleHook)))
+
+	// Admin endpoints, only reachable with the admin token
+	adminMW := AuthMiddleware(s.config.API.AdminToken, s.logger)
+	mux.Handle("/orphans", adminMW(http.HandlerFunc(apiHandler.HandleOrphans)))
+	mux.Handle("/orphans/claim", adminMW(http.HandlerFunc(apiHandler.HandleClaimOrphans)))
 
 	// Metrics endpoint (no auth)
 	mux.HandleFunc("/metrics", apiHandler.HandleMetrics)
 
+	// DNS-over-HTTPS (no auth, HTTPS on the domain itself only)
+	if s.dohHandler != nil {
+		mux.Handle("/dns-query", dohOnly(s.config.Domain, s.dohHandler, captureHandler))
+	}
+
 	// Wildcard capture (everything else)
 	mux.Handle("/", captureHandler)
 
 	// Apply global middleware
 	handler := RecoveryMiddleware(s.logger)(LoggingMiddleware(s.logger)(mux))
 
+	// Derive request contexts from ctx so long-polling requests are released on shutdown
+	baseContext := func(net.Listener) context.Context { return ctx }
+
 	errChan := make(chan error, 2)
 
 	// Start HTTPS server if enabled
@@ -146,16 +203,20 @@ func (s *Server) Start(ctx context.Context) error {
 			// Get TLS config from CertMagic
 			tlsConfig := certmagicConfig.TLSConfig()
 			tlsConfig.NextProtos = append([]string{"h2", "http/1.1"}, tlsConfig.NextProtos...)
+			s.tlsConfig = tlsConfig
+			close(s.tlsReady)
 
 			s.httpsServer = &http.Server{
-				Addr:      fmt.Sprintf(":%d", s.config.HTTPS.Port),
-				Handler:   handler,
-				TLSConfig: tlsConfig,
-				ErrorLog:  newSuppressedTLSLogger(s.logger),
+				Addr:        net.JoinHostPort(s.config.HTTPS.Bind, strconv.Itoa(s.config.HTTPS.Port)),
+				Handler:     handler,
+				TLSConfig:   tlsConfig,
+				ErrorLog:    newSuppressedTLSLogger(s.logger),
+				BaseContext: baseContext,
 			}
 
 			go func() {
 				s.logger.Info("https server starting (certmagic wildcard)",
+					"bind", s.config.HTTPS.Bind,
 					"port", s.config.HTTPS.Port,
 					"domains", domains)
 
@@ -171,13 +232,14 @@ func (s *Server) Start(ctx context.Context) error {
 	// Always start HTTP server on configured port
 	if s.httpServer == nil {
 		s.httpServer = &http.Server{
-			Addr:     fmt.Sprintf(":%d", s.config.HTTP.Port),
-			Handler:  handler,
-			ErrorLog: newSuppressedTLSLogger(s.logger),
+			Addr:        net.JoinHostPort(s.config.HTTP.Bind, strconv.Itoa(s.config.HTTP.Port)),
+			Handler:     handler,
+			ErrorLog:    newSuppressedTLSLogger(s.logger),
+			BaseContext: baseContext,
 		}
 
 		go func() {
-			s.logger.Info("http server starting", "port", s.config.HTTP.Port)
+			s.logger.Info("http server starting", "bind", s.config.HTTP.Bind, "port", s.config.HTTP.Port)
 			if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 				errChan <- fmt.Errorf("http server error: %w", err)
 			}
@@ -204,6 +266,24 @@ func (s *Server) Start(ctx context.Context) error {
 	}
 }
 
+// dohOnly routes TLS requests for the domain itself to handler, and plain
+// HTTP or hook host requests to fallback, so a callback to
+// https://<hook>.<domain>/dns-query is captured like any other
+func dohOnly(domain string, handler, fallback http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+
+		if r.TLS == nil || !strings.EqualFold(strings.TrimSuffix(host, "."), domain) {
+			fallback.ServeHTTP(w, r)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
 // suppressedTLSWriter wraps a logger to filter out TLS handshake errors
 type suppressedTLSWriter struct {
 	logger *slog.Logger
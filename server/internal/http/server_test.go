@@ -2,9 +2,12 @@ package http
 
 import (
 	"context"
+	"crypto/tls"
+	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -267,7 +270,11 @@ func TestServer_StartHTTPSManualDisabled(t *testing.T) {
 }
 
 func TestServer_MiddlewareChain(t *testing.T) {
-	idGen := func() string { return "test-id" }
+	idCounter := 0
+	idGen := func() string {
+		idCounter++
+		return fmt.Sprintf("test-id-%d", idCounter)
+	}
 	manager := storage.NewMemoryManager(idGen)
 	evictorCfg := config.EvictionConfig{
 		CleanupInterval: 60,
@@ -397,3 +404,60 @@ func TestServer_ContextCancellation(t *testing.T) {
 		t.Fatal("server did not stop after context cancellation")
 	}
 }
+
+func TestDoHOnly(t *testing.T) {
+	handler := dohOnly("example.com",
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusTeapot) }),
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }),
+	)
+
+	tests := []struct {
+		name string
+		host string
+		tls  bool
+		want int
+	}{
+		{"plain http falls back", "example.com", false, http.StatusOK},
+		{"tls to the domain", "example.com", true, http.StatusTeapot},
+		{"tls to the domain with port", "EXAMPLE.com:443", true, http.StatusTeapot},
+		{"tls to a hook host falls back", "abc123.example.com", true, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/dns-query", nil)
+			r.Host = tt.host
+			if tt.tls {
+				r.TLS = &tls.ConnectionState{}
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Errorf("expected status %d, got %d", tt.want, w.Code)
+			}
+		})
+	}
+}
+
+func TestServer_WaitTLSConfig(t *testing.T) {
+	server := NewServer(config.ServerConfig{Domain: "example.com"}, storage.NewMemoryManager(func() string { return "id" }), nil, nil, slog.Default(), nil)
+
+	t.Run("context ends first", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		if cfg := server.WaitTLSConfig(ctx); cfg != nil {
+			t.Errorf("expected nil config, got %v", cfg)
+		}
+	})
+
+	t.Run("certificate obtained", func(t *testing.T) {
+		server.tlsConfig = &tls.Config{}
+		close(server.tlsReady)
+
+		if cfg := server.WaitTLSConfig(context.Background()); cfg != server.tlsConfig {
+			t.Errorf("expected the published config, got %v", cfg)
+		}
+	})
+}
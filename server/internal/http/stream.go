@@ -0,0 +1,188 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/jomar/hookd/internal/storage"
+)
+
+// streamBufferSize is the number of events buffered per stream before
+// new events are dropped for that consumer
+const streamBufferSize = 256
+
+// streamWriteTimeout bounds how long a single write to a stream consumer may block
+const streamWriteTimeout = 10 * time.Second
+
+// streamHeartbeatInterval is how often an idle stream sends a heartbeat
+var streamHeartbeatInterval = 15 * time.Second
+
+// streamMessage is the payload pushed to stream consumers
+type streamMessage struct {
+	Type        string               `json:"type"`
+	HookID      string               `json:"hook_id,omitempty"`
+	Interaction *storage.Interaction `json:"interaction,omitempty"`
+	Timestamp   time.Time            `json:"timestamp"`
+}
+
+// HandleStream handles GET /stream?hooks=id1,id2
+// Interactions are pushed as Server-Sent Events, or over a WebSocket when the
+// client requests an upgrade. Streaming does not consume interactions.
+func (h *APIHandler) HandleStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondJSON(w, http.StatusMethodNotAllowed, map[string]string{
+			"error": "Method not allowed",
+		})
+		return
+	}
+
+	hookIDs := make([]string, 0)
+	for _, id := range strings.Split(r.URL.Query().Get("hooks"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			hookIDs = append(hookIDs, id)
+		}
+	}
+
+	if len(hookIDs) == 0 {
+		respondJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "hooks cannot be empty",
+		})
+		return
+	}
+
+	for _, id := range hookIDs {
+		if _, exists := h.storage.GetHook(id); !exists {
+			respondJSON(w, http.StatusNotFound, map[string]string{
+				"error": "Hook not found: " + id,
+			})
+			return
+		}
+	}
+
+	// Subscribe before answering so nothing stored after the response starts is missed
+	events, unsubscribe := h.storage.Subscribe(hookIDs, streamBufferSize)
+	defer unsubscribe()
+
+	if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		h.streamWebSocket(w, r, hookIDs, events)
+		return
+	}
+
+	h.streamSSE(w, r, hookIDs, events)
+}
+
+// streamSSE pushes interactions as Server-Sent Events
+func (h *APIHandler) streamSSE(w http.ResponseWriter, r *http.Request, hookIDs []string, events <-chan storage.Event) {
+	rc := http.NewResponseController(w)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if err := rc.Flush(); err != nil {
+		h.logger.Error("streaming not supported", "error", err)
+		return
+	}
+
+	h.logger.Info("stream opened", "transport", "sse", "hook_count", len(hookIDs), "client", r.RemoteAddr)
+
+	send := func(msg streamMessage) error {
+		// A stalled consumer must not hold the stream forever
+		_ = rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+
+		if msg.Type == "heartbeat" {
+			if _, err := fmt.Fprintf(w, ": heartbeat %s\n\n", msg.Timestamp.Format(time.RFC3339)); err != nil {
+				return err
+			}
+			return rc.Flush()
+		}
+
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+
+		if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", msg.Interaction.Seq, msg.Type, data); err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+
+	err := h.runStream(r.Context(), events, send)
+	h.logger.Info("stream closed", "transport", "sse", "client", r.RemoteAddr, "reason", err)
+}
+
+// streamWebSocket pushes interactions as JSON messages over a WebSocket
+func (h *APIHandler) streamWebSocket(w http.ResponseWriter, r *http.Request, hookIDs []string, events <-chan storage.Event) {
+	server := websocket.Server{
+		// Accept any origin: access is already restricted by the API key
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(ws *websocket.Conn) {
+			defer ws.Close()
+
+			// The connection is hijacked, so watch for the client going away by reading
+			ctx, cancel := context.WithCancel(r.Context())
+			defer cancel()
+
+			go func() {
+				defer cancel()
+				var discard []byte
+				for {
+					if err := websocket.Message.Receive(ws, &discard); err != nil {
+						return
+					}
+				}
+			}()
+
+			h.logger.Info("stream opened", "transport", "websocket", "hook_count", len(hookIDs), "client", r.RemoteAddr)
+
+			send := func(msg streamMessage) error {
+				_ = ws.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+				return websocket.JSON.Send(ws, msg)
+			}
+
+			err := h.runStream(ctx, events, send)
+			h.logger.Info("stream closed", "transport", "websocket", "client", r.RemoteAddr, "reason", err)
+		},
+	}
+
+	server.ServeHTTP(w, r)
+}
+
+// runStream forwards interaction events and heartbeats to send until the
+// context is done (client gone or server shutting down) or a write fails
+func (h *APIHandler) runStream(ctx context.Context, events <-chan storage.Event, send func(streamMessage) error) error {
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case event := <-events:
+			msg := streamMessage{
+				Type:        "interaction",
+				HookID:      event.HookID,
+				Interaction: event.Interaction,
+				Timestamp:   time.Now().UTC(),
+			}
+			if err := send(msg); err != nil {
+				return err
+			}
+
+		case <-heartbeat.C:
+			if err := send(streamMessage{Type: "heartbeat", Timestamp: time.Now().UTC()}); err != nil {
+				return err
+			}
+		}
+	}
+}
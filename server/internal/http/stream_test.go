@@ -0,0 +1,155 @@
+package http
+
+import (
+	"bufio"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/jomar/hookd/internal/config"
+	"github.com/jomar/hookd/internal/eviction"
+	"github.com/jomar/hookd/internal/storage"
+)
+
+func newStreamTestHandler() (*APIHandler, *storage.MemoryManager) {
+	idGen := func() string { return "test-id" }
+	manager := storage.NewMemoryManager(idGen)
+	evictorCfg := config.EvictionConfig{
+		CleanupInterval: 60,
+		InteractionTTL:  3600,
+		MaxPerHook:      100,
+		MaxMemoryMB:     100,
+	}
+	evictor := eviction.NewEvictor(manager, evictorCfg, slog.Default())
+
+	return NewAPIHandler(manager, evictor, "example.com", slog.Default(), idGen), manager
+}
+
+func TestAPIHandler_HandleStream_SSE(t *testing.T) {
+	handler, manager := newStreamTestHandler()
+	hook := manager.CreateHook("example.com")
+
+	server := httptest.NewServer(http.HandlerFunc(handler.HandleStream))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/stream?hooks=" + hook.ID)
+	if err != nil {
+		t.Fatalf("failed to open stream: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("expected text/event-stream, got %s", ct)
+	}
+
+	// The subscription is registered before headers are sent
+	manager.AddInteraction(hook.ID, storage.DNSInteraction("int-1", "1.2.3.4", "test.com", "A"))
+
+	reader := bufio.NewReader(resp.Body)
+	var event, data string
+	for data == "" {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("failed to read stream: %v", err)
+		}
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event: "))
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimSpace(strings.TrimPrefix(line, "data: "))
+		}
+	}
+
+	if event != "interaction" {
+		t.Errorf("expected interaction event, got %s", event)
+	}
+
+	var msg streamMessage
+	if err := json.Unmarshal([]byte(data), &msg); err != nil {
+		t.Fatalf("failed to decode event: %v", err)
+	}
+
+	if msg.HookID != hook.ID || msg.Interaction.ID != "int-1" {
+		t.Errorf("unexpected event: %+v", msg)
+	}
+
+	// Streaming does not consume interactions
+	if stats := manager.Stats(); stats.InteractionsTotal != 1 {
+		t.Errorf("expected interaction to remain stored, got %d", stats.InteractionsTotal)
+	}
+}
+
+func TestAPIHandler_HandleStream_WebSocket(t *testing.T) {
+	original := streamHeartbeatInterval
+	streamHeartbeatInterval = 50 * time.Millisecond
+	defer func() { streamHeartbeatInterval = original }()
+
+	handler, manager := newStreamTestHandler()
+	hook := manager.CreateHook("example.com")
+
+	server := httptest.NewServer(http.HandlerFunc(handler.HandleStream))
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/stream?hooks=" + hook.ID
+	ws, err := websocket.Dial(wsURL, "", server.URL)
+	if err != nil {
+		t.Fatalf("failed to open websocket: %v", err)
+	}
+	defer ws.Close()
+	ws.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	// Idle streams receive heartbeats
+	var msg streamMessage
+	if err := websocket.JSON.Receive(ws, &msg); err != nil {
+		t.Fatalf("failed to receive heartbeat: %v", err)
+	}
+	if msg.Type != "heartbeat" {
+		t.Errorf("expected heartbeat, got %s", msg.Type)
+	}
+
+	manager.AddInteraction(hook.ID, storage.HTTPInteraction("int-1", "1.2.3.4", "GET", "/", map[string]string{}, ""))
+
+	for msg.Type != "interaction" {
+		if err := websocket.JSON.Receive(ws, &msg); err != nil {
+			t.Fatalf("failed to receive interaction: %v", err)
+		}
+	}
+
+	if msg.HookID != hook.ID || msg.Interaction.ID != "int-1" {
+		t.Errorf("unexpected message: %+v", msg)
+	}
+}
+
+func TestAPIHandler_HandleStream_Validation(t *testing.T) {
+	handler, _ := newStreamTestHandler()
+
+	tests := []struct {
+		name     string
+		method   string
+		target   string
+		expected int
+	}{
+		{"missing hooks", http.MethodGet, "/stream", http.StatusBadRequest},
+		{"unknown hook", http.MethodGet, "/stream?hooks=nonexistent", http.StatusNotFound},
+		{"method not allowed", http.MethodPost, "/stream?hooks=test-id", http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			w := httptest.NewRecorder()
+
+			handler.HandleStream(w, req)
+
+			if w.Code != tt.expected {
+				t.Errorf("expected status %d, got %d", tt.expected, w.Code)
+			}
+		})
+	}
+}
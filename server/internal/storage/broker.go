@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Event is published whenever an interaction is stored for a hook
+type Event struct {
+	HookID      string
+	Interaction *Interaction
+}
+
+// subscriber receives events for a set of hooks
+type subscriber struct {
+	hookIDs map[string]bool
+	events  chan Event
+}
+
+// broker fans out stored interactions to subscribers.
+// Publishing never blocks: events are dropped for subscribers whose buffer is full,
+// so a slow consumer cannot stall DNS or HTTP capture.
+type broker struct {
+	subscribers map[*subscriber]struct{}
+	active      atomic.Int64 // number of subscribers, checked without locking
+	mu          sync.RWMutex
+}
+
+// newBroker creates an empty broker
+func newBroker() *broker {
+	return &broker{
+		subscribers: make(map[*subscriber]struct{}),
+	}
+}
+
+// subscribe registers a subscriber for the given hooks and returns its event
+// channel along with a function that unregisters it
+func (b *broker) subscribe(hookIDs []string, buffer int) (<-chan Event, func()) {
+	if buffer < 1 {
+		buffer = 1
+	}
+
+	sub := &subscriber{
+		hookIDs: make(map[string]bool, len(hookIDs)),
+		events:  make(chan Event, buffer),
+	}
+	for _, id := range hookIDs {
+		sub.hookIDs[id] = true
+	}
+
+	b.mu.Lock()
+	b.subscribers[sub] = struct{}{}
+	b.active.Add(1)
+	b.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers, sub)
+			b.active.Add(-1)
+			b.mu.Unlock()
+		})
+	}
+
+	return sub.events, unsubscribe
+}
+
+// publish delivers an event to every subscriber of the hook without blocking
+func (b *broker) publish(hookID string, interaction *Interaction) {
+	// Fast path: capture does not touch the broker lock when nobody is listening
+	if b.active.Load() == 0 {
+		return
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for sub := range b.subscribers {
+		if !sub.hookIDs[hookID] {
+			continue
+		}
+
+		select {
+		case sub.events <- Event{HookID: hookID, Interaction: interaction}:
+		default:
+			// Subscriber is not keeping up, drop the event
+		}
+	}
+}
@@ -0,0 +1,621 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const walFileName = "hookd.wal"
+
+// walOp identifies the kind of mutation recorded in the write-ahead log
+type walOp string
+
+const (
+	walOpCreateHook         walOp = "create_hook"
+	walOpAddInteraction     walOp = "add_interaction"
+	walOpDeleteInteractions walOp = "delete_interactions"
+	walOpDeleteHook         walOp = "delete_hook"
+	walOpAck                walOp = "ack"
+	walOpUpdateHook         walOp = "update_hook"
+	walOpSetExfil           walOp = "set_exfil"
+	walOpExfilChunk         walOp = "exfil_chunk"
+)
+
+// walEntry is a single line of the write-ahead log
+type walEntry struct {
+	Op          walOp        `json:"op"`
+	HookID      string       `json:"hook_id,omitempty"`
+	Hook        *Hook        `json:"hook,omitempty"`
+	Interaction *Interaction `json:"interaction,omitempty"`
+	IDs         []string     `json:"ids,omitempty"`
+	Cursor      uint64       `json:"cursor,omitempty"`
+	Chunk       *ExfilChunk  `json:"chunk,omitempty"`
+}
+
+// DiskManager implements persistent storage backed by a write-ahead log.
+// The live state is kept in a MemoryManager; every mutation is appended to
+// the log so it can be replayed after a restart. The log is periodically
+// rewritten into a compact snapshot of the live state.
+type DiskManager struct {
+	mem             *MemoryManager
+	dir             string
+	wal             *walWriter
+	pending         int      // entries appended since the last compaction
+	compacting      bool     // a snapshot is being written, appends go to backlog too
+	backlog         [][]byte // lines appended since the snapshot was captured
+	closed          bool     // set by Close, nothing is queued for the log anymore
+	compactInterval time.Duration
+	logger          *slog.Logger
+	mu              sync.Mutex // serializes mutations with the order of their log entries
+}
+
+// NewDiskManager opens (or creates) the write-ahead log in dir and replays it
+func NewDiskManager(dir string, compactInterval time.Duration, idGenerator func() string, logger *slog.Logger) (*DiskManager, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+
+	d := &DiskManager{
+		mem:             NewMemoryManager(idGenerator),
+		dir:             dir,
+		compactInterval: compactInterval,
+		logger:          logger,
+	}
+
+	if err := d.replay(); err != nil {
+		return nil, err
+	}
+
+	// Rewrite the log right away so a torn tail from a crash is dropped
+	d.wal = newWALWriter(logger)
+	if err := d.compact(); err != nil {
+		d.Close()
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// Start runs periodic compaction until the context is cancelled
+func (d *DiskManager) Start(ctx context.Context) {
+	ticker := time.NewTicker(d.compactInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.mu.Lock()
+			pending := d.pending
+			d.mu.Unlock()
+
+			if pending > 0 {
+				if err := d.compact(); err != nil {
+					d.logger.Error("wal compaction failed", "error", err)
+				}
+			}
+		}
+	}
+}
+
+// Close writes the queued entries, flushes the write-ahead log to disk and
+// closes it
+func (d *DiskManager) Close() error {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return nil
+	}
+	d.closed = true
+	close(d.wal.requests)
+	d.mu.Unlock()
+
+	return <-d.wal.stopped
+}
+
+// CreateHook creates a new hook with a generated ID and persists it.
+// It returns nil if no unused ID could be generated.
+func (d *DiskManager) CreateHook(domain string) *Hook {
+	hook, _ := d.CreateHookWithOptions(domain, HookOptions{})
+	return hook
+}
+
+// CreateHookWithOptions creates a new hook with the given options and persists it
+func (d *DiskManager) CreateHookWithOptions(domain string, opts HookOptions) (*Hook, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	hook, err := d.mem.CreateHookWithOptions(domain, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	d.appendOrLog(walEntry{Op: walOpCreateHook, Hook: hook})
+	return hook, nil
+}
+
+// GetHook retrieves a hook by ID
+func (d *DiskManager) GetHook(id string) (*Hook, bool) {
+	return d.mem.GetHook(id)
+}
+
+// AddInteraction persists an interaction and adds it to a hook. The entry is
+// queued under the lock but written after releasing it, so concurrent
+// captures are not serialized on the write.
+func (d *DiskManager) AddInteraction(hookID string, interaction *Interaction) error {
+	d.mu.Lock()
+
+	if _, exists := d.mem.GetHook(hookID); !exists {
+		d.mu.Unlock()
+		// Orphans, if kept, are not persisted
+		return d.mem.AddInteraction(hookID, interaction)
+	}
+
+	// The sequence number is assigned by the memory store, so log afterwards
+	if err := d.mem.AddInteraction(hookID, interaction); err != nil {
+		d.mu.Unlock()
+		return err
+	}
+
+	done := d.queue(walEntry{Op: walOpAddInteraction, HookID: hookID, Interaction: interaction})
+	d.mu.Unlock()
+
+	return <-done
+}
+
+// PollInteractions retrieves and deletes interactions for a hook
+func (d *DiskManager) PollInteractions(hookID string) ([]*Interaction, error) {
+	interactions, _, err := d.PollMatching(hookID, PollOptions{})
+	return interactions, err
+}
+
+// PollMatching retrieves and deletes the interactions of a hook that match the options
+func (d *DiskManager) PollMatching(hookID string, opts PollOptions) ([]*Interaction, bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	interactions, hasMore, err := d.mem.PollMatching(hookID, opts)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if len(interactions) > 0 {
+		d.appendOrLog(walEntry{Op: walOpDeleteInteractions, HookID: hookID, IDs: interactionIDs(interactions)})
+	}
+
+	return interactions, hasMore, nil
+}
+
+// PollInteractionsBatch retrieves and deletes interactions for multiple hooks
+func (d *DiskManager) PollInteractionsBatch(hookIDs []string) map[string]*PollResult {
+	return d.PollMatchingBatch(hookIDs, PollOptions{})
+}
+
+// PollMatchingBatch retrieves and deletes matching interactions for multiple hooks
+func (d *DiskManager) PollMatchingBatch(hookIDs []string, opts PollOptions) map[string]*PollResult {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	results := d.mem.PollMatchingBatch(hookIDs, opts)
+	for hookID, result := range results {
+		if len(result.Interactions) > 0 {
+			d.appendOrLog(walEntry{Op: walOpDeleteInteractions, HookID: hookID, IDs: interactionIDs(result.Interactions)})
+		}
+	}
+
+	return results
+}
+
+// PeekInteractions returns interactions after a cursor without deleting them
+func (d *DiskManager) PeekInteractions(hookID string, cursor uint64) ([]*Interaction, uint64, error) {
+	return d.mem.PeekInteractions(hookID, cursor)
+}
+
+// PendingCount returns the number of interactions queued for a hook
+func (d *DiskManager) PendingCount(hookID string) int {
+	return d.mem.PendingCount(hookID)
+}
+
+// PeekMatching returns matching interactions after a cursor without deleting them
+func (d *DiskManager) PeekMatching(hookID string, cursor uint64, opts PollOptions) ([]*Interaction, uint64, bool, error) {
+	return d.mem.PeekMatching(hookID, cursor, opts)
+}
+
+// AckInteractions deletes interactions up to and including a cursor
+func (d *DiskManager) AckInteractions(hookID string, cursor uint64) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	acked := d.mem.AckInteractions(hookID, cursor)
+	if acked > 0 {
+		d.appendOrLog(walEntry{Op: walOpAck, HookID: hookID, Cursor: cursor})
+	}
+
+	return acked
+}
+
+// Subscribe registers for events on the given hooks
+func (d *DiskManager) Subscribe(hookIDs []string, buffer int) (<-chan Event, func()) {
+	return d.mem.Subscribe(hookIDs, buffer)
+}
+
+// GetAllHooks returns all registered hooks
+func (d *DiskManager) GetAllHooks() []*Hook {
+	return d.mem.GetAllHooks()
+}
+
+// GetAllInteractions returns all interactions
+func (d *DiskManager) GetAllInteractions() map[string][]*Interaction {
+	return d.mem.GetAllInteractions()
+}
+
+// DeleteInteractions deletes specific interactions from a hook
+func (d *DiskManager) DeleteInteractions(hookID string, interactionIDs []string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.mem.DeleteInteractions(hookID, interactionIDs)
+	d.appendOrLog(walEntry{Op: walOpDeleteInteractions, HookID: hookID, IDs: interactionIDs})
+}
+
+// DeleteHook deletes a hook and all its interactions
+func (d *DiskManager) DeleteHook(hookID string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.mem.DeleteHook(hookID)
+	d.appendOrLog(walEntry{Op: walOpDeleteHook, HookID: hookID})
+}
+
+// RenewHook sets a new expiry time for a hook and persists it
+func (d *DiskManager) RenewHook(hookID string, expiresAt time.Time) (*Hook, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	hook, exists := d.mem.RenewHook(hookID, expiresAt)
+	if exists {
+		d.appendOrLog(walEntry{Op: walOpUpdateHook, Hook: hook})
+	}
+
+	return hook, exists
+}
+
+// SetDNSRecords replaces the custom DNS records of a hook and persists them
+func (d *DiskManager) SetDNSRecords(hookID string, records []DNSRecord) (*Hook, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	hook, exists := d.mem.SetDNSRecords(hookID, records)
+	if exists {
+		d.appendOrLog(walEntry{Op: walOpUpdateHook, Hook: hook})
+	}
+
+	return hook, exists
+}
+
+// SetRebind sets or clears the DNS rebinding strategy of a hook and persists it
+func (d *DiskManager) SetRebind(hookID string, rebind *RebindConfig) (*Hook, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	hook, exists := d.mem.SetRebind(hookID, rebind)
+	if exists {
+		d.appendOrLog(walEntry{Op: walOpUpdateHook, Hook: hook})
+	}
+
+	return hook, exists
+}
+
+// SetHTTPResponses replaces the HTTP responses of a hook and persists them
+func (d *DiskManager) SetHTTPResponses(hookID string, responses []HTTPResponse) (*Hook, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	hook, exists := d.mem.SetHTTPResponses(hookID, responses)
+	if exists {
+		d.appendOrLog(walEntry{Op: walOpUpdateHook, Hook: hook})
+	}
+
+	return hook, exists
+}
+
+// SetExfil enables or disables exfiltration decoding and persists it
+func (d *DiskManager) SetExfil(hookID string, exfil *ExfilConfig) (*Hook, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	hook, exists := d.mem.SetExfil(hookID, exfil)
+	if exists {
+		// Not an update_hook entry: replaying it must also drop the chunks
+		d.appendOrLog(walEntry{Op: walOpSetExfil, HookID: hookID, Hook: hook})
+	}
+
+	return hook, exists
+}
+
+// AddExfilChunk collects a chunk of an exfiltrated payload and persists it
+func (d *DiskManager) AddExfilChunk(hookID string, chunk ExfilChunk) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	added := d.mem.AddExfilChunk(hookID, chunk)
+	if added {
+		d.appendOrLog(walEntry{Op: walOpExfilChunk, HookID: hookID, Chunk: &chunk})
+	}
+
+	return added
+}
+
+// ExfilChunks returns the chunks collected for a hook, sorted by sequence number
+func (d *DiskManager) ExfilChunks(hookID string) ([]ExfilChunk, bool) {
+	return d.mem.ExfilChunks(hookID)
+}
+
+// SetOrphans makes interactions for unknown hooks go to an orphan store. It
+// must be called before the manager is used.
+func (d *DiskManager) SetOrphans(orphans *OrphanStore) {
+	d.mem.SetOrphans(orphans)
+}
+
+// Orphans returns the orphan store, or nil when orphans are not kept
+func (d *DiskManager) Orphans() *OrphanStore {
+	return d.mem.Orphans()
+}
+
+// Stats returns storage statistics
+func (d *DiskManager) Stats() Stats {
+	return d.mem.Stats()
+}
+
+// queue encodes an entry and queues it for the log writer (caller must hold
+// d.mu, which fixes the order of the entries). The returned channel reports
+// the outcome of the write.
+func (d *DiskManager) queue(entry walEntry) <-chan error {
+	done := make(chan error, 1)
+	if d.closed {
+		done <- fmt.Errorf("wal is closed")
+		return done
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		done <- fmt.Errorf("failed to encode wal entry: %w", err)
+		return done
+	}
+	line = append(line, '\n')
+
+	d.wal.requests <- walRequest{line: line, done: done}
+	d.pending++
+	if d.compacting {
+		d.backlog = append(d.backlog, line)
+	}
+	return done
+}
+
+// appendOrLog writes an entry for operations that cannot report errors
+// (caller must hold d.mu)
+func (d *DiskManager) appendOrLog(entry walEntry) {
+	if err := <-d.queue(entry); err != nil {
+		d.logger.Error("failed to persist storage operation", "op", entry.Op, "error", err)
+	}
+}
+
+// replay rebuilds the in-memory state from the log on disk
+func (d *DiskManager) replay() error {
+	f, err := os.Open(filepath.Join(d.dir, walFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open wal: %w", err)
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	for lineNum := 1; ; lineNum++ {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(bytes.TrimSpace(line)) > 0 {
+				// Torn write from a crash: the entry was never acknowledged
+				d.logger.Warn("ignoring incomplete wal entry", "line", lineNum)
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read wal: %w", err)
+		}
+
+		var entry walEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return fmt.Errorf("corrupt wal entry at line %d: %w", lineNum, err)
+		}
+
+		d.apply(entry)
+	}
+}
+
+// apply replays a single log entry against the in-memory state
+func (d *DiskManager) apply(entry walEntry) {
+	switch entry.Op {
+	case walOpCreateHook:
+		if entry.Hook != nil {
+			d.mem.restoreHook(entry.Hook, entry.Cursor)
+		}
+	case walOpAddInteraction:
+		if entry.Interaction != nil {
+			d.mem.restoreInteraction(entry.HookID, entry.Interaction)
+		}
+	case walOpDeleteInteractions:
+		d.mem.DeleteInteractions(entry.HookID, entry.IDs)
+	case walOpDeleteHook:
+		d.mem.DeleteHook(entry.HookID)
+	case walOpAck:
+		d.mem.AckInteractions(entry.HookID, entry.Cursor)
+	case walOpUpdateHook:
+		if entry.Hook != nil {
+			d.mem.restoreHook(entry.Hook, 0)
+		}
+	case walOpSetExfil:
+		if entry.Hook != nil {
+			d.mem.SetExfil(entry.HookID, entry.Hook.Exfil)
+		}
+	case walOpExfilChunk:
+		if entry.Chunk != nil {
+			d.mem.AddExfilChunk(entry.HookID, *entry.Chunk)
+		}
+	}
+}
+
+// walSnapshot is the live state captured for a compaction. Hooks are replaced
+// rather than modified and interaction slices are never written in place, so
+// the captured values stay consistent once the lock is released.
+type walSnapshot struct {
+	hooks        []*Hook
+	interactions map[string][]*Interaction
+	cursors      map[string]uint64
+	chunks       map[string][]ExfilChunk
+}
+
+// compact rewrites the log into a snapshot of the live state. The state is
+// captured under d.mu, but written and synced without holding it so captures
+// are not stalled. Entries appended in the meantime are carried over to the
+// new log before it replaces the old one, which stays in use on any failure.
+func (d *DiskManager) compact() error {
+	d.mu.Lock()
+	snap := d.captureSnapshot()
+	d.compacting = true
+	d.mu.Unlock()
+
+	path := filepath.Join(d.dir, walFileName)
+	tmpPath := path + ".tmp"
+	tmp, err := writeSnapshot(tmpPath, snap)
+
+	d.mu.Lock()
+	backlog, pending := d.backlog, d.pending
+	d.backlog, d.compacting = nil, false
+	if err == nil && d.closed {
+		err = fmt.Errorf("wal is closed")
+	}
+	if err != nil {
+		d.mu.Unlock()
+		if tmp != nil {
+			tmp.Close()
+			os.Remove(tmpPath)
+		}
+		return err
+	}
+
+	// Queued in order: the writer installs the snapshot right after the
+	// entries of the backlog reached the old log
+	done := make(chan error, 1)
+	d.wal.requests <- walRequest{install: &walInstall{snapshot: tmp, path: path, backlog: backlog}, done: done}
+	d.mu.Unlock()
+
+	if err := <-done; err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+
+	d.mu.Lock()
+	d.pending -= pending - len(backlog)
+	d.mu.Unlock()
+	return nil
+}
+
+// captureSnapshot collects the live state (caller must hold d.mu)
+func (d *DiskManager) captureSnapshot() walSnapshot {
+	hooks := d.mem.GetAllHooks()
+	sort.Slice(hooks, func(i, j int) bool {
+		return hooks[i].CreatedAt.Before(hooks[j].CreatedAt)
+	})
+
+	snap := walSnapshot{
+		hooks:        hooks,
+		interactions: d.mem.GetAllInteractions(),
+		cursors:      make(map[string]uint64, len(hooks)),
+		chunks:       make(map[string][]ExfilChunk),
+	}
+	for _, hook := range hooks {
+		snap.cursors[hook.ID] = d.mem.cursor(hook.ID)
+		if chunks, _ := d.mem.ExfilChunks(hook.ID); len(chunks) > 0 {
+			snap.chunks[hook.ID] = chunks
+		}
+	}
+
+	return snap
+}
+
+// writeSnapshot writes every captured hook, interaction and exfiltrated chunk
+// to a new file at path and syncs it. The file is left open for appends, and
+// removed on failure.
+func writeSnapshot(path string, snap walSnapshot) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create wal snapshot: %w", err)
+	}
+
+	if err := encodeSnapshot(f, snap); err != nil {
+		f.Close()
+		os.Remove(path)
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// encodeSnapshot encodes a snapshot to f and syncs it
+func encodeSnapshot(f *os.File, snap walSnapshot) error {
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+
+	for _, hook := range snap.hooks {
+		// Keep the cursor so sequence numbers stay monotonic after acknowledged interactions are gone
+		if err := enc.Encode(walEntry{Op: walOpCreateHook, Hook: hook, Cursor: snap.cursors[hook.ID]}); err != nil {
+			return fmt.Errorf("failed to write wal snapshot: %w", err)
+		}
+		for _, interaction := range snap.interactions[hook.ID] {
+			if err := enc.Encode(walEntry{Op: walOpAddInteraction, HookID: hook.ID, Interaction: interaction}); err != nil {
+				return fmt.Errorf("failed to write wal snapshot: %w", err)
+			}
+		}
+
+		chunks := snap.chunks[hook.ID]
+		for i := range chunks {
+			if err := enc.Encode(walEntry{Op: walOpExfilChunk, HookID: hook.ID, Chunk: &chunks[i]}); err != nil {
+				return fmt.Errorf("failed to write wal snapshot: %w", err)
+			}
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write wal snapshot: %w", err)
+	}
+
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("failed to sync wal snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// interactionIDs returns the IDs of the given interactions
+func interactionIDs(interactions []*Interaction) []string {
+	ids := make([]string, len(interactions))
+	for i, interaction := range interactions {
+		ids[i] = interaction.ID
+	}
+	return ids
+}
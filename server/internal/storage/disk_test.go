@@ -0,0 +1,472 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestDiskManager(t *testing.T, dir string) *DiskManager {
+	t.Helper()
+
+	counter := 0
+	idGen := func() string {
+		counter++
+		return fmt.Sprintf("id-%d-%d", time.Now().UnixNano(), counter)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	manager, err := NewDiskManager(dir, time.Minute, idGen, logger)
+	if err != nil {
+		t.Fatalf("failed to open disk manager: %v", err)
+	}
+
+	return manager
+}
+
+func TestDiskManager_RestoreAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+
+	manager := newTestDiskManager(t, dir)
+	hook := manager.CreateHook("example.com")
+	manager.AddInteraction(hook.ID, DNSInteraction("int1", "1.2.3.4", "test.com", "A"))
+	manager.AddInteraction(hook.ID, HTTPInteraction("int2", "5.6.7.8", "POST", "/cb", map[string]string{"User-Agent": "curl"}, "body"))
+
+	if err := manager.Close(); err != nil {
+		t.Fatalf("failed to close disk manager: %v", err)
+	}
+
+	reopened := newTestDiskManager(t, dir)
+	defer reopened.Close()
+
+	restored, exists := reopened.GetHook(hook.ID)
+	if !exists {
+		t.Fatal("expected hook to be restored")
+	}
+
+	if restored.DNS != hook.DNS || !restored.CreatedAt.Equal(hook.CreatedAt) {
+		t.Errorf("restored hook mismatch: got %+v, want %+v", restored, hook)
+	}
+
+	interactions, _ := reopened.PollInteractions(hook.ID)
+	if len(interactions) != 2 {
+		t.Fatalf("expected 2 restored interactions, got %d", len(interactions))
+	}
+
+	if interactions[0].ID != "int1" || interactions[1].ID != "int2" {
+		t.Errorf("expected interactions in insertion order, got %s, %s", interactions[0].ID, interactions[1].ID)
+	}
+
+	if interactions[1].Data["body"] != "body" {
+		t.Errorf("expected body to be restored, got %v", interactions[1].Data["body"])
+	}
+}
+
+func TestDiskManager_DeletesArePersisted(t *testing.T) {
+	dir := t.TempDir()
+
+	manager := newTestDiskManager(t, dir)
+	polled := manager.CreateHook("example.com")
+	evicted := manager.CreateHook("example.com")
+	deleted := manager.CreateHook("example.com")
+
+	manager.AddInteraction(polled.ID, DNSInteraction("int1", "1.2.3.4", "test.com", "A"))
+	manager.AddInteraction(evicted.ID, DNSInteraction("int2", "1.2.3.4", "test.com", "A"))
+	manager.AddInteraction(evicted.ID, DNSInteraction("int3", "1.2.3.4", "test.com", "A"))
+
+	manager.PollInteractions(polled.ID)
+	manager.DeleteInteractions(evicted.ID, []string{"int2"})
+	manager.DeleteHook(deleted.ID)
+	manager.Close()
+
+	reopened := newTestDiskManager(t, dir)
+	defer reopened.Close()
+
+	if _, exists := reopened.GetHook(deleted.ID); exists {
+		t.Error("expected deleted hook to stay deleted")
+	}
+
+	stats := reopened.Stats()
+	if stats.HooksActive != 2 {
+		t.Errorf("expected 2 hooks, got %d", stats.HooksActive)
+	}
+
+	if stats.InteractionsTotal != 1 {
+		t.Errorf("expected 1 interaction, got %d", stats.InteractionsTotal)
+	}
+
+	interactions, _ := reopened.PollInteractions(evicted.ID)
+	if len(interactions) != 1 || interactions[0].ID != "int3" {
+		t.Errorf("expected only int3 to remain, got %v", interactions)
+	}
+}
+
+func TestDiskManager_Compaction(t *testing.T) {
+	dir := t.TempDir()
+
+	manager := newTestDiskManager(t, dir)
+	defer manager.Close()
+
+	hook := manager.CreateHook("example.com")
+	for i := 0; i < 50; i++ {
+		manager.AddInteraction(hook.ID, DNSInteraction(fmt.Sprintf("int%d", i), "1.2.3.4", "test.com", "A"))
+	}
+	manager.PollInteractions(hook.ID)
+
+	walPath := filepath.Join(dir, walFileName)
+	before, _ := os.Stat(walPath)
+
+	if err := manager.compact(); err != nil {
+		t.Fatalf("compaction failed: %v", err)
+	}
+
+	after, _ := os.Stat(walPath)
+	if after.Size() >= before.Size() {
+		t.Errorf("expected compaction to shrink wal (%d -> %d bytes)", before.Size(), after.Size())
+	}
+
+	if manager.pending != 0 {
+		t.Errorf("expected no pending entries after compaction, got %d", manager.pending)
+	}
+
+	// Writes after compaction must still be appended
+	manager.AddInteraction(hook.ID, DNSInteraction("late", "1.2.3.4", "test.com", "A"))
+	if manager.pending != 1 {
+		t.Errorf("expected 1 pending entry, got %d", manager.pending)
+	}
+}
+
+func TestDiskManager_CompactionKeepsConcurrentWrites(t *testing.T) {
+	dir := t.TempDir()
+
+	manager := newTestDiskManager(t, dir)
+	hook := manager.CreateHook("example.com")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 500; i++ {
+			manager.AddInteraction(hook.ID, DNSInteraction(fmt.Sprintf("int%d", i), "1.2.3.4", "test.com", "A"))
+		}
+	}()
+
+	// Interactions added while a snapshot is written must reach the new log
+	for i := 0; i < 20; i++ {
+		if err := manager.compact(); err != nil {
+			t.Fatalf("compaction failed: %v", err)
+		}
+	}
+	<-done
+
+	if err := manager.Close(); err != nil {
+		t.Fatalf("failed to close disk manager: %v", err)
+	}
+
+	reopened := newTestDiskManager(t, dir)
+	defer reopened.Close()
+
+	interactions, _ := reopened.PollInteractions(hook.ID)
+	if len(interactions) != 500 {
+		t.Errorf("expected 500 restored interactions, got %d", len(interactions))
+	}
+}
+
+func TestDiskManager_CompactionFailureKeepsLog(t *testing.T) {
+	dir := t.TempDir()
+
+	manager := newTestDiskManager(t, dir)
+	defer manager.Close()
+
+	hook := manager.CreateHook("example.com")
+
+	// A non-empty directory in place of the log makes the rename fail
+	walPath := filepath.Join(dir, walFileName)
+	if err := os.Remove(walPath); err != nil {
+		t.Fatalf("failed to remove wal: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(walPath, "blocker"), 0o700); err != nil {
+		t.Fatalf("failed to create blocker: %v", err)
+	}
+
+	if err := manager.compact(); err == nil {
+		t.Fatal("expected compaction to fail")
+	}
+
+	if _, err := os.Stat(walPath + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("expected the snapshot to be removed, got %v", err)
+	}
+
+	// The old log stays open, mutations are still written
+	if err := manager.AddInteraction(hook.ID, DNSInteraction("late", "1.2.3.4", "test.com", "A")); err != nil {
+		t.Errorf("expected the log to remain writable, got %v", err)
+	}
+}
+
+func TestDiskManager_TornTail(t *testing.T) {
+	dir := t.TempDir()
+
+	manager := newTestDiskManager(t, dir)
+	hook := manager.CreateHook("example.com")
+	manager.AddInteraction(hook.ID, DNSInteraction("int1", "1.2.3.4", "test.com", "A"))
+	manager.Close()
+
+	// Simulate a crash in the middle of writing an entry
+	f, err := os.OpenFile(filepath.Join(dir, walFileName), os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		t.Fatalf("failed to open wal: %v", err)
+	}
+	f.WriteString(`{"op":"add_interaction","hook_id":"` + hook.ID + `","interac`)
+	f.Close()
+
+	reopened := newTestDiskManager(t, dir)
+	defer reopened.Close()
+
+	if stats := reopened.Stats(); stats.InteractionsTotal != 1 {
+		t.Errorf("expected 1 interaction, got %d", stats.InteractionsTotal)
+	}
+}
+
+func TestDiskManager_CorruptEntry(t *testing.T) {
+	dir := t.TempDir()
+
+	content := "not json\n" + `{"op":"delete_hook","hook_id":"x"}` + "\n"
+	if err := os.WriteFile(filepath.Join(dir, walFileName), []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write wal: %v", err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	if _, err := NewDiskManager(dir, time.Minute, func() string { return "id" }, logger); err == nil {
+		t.Error("expected error for corrupt wal entry")
+	}
+}
+
+func TestDiskManager_AddInteraction_NonExistentHook(t *testing.T) {
+	manager := newTestDiskManager(t, t.TempDir())
+	defer manager.Close()
+
+	if err := manager.AddInteraction("nonexistent", DNSInteraction("int1", "1.2.3.4", "test.com", "A")); err != nil {
+		t.Errorf("expected nil error, got %v", err)
+	}
+
+	if manager.pending != 0 {
+		t.Errorf("expected nothing to be logged, got %d entries", manager.pending)
+	}
+}
+
+func TestDiskManager_CursorSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+
+	manager := newTestDiskManager(t, dir)
+	hook := manager.CreateHook("example.com")
+	manager.AddInteraction(hook.ID, DNSInteraction("int1", "1.2.3.4", "test.com", "A"))
+	manager.AddInteraction(hook.ID, DNSInteraction("int2", "1.2.3.4", "test.com", "A"))
+	manager.AckInteractions(hook.ID, 2)
+
+	// Compaction drops the acknowledged interactions entirely
+	manager.compact()
+	manager.Close()
+
+	reopened := newTestDiskManager(t, dir)
+	defer reopened.Close()
+
+	reopened.AddInteraction(hook.ID, DNSInteraction("int3", "1.2.3.4", "test.com", "A"))
+
+	interactions, cursor, _ := reopened.PeekInteractions(hook.ID, 2)
+	if len(interactions) != 1 || interactions[0].ID != "int3" {
+		t.Fatalf("expected int3 after cursor 2, got %v", interactions)
+	}
+
+	if cursor != 3 {
+		t.Errorf("expected cursor 3, got %d", cursor)
+	}
+}
+
+func TestDiskManager_RenewSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+
+	manager := newTestDiskManager(t, dir)
+	hook, _ := manager.CreateHookWithOptions("example.com", HookOptions{
+		TTL:    time.Hour,
+		Labels: map[string]string{"project": "acme"},
+	})
+	expiresAt := time.Now().Add(72 * time.Hour)
+	manager.RenewHook(hook.ID, expiresAt)
+	manager.Close()
+
+	reopened := newTestDiskManager(t, dir)
+	defer reopened.Close()
+
+	restored, exists := reopened.GetHook(hook.ID)
+	if !exists {
+		t.Fatal("expected hook to be restored")
+	}
+
+	if !restored.ExpiresAt.Equal(expiresAt) {
+		t.Errorf("expected expiry %v, got %v", expiresAt, restored.ExpiresAt)
+	}
+
+	if restored.TTL != 3600 || restored.Labels["project"] != "acme" {
+		t.Errorf("expected hook options to be restored, got %+v", restored)
+	}
+}
+
+func TestDiskManager_DNSRecordsSurviveRestart(t *testing.T) {
+	dir := t.TempDir()
+
+	manager := newTestDiskManager(t, dir)
+	hook := manager.CreateHook("example.com")
+	manager.SetDNSRecords(hook.ID, []DNSRecord{{Type: "MX", Value: "mail.example.net.", Priority: 5, TTL: 30}})
+	manager.Close()
+
+	reopened := newTestDiskManager(t, dir)
+	defer reopened.Close()
+
+	restored, exists := reopened.GetHook(hook.ID)
+	if !exists {
+		t.Fatal("expected hook to be restored")
+	}
+
+	want := DNSRecord{Type: "MX", Value: "mail.example.net.", Priority: 5, TTL: 30}
+	if len(restored.DNSRecords) != 1 || restored.DNSRecords[0] != want {
+		t.Errorf("expected records %v, got %v", want, restored.DNSRecords)
+	}
+}
+
+func TestDiskManager_RebindSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+
+	manager := newTestDiskManager(t, dir)
+	hook := manager.CreateHook("example.com")
+	manager.SetRebind(hook.ID, &RebindConfig{Mode: RebindModeTime, Answers: []string{"self", "169.254.169.254"}, Interval: 5, TTL: 1})
+	manager.Close()
+
+	reopened := newTestDiskManager(t, dir)
+	defer reopened.Close()
+
+	restored, exists := reopened.GetHook(hook.ID)
+	if !exists {
+		t.Fatal("expected hook to be restored")
+	}
+
+	rebind := restored.Rebind
+	if rebind == nil || rebind.Mode != RebindModeTime || rebind.Interval != 5 || rebind.TTL != 1 || len(rebind.Answers) != 2 {
+		t.Errorf("expected time strategy to be restored, got %+v", rebind)
+	}
+}
+
+func TestDiskManager_HTTPResponsesSurviveRestart(t *testing.T) {
+	dir := t.TempDir()
+
+	manager := newTestDiskManager(t, dir)
+	hook := manager.CreateHook("example.com")
+	manager.SetHTTPResponses(hook.ID, []HTTPResponse{{Method: "GET", Status: 401, Headers: map[string]string{"Www-Authenticate": "Basic"}, DelayMS: 500}})
+	manager.Close()
+
+	reopened := newTestDiskManager(t, dir)
+	defer reopened.Close()
+
+	restored, exists := reopened.GetHook(hook.ID)
+	if !exists {
+		t.Fatal("expected hook to be restored")
+	}
+
+	responses := restored.HTTPResponses
+	if len(responses) != 1 || responses[0].Status != 401 || responses[0].DelayMS != 500 || responses[0].Headers["Www-Authenticate"] != "Basic" {
+		t.Errorf("expected responses to be restored, got %+v", responses)
+	}
+}
+
+func TestDiskManager_ExfilChunksSurviveRestart(t *testing.T) {
+	dir := t.TempDir()
+
+	manager := newTestDiskManager(t, dir)
+	first := manager.CreateHook("example.com")
+	manager.SetExfil(first.ID, &ExfilConfig{Encoding: ExfilEncodingHex})
+	manager.AddExfilChunk(first.ID, ExfilChunk{Seq: 0, Data: "ff"})
+
+	// Re-enabling drops the previous chunks, also when replayed
+	manager.SetExfil(first.ID, &ExfilConfig{Encoding: ExfilEncodingHex})
+	manager.AddExfilChunk(first.ID, ExfilChunk{Seq: 0, Data: "72"})
+	manager.AddExfilChunk(first.ID, ExfilChunk{Seq: 1, Data: "6f"})
+	manager.Close()
+
+	reopened := newTestDiskManager(t, dir)
+	chunks, _ := reopened.ExfilChunks(first.ID)
+	if len(chunks) != 2 || chunks[0].Data != "72" || chunks[1].Data != "6f" {
+		t.Fatalf("expected chunks to be restored, got %v", chunks)
+	}
+
+	// Opening compacted the log, the snapshot keeps the chunks too
+	reopened.Close()
+	compacted := newTestDiskManager(t, dir)
+	defer compacted.Close()
+
+	hook, _ := compacted.GetHook(first.ID)
+	if hook.Exfil == nil || hook.Exfil.Encoding != ExfilEncodingHex {
+		t.Errorf("expected exfil config to be restored, got %+v", hook.Exfil)
+	}
+	if chunks, _ := compacted.ExfilChunks(first.ID); len(chunks) != 2 {
+		t.Errorf("expected 2 chunks after compaction, got %v", chunks)
+	}
+}
+
+func TestDiskManager_PollMatchingIsPersisted(t *testing.T) {
+	dir := t.TempDir()
+
+	manager := newTestDiskManager(t, dir)
+	hook := manager.CreateHook("example.com")
+	manager.AddInteraction(hook.ID, DNSInteraction("int1", "1.2.3.4", "test.com", "A"))
+	manager.AddInteraction(hook.ID, HTTPInteraction("int2", "1.2.3.4", "GET", "/", nil, ""))
+
+	manager.PollMatching(hook.ID, PollOptions{Filter: &Filter{Types: []InteractionType{InteractionTypeDNS}}})
+	manager.Close()
+
+	reopened := newTestDiskManager(t, dir)
+	defer reopened.Close()
+
+	interactions, _ := reopened.PollInteractions(hook.ID)
+	if len(interactions) != 1 || interactions[0].ID != "int2" {
+		t.Errorf("expected only int2 to remain, got %v", interactions)
+	}
+}
+
+func TestDiskManager_ConcurrentCaptures(t *testing.T) {
+	dir := t.TempDir()
+
+	manager := newTestDiskManager(t, dir)
+	hook := manager.CreateHook("example.com")
+
+	// Entries queued by concurrent captures are written in batches
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				if err := manager.AddInteraction(hook.ID, DNSInteraction(fmt.Sprintf("int%d-%d", g, i), "1.2.3.4", "test.com", "A")); err != nil {
+					t.Errorf("failed to add interaction: %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := manager.Close(); err != nil {
+		t.Fatalf("failed to close disk manager: %v", err)
+	}
+	if err := manager.AddInteraction(hook.ID, DNSInteraction("late", "1.2.3.4", "test.com", "A")); err == nil {
+		t.Error("expected an error after close")
+	}
+
+	reopened := newTestDiskManager(t, dir)
+	defer reopened.Close()
+
+	if stats := reopened.Stats(); stats.InteractionsTotal != 800 {
+		t.Errorf("expected 800 restored interactions, got %d", stats.InteractionsTotal)
+	}
+}
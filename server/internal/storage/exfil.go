@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxExfilChunks caps the chunks collected per hook
+const MaxExfilChunks = 4096
+
+// ExfilChunk is a piece of an exfiltrated payload, as received in a DNS query
+type ExfilChunk struct {
+	Seq  int    `json:"seq"`
+	Data string `json:"data"` // encoded chunk labels, concatenated
+}
+
+// ExfilPayload is a payload reassembled from the chunks collected for a hook
+type ExfilPayload struct {
+	Encoding string `json:"encoding"`
+	Chunks   int    `json:"chunks"`
+	FirstSeq int    `json:"first_seq"`
+	LastSeq  int    `json:"last_seq"`
+	Missing  []int  `json:"missing"`         // sequence numbers absent between first_seq and last_seq
+	Data     []byte `json:"data"`            // decoded payload, base64 in JSON
+	Text     string `json:"text,omitempty"`  // decoded payload, when it is valid UTF-8
+	Error    string `json:"error,omitempty"` // why the chunks could not be decoded
+}
+
+// ReassembleExfil decodes chunks sorted by sequence number. Chunks are
+// concatenated before decoding, so they may split the payload anywhere
+func ReassembleExfil(encoding string, chunks []ExfilChunk) *ExfilPayload {
+	payload := &ExfilPayload{
+		Encoding: encoding,
+		Chunks:   len(chunks),
+		Missing:  []int{},
+	}
+	if len(chunks) == 0 {
+		return payload
+	}
+
+	payload.FirstSeq = chunks[0].Seq
+	payload.LastSeq = chunks[len(chunks)-1].Seq
+
+	var encoded strings.Builder
+	next := payload.FirstSeq
+	for _, chunk := range chunks {
+		for ; next < chunk.Seq; next++ {
+			payload.Missing = append(payload.Missing, next)
+		}
+		next = chunk.Seq + 1
+		encoded.WriteString(chunk.Data)
+	}
+
+	if len(payload.Missing) > 0 {
+		payload.Error = fmt.Sprintf("%d chunks missing", len(payload.Missing))
+		return payload
+	}
+
+	data, err := decodeExfil(encoding, encoded.String())
+	if err != nil {
+		payload.Error = err.Error()
+		return payload
+	}
+
+	payload.Data = data
+	if utf8.Valid(data) {
+		payload.Text = string(data)
+	}
+	return payload
+}
+
+// decodeExfil decodes a concatenated payload. Hex and base32 are case
+// insensitive since resolvers may randomize the case of query names
+func decodeExfil(encoding, encoded string) ([]byte, error) {
+	encoded = strings.TrimRight(encoded, "=")
+
+	var (
+		data []byte
+		err  error
+	)
+	switch encoding {
+	case ExfilEncodingHex:
+		data, err = hex.DecodeString(encoded)
+	case ExfilEncodingBase32:
+		data, err = base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.ToUpper(encoded))
+	case ExfilEncodingBase64URL:
+		data, err = base64.RawURLEncoding.DecodeString(encoded)
+	default:
+		return nil, fmt.Errorf("unknown encoding %q", encoding)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s payload: %w", encoding, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"testing"
+)
+
+func TestReassembleExfil(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoding string
+		chunks   []ExfilChunk
+		want     string
+		missing  int
+		wantErr  bool
+	}{
+		{"hex", ExfilEncodingHex, []ExfilChunk{{0, "726f"}, {1, "6F74"}}, "root", 0, false},
+		{"base32 split mid symbol", ExfilEncodingBase32, []ExfilChunk{{1, "nbswy3"}, {2, "dp"}}, "hello", 0, false},
+		{"base64url", ExfilEncodingBase64URL, []ExfilChunk{{0, "aWQ9MCh"}, {1, "yb290KQ"}}, "id=0(root)", 0, false},
+		{"padding is ignored", ExfilEncodingBase64URL, []ExfilChunk{{0, "aGk="}}, "hi", 0, false},
+		{"missing chunks", ExfilEncodingHex, []ExfilChunk{{0, "72"}, {3, "74"}}, "", 2, true},
+		{"invalid data", ExfilEncodingHex, []ExfilChunk{{0, "zz"}}, "", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := ReassembleExfil(tt.encoding, tt.chunks)
+
+			if (payload.Error != "") != tt.wantErr {
+				t.Fatalf("unexpected error %q", payload.Error)
+			}
+			if payload.Text != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, payload.Text)
+			}
+			if len(payload.Missing) != tt.missing {
+				t.Errorf("expected %d missing chunks, got %v", tt.missing, payload.Missing)
+			}
+			if payload.Chunks != len(tt.chunks) {
+				t.Errorf("expected %d chunks, got %d", len(tt.chunks), payload.Chunks)
+			}
+		})
+	}
+}
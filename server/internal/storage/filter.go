@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"net/netip"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Filter selects which interactions a poll returns and consumes.
+// Empty fields match everything; a nil filter matches every interaction.
+type Filter struct {
+	Types   []InteractionType
+	QTypes  []string       // DNS query types, e.g. A, TXT
+	Methods []string       // HTTP methods, e.g. GET, POST
+	Path    *regexp.Regexp // matched against the HTTP path
+	Sources []netip.Prefix // source IPs and networks
+	Since   time.Time      // inclusive
+	Until   time.Time      // exclusive
+}
+
+// Match reports whether an interaction satisfies every condition of the filter
+func (f *Filter) Match(interaction *Interaction) bool {
+	if f == nil {
+		return true
+	}
+
+	if len(f.Types) > 0 && !containsType(f.Types, interaction.Type) {
+		return false
+	}
+
+	if !f.Since.IsZero() && interaction.Timestamp.Before(f.Since) {
+		return false
+	}
+
+	if !f.Until.IsZero() && !interaction.Timestamp.Before(f.Until) {
+		return false
+	}
+
+	if len(f.Sources) > 0 && !f.matchSource(interaction.SourceIP) {
+		return false
+	}
+
+	// DNS-specific conditions never match HTTP interactions and vice versa
+	if len(f.QTypes) > 0 && !containsFold(f.QTypes, dataString(interaction, "qtype")) {
+		return false
+	}
+
+	if len(f.Methods) > 0 && !containsFold(f.Methods, dataString(interaction, "method")) {
+		return false
+	}
+
+	if f.Path != nil {
+		if interaction.Type != InteractionTypeHTTP || !f.Path.MatchString(dataString(interaction, "path")) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// IsEmpty reports whether the filter matches every interaction
+func (f *Filter) IsEmpty() bool {
+	return f == nil || (len(f.Types) == 0 && len(f.QTypes) == 0 && len(f.Methods) == 0 &&
+		f.Path == nil && len(f.Sources) == 0 && f.Since.IsZero() && f.Until.IsZero())
+}
+
+// matchSource reports whether an IP falls within one of the filter sources
+func (f *Filter) matchSource(sourceIP string) bool {
+	addr, err := netip.ParseAddr(sourceIP)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+
+	for _, prefix := range f.Sources {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsType reports whether types includes t
+func containsType(types []InteractionType, t InteractionType) bool {
+	for _, candidate := range types {
+		if candidate == t {
+			return true
+		}
+	}
+	return false
+}
+
+// containsFold reports whether values includes s, ignoring case
+func containsFold(values []string, s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// dataString returns a string field of an interaction's data
+func dataString(interaction *Interaction, key string) string {
+	s, _ := interaction.Data[key].(string)
+	return s
+}
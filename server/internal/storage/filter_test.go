@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"net/netip"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestFilter_Match(t *testing.T) {
+	now := time.Now().UTC()
+
+	dnsA := DNSInteraction("dns-a", "10.1.2.3", "abc.example.com", "A")
+	dnsTXT := DNSInteraction("dns-txt", "2001:db8::1", "abc.example.com", "TXT")
+	httpGet := HTTPInteraction("http-get", "192.0.2.10", "GET", "/api/callback", nil, "")
+	httpPost := HTTPInteraction("http-post", "::ffff:192.0.2.20", "POST", "/upload", nil, "data")
+
+	tests := []struct {
+		name     string
+		filter   *Filter
+		expected []string
+	}{
+		{"nil filter", nil, []string{"dns-a", "dns-txt", "http-get", "http-post"}},
+		{"empty filter", &Filter{}, []string{"dns-a", "dns-txt", "http-get", "http-post"}},
+		{"type dns", &Filter{Types: []InteractionType{InteractionTypeDNS}}, []string{"dns-a", "dns-txt"}},
+		{"qtype case-insensitive", &Filter{QTypes: []string{"txt"}}, []string{"dns-txt"}},
+		{"method", &Filter{Methods: []string{"post"}}, []string{"http-post"}},
+		{"path regex", &Filter{Path: regexp.MustCompile(`^/api/`)}, []string{"http-get"}},
+		{"single source", &Filter{Sources: []netip.Prefix{netip.MustParsePrefix("10.1.2.3/32")}}, []string{"dns-a"}},
+		{"ipv4 network matches mapped address", &Filter{Sources: []netip.Prefix{netip.MustParsePrefix("192.0.2.0/24")}}, []string{"http-get", "http-post"}},
+		{"ipv6 network", &Filter{Sources: []netip.Prefix{netip.MustParsePrefix("2001:db8::/32")}}, []string{"dns-txt"}},
+		{"since in the future", &Filter{Since: now.Add(time.Hour)}, []string{}},
+		{"until in the past", &Filter{Until: now.Add(-time.Hour)}, []string{}},
+		{"time range", &Filter{Since: now.Add(-time.Hour), Until: now.Add(time.Hour)}, []string{"dns-a", "dns-txt", "http-get", "http-post"}},
+		{"conditions combine", &Filter{Types: []InteractionType{InteractionTypeHTTP}, Methods: []string{"GET"}}, []string{"http-get"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matched := make([]string, 0)
+			for _, interaction := range []*Interaction{dnsA, dnsTXT, httpGet, httpPost} {
+				if tt.filter.Match(interaction) {
+					matched = append(matched, interaction.ID)
+				}
+			}
+
+			if len(matched) != len(tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, matched)
+			}
+			for i := range matched {
+				if matched[i] != tt.expected[i] {
+					t.Errorf("expected %v, got %v", tt.expected, matched)
+				}
+			}
+		})
+	}
+}
@@ -1,16 +1,30 @@
 package storage
 
 import (
+	"errors"
+	"fmt"
+	"maps"
 	"runtime"
+	"sort"
 	"sync"
 	"time"
 )
 
+// ErrHookExists is returned when creating a hook whose ID is already taken
+var ErrHookExists = errors.New("hook already exists")
+
+// maxIDAttempts bounds how many generated IDs are tried before giving up
+const maxIDAttempts = 8
+
 // Manager defines the interface for storage operations
 type Manager interface {
 	// CreateHook creates a new hook and returns it
 	CreateHook(domain string) *Hook
 
+	// CreateHookWithOptions creates a new hook with a requested ID or prefix, a custom TTL,
+	// labels and description. It returns ErrHookExists if the ID is already taken.
+	CreateHookWithOptions(domain string, opts HookOptions) (*Hook, error)
+
 	// GetHook retrieves a hook by ID
 	GetHook(id string) (*Hook, bool)
 
@@ -23,6 +37,34 @@ type Manager interface {
 	// PollInteractionsBatch retrieves and deletes interactions for multiple hooks
 	PollInteractionsBatch(hookIDs []string) map[string]*PollResult
 
+	// PeekInteractions returns interactions after a cursor without deleting them,
+	// along with the cursor to use for the next call
+	PeekInteractions(hookID string, cursor uint64) ([]*Interaction, uint64, error)
+
+	// PendingCount returns the number of interactions queued for a hook
+	PendingCount(hookID string) int
+
+	// PollMatching retrieves and deletes the interactions of a hook that match the
+	// options' filter and fit its limits, leaving the others queued. It also reports
+	// whether matching interactions were left queued by a limit.
+	PollMatching(hookID string, opts PollOptions) ([]*Interaction, bool, error)
+
+	// PollMatchingBatch retrieves and deletes matching interactions for multiple hooks.
+	// The limits apply to the batch as a whole.
+	PollMatchingBatch(hookIDs []string, opts PollOptions) map[string]*PollResult
+
+	// PeekMatching returns matching interactions after a cursor without deleting them,
+	// along with the cursor to use for the next call and whether more are pending
+	PeekMatching(hookID string, cursor uint64, opts PollOptions) ([]*Interaction, uint64, bool, error)
+
+	// AckInteractions deletes interactions up to and including a cursor
+	AckInteractions(hookID string, cursor uint64) int
+
+	// Subscribe returns a channel receiving an event for every interaction stored
+	// for the given hooks, and a function to unsubscribe. Events are dropped when
+	// the channel buffer is full.
+	Subscribe(hookIDs []string, buffer int) (<-chan Event, func())
+
 	// GetAllHooks returns all registered hooks
 	GetAllHooks() []*Hook
 
@@ -35,6 +77,35 @@ type Manager interface {
 	// DeleteHook deletes a hook and all its interactions
 	DeleteHook(hookID string)
 
+	// RenewHook sets a new expiry time for a hook and returns the updated hook
+	RenewHook(hookID string, expiresAt time.Time) (*Hook, bool)
+
+	// SetDNSRecords replaces the custom DNS records of a hook and returns the updated hook
+	SetDNSRecords(hookID string, records []DNSRecord) (*Hook, bool)
+
+	// SetRebind sets or, when nil, clears the DNS rebinding strategy of a hook
+	// and returns the updated hook
+	SetRebind(hookID string, rebind *RebindConfig) (*Hook, bool)
+
+	// SetHTTPResponses replaces the HTTP responses of a hook and returns the updated hook
+	SetHTTPResponses(hookID string, responses []HTTPResponse) (*Hook, bool)
+
+	// SetExfil enables or, when nil, disables the decoding of payloads exfiltrated
+	// through DNS labels and returns the updated hook. Collected chunks are dropped.
+	SetExfil(hookID string, exfil *ExfilConfig) (*Hook, bool)
+
+	// AddExfilChunk collects a chunk of an exfiltrated payload. It reports false
+	// when the hook does not decode payloads, already holds a chunk with that
+	// sequence number (resolver retries) or is full.
+	AddExfilChunk(hookID string, chunk ExfilChunk) bool
+
+	// ExfilChunks returns the chunks collected for a hook, sorted by sequence number
+	ExfilChunks(hookID string) ([]ExfilChunk, bool)
+
+	// Orphans returns the store catching interactions for unknown hooks, or nil
+	// when orphans are not kept
+	Orphans() *OrphanStore
+
 	// Stats returns storage statistics
 	Stats() Stats
 }
@@ -48,135 +119,416 @@ type Stats struct {
 	Memory            MemoryStats
 }
 
-// MemoryManager implements in-memory storage
-type MemoryManager struct {
+// shardCount is the number of independently locked hook partitions
+const shardCount = 64
+
+// shard holds a partition of hooks, selected by hashing the hook ID.
+// Counters are kept per shard, under the shard lock, so writers never share them.
+type shard struct {
 	hooks        map[string]*Hook
 	interactions map[string][]*Interaction
+	cursors      map[string]uint64         // last sequence number assigned per hook
+	exfil        map[string]map[int]string // exfiltrated chunks by sequence number, per hook
+	counts       shardCounts
 	mu           sync.RWMutex
-	idGenerator  func() string
+}
+
+// shardCounts tracks the number of hooks and interactions held by a shard
+type shardCounts struct {
+	hooks int
+	total int
+	dns   int
+	http  int
+}
+
+// added records a stored interaction
+func (c *shardCounts) added(interaction *Interaction) {
+	c.total++
+	switch interaction.Type {
+	case InteractionTypeDNS:
+		c.dns++
+	case InteractionTypeHTTP:
+		c.http++
+	}
+}
+
+// removed records removed interactions
+func (c *shardCounts) removed(interactions []*Interaction) {
+	c.total -= len(interactions)
+	for _, interaction := range interactions {
+		switch interaction.Type {
+		case InteractionTypeDNS:
+			c.dns--
+		case InteractionTypeHTTP:
+			c.http--
+		}
+	}
+}
+
+// MemoryManager implements in-memory storage.
+// Hooks are spread across lock-striped shards so captures for different hooks
+// do not contend, and counters are maintained on write so Stats never walks
+// the stored interactions.
+type MemoryManager struct {
+	shards      [shardCount]*shard
+	broker      *broker
+	orphans     *OrphanStore // nil when interactions for unknown hooks are dropped
+	idGenerator func() string
 }
 
 // NewMemoryManager creates a new in-memory storage manager
 func NewMemoryManager(idGenerator func() string) *MemoryManager {
-	return &MemoryManager{
-		hooks:        make(map[string]*Hook),
-		interactions: make(map[string][]*Interaction),
-		idGenerator:  idGenerator,
+	m := &MemoryManager{
+		broker:      newBroker(),
+		idGenerator: idGenerator,
+	}
+
+	for i := range m.shards {
+		m.shards[i] = &shard{
+			hooks:        make(map[string]*Hook),
+			interactions: make(map[string][]*Interaction),
+			cursors:      make(map[string]uint64),
+			exfil:        make(map[string]map[int]string),
+		}
 	}
+
+	return m
+}
+
+// shardFor returns the shard owning a hook ID (FNV-1a hash)
+func (m *MemoryManager) shardFor(hookID string) *shard {
+	var h uint32 = 2166136261
+	for i := 0; i < len(hookID); i++ {
+		h ^= uint32(hookID[i])
+		h *= 16777619
+	}
+	return m.shards[h%shardCount]
 }
 
-// CreateHook creates a new hook
+// CreateHook creates a new hook with a generated ID.
+// It returns nil if no unused ID could be generated.
 func (m *MemoryManager) CreateHook(domain string) *Hook {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	hook, _ := m.CreateHookWithOptions(domain, HookOptions{})
+	return hook
+}
+
+// CreateHookWithOptions creates a new hook with the given options
+func (m *MemoryManager) CreateHookWithOptions(domain string, opts HookOptions) (*Hook, error) {
+	if opts.ID != "" {
+		return m.insertHook(newHook(opts.ID, domain, opts))
+	}
+
+	// Generated IDs are random, so a collision only needs another draw
+	for attempt := 0; attempt < maxIDAttempts; attempt++ {
+		id := m.idGenerator()
+		if opts.Prefix != "" {
+			id = opts.Prefix + "-" + id
+		}
+
+		hook, err := m.insertHook(newHook(id, domain, opts))
+		if !errors.Is(err, ErrHookExists) {
+			return hook, err
+		}
+	}
+
+	return nil, fmt.Errorf("failed to generate a unique hook ID: %w", ErrHookExists)
+}
+
+// insertHook stores a new hook, refusing to replace an existing one
+func (m *MemoryManager) insertHook(hook *Hook) (*Hook, error) {
+	sh := m.shardFor(hook.ID)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	if _, exists := sh.hooks[hook.ID]; exists {
+		return nil, ErrHookExists
+	}
+
+	sh.counts.hooks++
+	sh.hooks[hook.ID] = hook
+	sh.interactions[hook.ID] = make([]*Interaction, 0)
+	sh.cursors[hook.ID] = 0
+
+	return hook, nil
+}
 
-	id := m.idGenerator()
+// newHook builds a hook for the given ID and options
+func newHook(id, domain string, opts HookOptions) *Hook {
 	hook := &Hook{
-		ID:        id,
-		DNS:       id + "." + domain,
-		HTTP:      "http://" + id + "." + domain,
-		HTTPS:     "https://" + id + "." + domain,
-		CreatedAt: time.Now().UTC(),
+		ID:          id,
+		DNS:         id + "." + domain,
+		HTTP:        "http://" + id + "." + domain,
+		HTTPS:       "https://" + id + "." + domain,
+		CreatedAt:   time.Now().UTC(),
+		TTL:         int(opts.TTL / time.Second),
+		Description: opts.Description,
 	}
 
-	m.hooks[id] = hook
-	m.interactions[id] = make([]*Interaction, 0)
+	if len(opts.Labels) > 0 {
+		hook.Labels = make(map[string]string, len(opts.Labels))
+		for k, v := range opts.Labels {
+			hook.Labels[k] = v
+		}
+	}
 
 	return hook
 }
 
+// restoreHook inserts an existing hook, keeping its ID, creation time and cursor
+func (m *MemoryManager) restoreHook(hook *Hook, cursor uint64) {
+	sh := m.shardFor(hook.ID)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	if _, exists := sh.hooks[hook.ID]; !exists {
+		sh.counts.hooks++
+	}
+
+	sh.hooks[hook.ID] = hook
+	if _, exists := sh.interactions[hook.ID]; !exists {
+		sh.interactions[hook.ID] = make([]*Interaction, 0)
+	}
+	if cursor > sh.cursors[hook.ID] {
+		sh.cursors[hook.ID] = cursor
+	}
+}
+
+// restoreInteraction appends an existing interaction, keeping its sequence number
+func (m *MemoryManager) restoreInteraction(hookID string, interaction *Interaction) {
+	sh := m.shardFor(hookID)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	if _, exists := sh.hooks[hookID]; !exists {
+		return
+	}
+
+	sh.interactions[hookID] = append(sh.interactions[hookID], interaction)
+	if interaction.Seq > sh.cursors[hookID] {
+		sh.cursors[hookID] = interaction.Seq
+	}
+	sh.counts.added(interaction)
+}
+
+// cursor returns the last sequence number assigned for a hook
+func (m *MemoryManager) cursor(hookID string) uint64 {
+	sh := m.shardFor(hookID)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	return sh.cursors[hookID]
+}
+
 // GetHook retrieves a hook by ID
 func (m *MemoryManager) GetHook(id string) (*Hook, bool) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+	sh := m.shardFor(id)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
 
-	hook, exists := m.hooks[id]
+	hook, exists := sh.hooks[id]
 	return hook, exists
 }
 
 // AddInteraction adds an interaction to a hook
 func (m *MemoryManager) AddInteraction(hookID string, interaction *Interaction) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	sh := m.shardFor(hookID)
+	sh.mu.Lock()
 
 	// Check if hook exists
-	if _, exists := m.hooks[hookID]; !exists {
+	if _, exists := sh.hooks[hookID]; !exists {
+		sh.mu.Unlock()
+		if m.orphans != nil {
+			m.orphans.Add(hookID, interaction)
+		}
 		return nil // Silently ignore interactions for non-existent hooks
 	}
 
-	m.interactions[hookID] = append(m.interactions[hookID], interaction)
+	sh.cursors[hookID]++
+	interaction.Seq = sh.cursors[hookID]
+
+	sh.interactions[hookID] = append(sh.interactions[hookID], interaction)
+	sh.counts.added(interaction)
+	sh.mu.Unlock()
+
+	// Notify waiters outside the storage lock
+	m.broker.publish(hookID, interaction)
 	return nil
 }
 
 // PollInteractions retrieves and deletes interactions for a hook
 func (m *MemoryManager) PollInteractions(hookID string) ([]*Interaction, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	interactions, _, err := m.PollMatching(hookID, PollOptions{})
+	return interactions, err
+}
+
+// PollMatching retrieves and deletes the interactions of a hook that match the options
+func (m *MemoryManager) PollMatching(hookID string, opts PollOptions) ([]*Interaction, bool, error) {
+	sh := m.shardFor(hookID)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	interactions, hasMore := m.drain(sh, hookID, opts, newPollBudget(opts))
+	return interactions, hasMore, nil
+}
 
-	interactions, exists := m.interactions[hookID]
+// drain returns and removes a hook's matching interactions within the budget,
+// and reports whether matching interactions were left behind (caller must hold the shard lock)
+func (m *MemoryManager) drain(sh *shard, hookID string, opts PollOptions, budget *pollBudget) ([]*Interaction, bool) {
+	interactions, exists := sh.interactions[hookID]
 	if !exists {
-		return []*Interaction{}, nil
+		return []*Interaction{}, false
 	}
 
-	// Return a copy and clear the slice
-	result := make([]*Interaction, len(interactions))
-	copy(result, interactions)
+	if opts.unbounded() {
+		// Hand the slice over and start a fresh one
+		sh.interactions[hookID] = make([]*Interaction, 0)
+		sh.counts.removed(interactions)
+		return interactions, false
+	}
+
+	hasMore := false
+	matched := make([]*Interaction, 0)
+	kept := make([]*Interaction, 0, len(interactions))
+	for _, interaction := range interactions {
+		switch {
+		case !opts.Filter.Match(interaction):
+			kept = append(kept, interaction)
+		case budget.take(interaction):
+			matched = append(matched, interaction)
+		default:
+			kept = append(kept, interaction)
+			hasMore = true
+		}
+	}
 
-	m.interactions[hookID] = make([]*Interaction, 0)
+	sh.interactions[hookID] = kept
+	sh.counts.removed(matched)
 
-	return result, nil
+	return matched, hasMore
 }
 
 // PollInteractionsBatch retrieves and deletes interactions for multiple hooks
 func (m *MemoryManager) PollInteractionsBatch(hookIDs []string) map[string]*PollResult {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	return m.PollMatchingBatch(hookIDs, PollOptions{})
+}
 
+// PollMatchingBatch retrieves and deletes matching interactions for multiple hooks
+func (m *MemoryManager) PollMatchingBatch(hookIDs []string, opts PollOptions) map[string]*PollResult {
 	results := make(map[string]*PollResult, len(hookIDs))
+	budget := newPollBudget(opts)
 
 	for _, hookID := range hookIDs {
+		sh := m.shardFor(hookID)
+		sh.mu.Lock()
+
 		// Check if hook exists
-		if _, exists := m.hooks[hookID]; !exists {
+		if _, exists := sh.hooks[hookID]; !exists {
+			sh.mu.Unlock()
 			results[hookID] = &PollResult{
 				Error: "Hook not found",
 			}
 			continue
 		}
 
-		// Get interactions
-		interactions, exists := m.interactions[hookID]
-		if !exists || len(interactions) == 0 {
-			results[hookID] = &PollResult{
-				Interactions: make([]*Interaction, 0),
-			}
-			if exists {
-				m.interactions[hookID] = make([]*Interaction, 0)
-			}
-			continue
+		interactions, hasMore := m.drain(sh, hookID, opts, budget)
+		results[hookID] = &PollResult{
+			Interactions: interactions,
+			HasMore:      hasMore,
 		}
+		sh.mu.Unlock()
+	}
 
-		// Return a copy and clear the slice
-		result := make([]*Interaction, len(interactions))
-		copy(result, interactions)
+	return results
+}
 
-		m.interactions[hookID] = make([]*Interaction, 0)
+// PeekInteractions returns interactions after a cursor without deleting them
+func (m *MemoryManager) PeekInteractions(hookID string, cursor uint64) ([]*Interaction, uint64, error) {
+	interactions, next, _, err := m.PeekMatching(hookID, cursor, PollOptions{})
+	return interactions, next, err
+}
 
-		results[hookID] = &PollResult{
-			Interactions: result,
+// PendingCount returns the number of interactions queued for a hook
+func (m *MemoryManager) PendingCount(hookID string) int {
+	sh := m.shardFor(hookID)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	return len(sh.interactions[hookID])
+}
+
+// PeekMatching returns matching interactions after a cursor without deleting them.
+// The returned cursor moves past skipped non-matching interactions too, but never
+// past a matching interaction that did not fit the limits.
+func (m *MemoryManager) PeekMatching(hookID string, cursor uint64, opts PollOptions) ([]*Interaction, uint64, bool, error) {
+	sh := m.shardFor(hookID)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	interactions := sh.interactions[hookID]
+
+	// Interactions are ordered by sequence number, find the first one after the cursor
+	start := sort.Search(len(interactions), func(i int) bool {
+		return interactions[i].Seq > cursor
+	})
+
+	budget := newPollBudget(opts)
+	next := cursor
+	result := make([]*Interaction, 0)
+	for _, interaction := range interactions[start:] {
+		if opts.Filter.Match(interaction) {
+			if !budget.take(interaction) {
+				return result, next, true, nil
+			}
+			result = append(result, interaction)
 		}
+		next = interaction.Seq
 	}
 
-	return results
+	return result, next, false, nil
+}
+
+// AckInteractions deletes interactions up to and including a cursor
+func (m *MemoryManager) AckInteractions(hookID string, cursor uint64) int {
+	sh := m.shardFor(hookID)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	interactions, exists := sh.interactions[hookID]
+	if !exists {
+		return 0
+	}
+
+	end := sort.Search(len(interactions), func(i int) bool {
+		return interactions[i].Seq > cursor
+	})
+
+	if end == 0 {
+		return 0
+	}
+
+	remaining := make([]*Interaction, len(interactions)-end)
+	copy(remaining, interactions[end:])
+	sh.interactions[hookID] = remaining
+	sh.counts.removed(interactions[:end])
+
+	return end
+}
+
+// Subscribe registers for events on the given hooks
+func (m *MemoryManager) Subscribe(hookIDs []string, buffer int) (<-chan Event, func()) {
+	return m.broker.subscribe(hookIDs, buffer)
 }
 
 // GetAllHooks returns all registered hooks
 func (m *MemoryManager) GetAllHooks() []*Hook {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+	hooks := make([]*Hook, 0)
 
-	hooks := make([]*Hook, 0, len(m.hooks))
-	for _, hook := range m.hooks {
-		hooks = append(hooks, hook)
+	for _, sh := range m.shards {
+		sh.mu.RLock()
+		for _, hook := range sh.hooks {
+			hooks = append(hooks, hook)
+		}
+		sh.mu.RUnlock()
 	}
 
 	return hooks
@@ -184,13 +536,15 @@ func (m *MemoryManager) GetAllHooks() []*Hook {
 
 // GetAllInteractions returns all interactions
 func (m *MemoryManager) GetAllInteractions() map[string][]*Interaction {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-
 	// Return a shallow copy
-	result := make(map[string][]*Interaction, len(m.interactions))
-	for k, v := range m.interactions {
-		result[k] = v
+	result := make(map[string][]*Interaction)
+
+	for _, sh := range m.shards {
+		sh.mu.RLock()
+		for k, v := range sh.interactions {
+			result[k] = v
+		}
+		sh.mu.RUnlock()
 	}
 
 	return result
@@ -198,10 +552,11 @@ func (m *MemoryManager) GetAllInteractions() map[string][]*Interaction {
 
 // DeleteInteractions deletes specific interactions from a hook
 func (m *MemoryManager) DeleteInteractions(hookID string, interactionIDs []string) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	sh := m.shardFor(hookID)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
 
-	interactions, exists := m.interactions[hookID]
+	interactions, exists := sh.interactions[hookID]
 	if !exists {
 		return
 	}
@@ -214,43 +569,218 @@ func (m *MemoryManager) DeleteInteractions(hookID string, interactionIDs []strin
 
 	// Filter out interactions
 	filtered := make([]*Interaction, 0)
+	removed := make([]*Interaction, 0, len(interactionIDs))
 	for _, interaction := range interactions {
-		if !toDelete[interaction.ID] {
+		if toDelete[interaction.ID] {
+			removed = append(removed, interaction)
+		} else {
 			filtered = append(filtered, interaction)
 		}
 	}
 
-	m.interactions[hookID] = filtered
+	sh.interactions[hookID] = filtered
+	sh.counts.removed(removed)
 }
 
 // DeleteHook deletes a hook and all its interactions
 func (m *MemoryManager) DeleteHook(hookID string) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	sh := m.shardFor(hookID)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	if _, exists := sh.hooks[hookID]; !exists {
+		return
+	}
 
-	delete(m.hooks, hookID)
-	delete(m.interactions, hookID)
+	sh.counts.removed(sh.interactions[hookID])
+	sh.counts.hooks--
+
+	delete(sh.hooks, hookID)
+	delete(sh.interactions, hookID)
+	delete(sh.cursors, hookID)
+	delete(sh.exfil, hookID)
 }
 
-// Stats returns storage statistics
-func (m *MemoryManager) Stats() Stats {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+// RenewHook sets a new expiry time for a hook
+func (m *MemoryManager) RenewHook(hookID string, expiresAt time.Time) (*Hook, bool) {
+	sh := m.shardFor(hookID)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
 
-	stats := Stats{
-		HooksActive: len(m.hooks),
+	hook, exists := sh.hooks[hookID]
+	if !exists {
+		return nil, false
 	}
 
-	for _, interactions := range m.interactions {
-		stats.InteractionsTotal += len(interactions)
-		for _, interaction := range interactions {
-			switch interaction.Type {
-			case InteractionTypeDNS:
-				stats.InteractionsDNS++
-			case InteractionTypeHTTP:
-				stats.InteractionsHTTP++
-			}
-		}
+	// Copy on write: callers may still be reading the previous hook
+	renewed := *hook
+	renewed.ExpiresAt = expiresAt.UTC()
+	sh.hooks[hookID] = &renewed
+
+	return &renewed, true
+}
+
+// SetDNSRecords replaces the custom DNS records of a hook
+func (m *MemoryManager) SetDNSRecords(hookID string, records []DNSRecord) (*Hook, bool) {
+	sh := m.shardFor(hookID)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	hook, exists := sh.hooks[hookID]
+	if !exists {
+		return nil, false
+	}
+
+	// Copy on write: the DNS server may still be reading the previous records
+	updated := *hook
+	updated.DNSRecords = nil
+	if len(records) > 0 {
+		updated.DNSRecords = append([]DNSRecord(nil), records...)
+	}
+	sh.hooks[hookID] = &updated
+
+	return &updated, true
+}
+
+// SetRebind sets or clears the DNS rebinding strategy of a hook
+func (m *MemoryManager) SetRebind(hookID string, rebind *RebindConfig) (*Hook, bool) {
+	sh := m.shardFor(hookID)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	hook, exists := sh.hooks[hookID]
+	if !exists {
+		return nil, false
+	}
+
+	// Copy on write: the DNS server may still be reading the previous strategy
+	updated := *hook
+	updated.Rebind = nil
+	if rebind != nil {
+		config := *rebind
+		config.Answers = append([]string(nil), rebind.Answers...)
+		updated.Rebind = &config
+	}
+	sh.hooks[hookID] = &updated
+
+	return &updated, true
+}
+
+// SetHTTPResponses replaces the HTTP responses of a hook
+func (m *MemoryManager) SetHTTPResponses(hookID string, responses []HTTPResponse) (*Hook, bool) {
+	sh := m.shardFor(hookID)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	hook, exists := sh.hooks[hookID]
+	if !exists {
+		return nil, false
+	}
+
+	// Copy on write: the capture handler may still be reading the previous responses
+	updated := *hook
+	updated.HTTPResponses = nil
+	for _, response := range responses {
+		response.Headers = maps.Clone(response.Headers)
+		updated.HTTPResponses = append(updated.HTTPResponses, response)
+	}
+	sh.hooks[hookID] = &updated
+
+	return &updated, true
+}
+
+// SetExfil enables or disables exfiltration decoding, dropping collected chunks
+func (m *MemoryManager) SetExfil(hookID string, exfil *ExfilConfig) (*Hook, bool) {
+	sh := m.shardFor(hookID)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	hook, exists := sh.hooks[hookID]
+	if !exists {
+		return nil, false
+	}
+
+	// Copy on write: the DNS server may still be reading the previous config
+	updated := *hook
+	updated.Exfil = nil
+	if exfil != nil {
+		config := *exfil
+		updated.Exfil = &config
+	}
+	sh.hooks[hookID] = &updated
+	delete(sh.exfil, hookID)
+
+	return &updated, true
+}
+
+// AddExfilChunk collects a chunk, keeping the first one received per sequence number
+func (m *MemoryManager) AddExfilChunk(hookID string, chunk ExfilChunk) bool {
+	sh := m.shardFor(hookID)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	hook, exists := sh.hooks[hookID]
+	if !exists || hook.Exfil == nil {
+		return false
+	}
+
+	chunks := sh.exfil[hookID]
+	if chunks == nil {
+		chunks = make(map[int]string)
+		sh.exfil[hookID] = chunks
+	}
+
+	if _, seen := chunks[chunk.Seq]; seen || len(chunks) >= MaxExfilChunks {
+		return false
+	}
+
+	chunks[chunk.Seq] = chunk.Data
+	return true
+}
+
+// ExfilChunks returns the chunks collected for a hook, sorted by sequence number
+func (m *MemoryManager) ExfilChunks(hookID string) ([]ExfilChunk, bool) {
+	sh := m.shardFor(hookID)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	if _, exists := sh.hooks[hookID]; !exists {
+		return nil, false
+	}
+
+	chunks := make([]ExfilChunk, 0, len(sh.exfil[hookID]))
+	for seq, data := range sh.exfil[hookID] {
+		chunks = append(chunks, ExfilChunk{Seq: seq, Data: data})
+	}
+	sort.Slice(chunks, func(i, j int) bool {
+		return chunks[i].Seq < chunks[j].Seq
+	})
+
+	return chunks, true
+}
+
+// SetOrphans makes interactions for unknown hooks go to an orphan store. It
+// must be called before the manager is used.
+func (m *MemoryManager) SetOrphans(orphans *OrphanStore) {
+	m.orphans = orphans
+}
+
+// Orphans returns the orphan store, or nil when orphans are not kept
+func (m *MemoryManager) Orphans() *OrphanStore {
+	return m.orphans
+}
+
+// Stats returns storage statistics
+func (m *MemoryManager) Stats() Stats {
+	var stats Stats
+
+	for _, sh := range m.shards {
+		sh.mu.RLock()
+		stats.HooksActive += sh.counts.hooks
+		stats.InteractionsTotal += sh.counts.total
+		stats.InteractionsDNS += sh.counts.dns
+		stats.InteractionsHTTP += sh.counts.http
+		sh.mu.RUnlock()
 	}
 
 	// Get detailed memory usage from Go runtime
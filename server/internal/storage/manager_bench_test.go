@@ -0,0 +1,188 @@
+package storage
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// globalLockManager reproduces the previous MemoryManager design (one
+// RWMutex for everything, Stats walking every interaction) as a baseline
+type globalLockManager struct {
+	hooks        map[string]*Hook
+	interactions map[string][]*Interaction
+	mu           sync.RWMutex
+}
+
+func newGlobalLockManager() *globalLockManager {
+	return &globalLockManager{
+		hooks:        make(map[string]*Hook),
+		interactions: make(map[string][]*Interaction),
+	}
+}
+
+func (m *globalLockManager) createHook(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.hooks[id] = &Hook{ID: id}
+	m.interactions[id] = make([]*Interaction, 0)
+}
+
+func (m *globalLockManager) AddInteraction(hookID string, interaction *Interaction) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.hooks[hookID]; !exists {
+		return nil
+	}
+
+	m.interactions[hookID] = append(m.interactions[hookID], interaction)
+	return nil
+}
+
+func (m *globalLockManager) PollInteractions(hookID string) ([]*Interaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	interactions := m.interactions[hookID]
+	result := make([]*Interaction, len(interactions))
+	copy(result, interactions)
+	m.interactions[hookID] = make([]*Interaction, 0)
+
+	return result, nil
+}
+
+func (m *globalLockManager) Stats() Stats {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stats := Stats{HooksActive: len(m.hooks)}
+	for _, interactions := range m.interactions {
+		stats.InteractionsTotal += len(interactions)
+		for _, interaction := range interactions {
+			switch interaction.Type {
+			case InteractionTypeDNS:
+				stats.InteractionsDNS++
+			case InteractionTypeHTTP:
+				stats.InteractionsHTTP++
+			}
+		}
+	}
+
+	return stats
+}
+
+// benchStore is the subset of operations exercised by the benchmarks
+type benchStore interface {
+	AddInteraction(hookID string, interaction *Interaction) error
+	PollInteractions(hookID string) ([]*Interaction, error)
+}
+
+const benchHooks = 1024
+
+func benchHookIDs() []string {
+	ids := make([]string, benchHooks)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("hook%04d", i)
+	}
+	return ids
+}
+
+func newBenchStores(ids []string) map[string]benchStore {
+	global := newGlobalLockManager()
+
+	next := 0
+	sharded := NewMemoryManager(func() string {
+		id := ids[next]
+		next++
+		return id
+	})
+
+	for _, id := range ids {
+		global.createHook(id)
+		sharded.CreateHook("example.com")
+	}
+
+	return map[string]benchStore{
+		"global":  global,
+		"sharded": sharded,
+	}
+}
+
+// BenchmarkAddInteraction_Parallel measures concurrent capture throughput
+func BenchmarkAddInteraction_Parallel(b *testing.B) {
+	ids := benchHookIDs()
+
+	for _, name := range []string{"global", "sharded"} {
+		store := newBenchStores(ids)[name]
+
+		b.Run(name, func(b *testing.B) {
+			var counter atomic.Uint64
+			interaction := DNSInteraction("int", "1.2.3.4", "test.com", "A")
+
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					n := counter.Add(1)
+					hookID := ids[n%benchHooks]
+					store.AddInteraction(hookID, &Interaction{ID: interaction.ID, Type: interaction.Type})
+
+					// Keep slices short so the benchmark measures locking, not growth
+					if n%64 == 0 {
+						store.PollInteractions(hookID)
+					}
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkMixedWorkload_Parallel interleaves capture with polls and stats reads
+func BenchmarkMixedWorkload_Parallel(b *testing.B) {
+	ids := benchHookIDs()
+
+	for _, name := range []string{"global", "sharded"} {
+		store := newBenchStores(ids)[name]
+		stats := store.(interface{ Stats() Stats })
+
+		b.Run(name, func(b *testing.B) {
+			var counter atomic.Uint64
+
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					n := counter.Add(1)
+					hookID := ids[n%benchHooks]
+
+					switch {
+					case n%100 == 0:
+						stats.Stats()
+					case n%10 == 0:
+						store.PollInteractions(hookID)
+					default:
+						store.AddInteraction(hookID, HTTPInteraction("int", "1.2.3.4", "GET", "/", nil, ""))
+					}
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkStats measures Stats with a large number of pending interactions
+func BenchmarkStats(b *testing.B) {
+	ids := benchHookIDs()
+
+	for _, name := range []string{"global", "sharded"} {
+		store := newBenchStores(ids)[name]
+		for i := 0; i < 100*benchHooks; i++ {
+			store.AddInteraction(ids[i%benchHooks], DNSInteraction("int", "1.2.3.4", "test.com", "A"))
+		}
+		stats := store.(interface{ Stats() Stats })
+
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				stats.Stats()
+			}
+		})
+	}
+}
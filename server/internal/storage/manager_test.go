@@ -1,7 +1,12 @@
 package storage
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestMemoryManager_CreateHook(t *testing.T) {
@@ -314,3 +319,591 @@ func TestMemoryManager_PollInteractions_NonExistentHook(t *testing.T) {
 		t.Errorf("expected 0 interactions for non-existent hook, got %d", len(interactions))
 	}
 }
+
+func TestMemoryManager_PeekInteractions(t *testing.T) {
+	manager := NewMemoryManager(func() string { return "test123" })
+	manager.CreateHook("example.com")
+
+	manager.AddInteraction("test123", DNSInteraction("int1", "1.2.3.4", "test.com", "A"))
+	manager.AddInteraction("test123", DNSInteraction("int2", "1.2.3.4", "test.com", "A"))
+
+	interactions, cursor, err := manager.PeekInteractions("test123", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(interactions) != 2 {
+		t.Fatalf("expected 2 interactions, got %d", len(interactions))
+	}
+
+	if cursor != 2 {
+		t.Errorf("expected cursor 2, got %d", cursor)
+	}
+
+	// Peeking must not delete
+	if stats := manager.Stats(); stats.InteractionsTotal != 2 {
+		t.Errorf("expected 2 interactions after peek, got %d", stats.InteractionsTotal)
+	}
+
+	// Only interactions after the cursor are returned
+	manager.AddInteraction("test123", DNSInteraction("int3", "1.2.3.4", "test.com", "A"))
+
+	interactions, cursor, _ = manager.PeekInteractions("test123", cursor)
+	if len(interactions) != 1 || interactions[0].ID != "int3" {
+		t.Fatalf("expected only int3 after cursor, got %v", interactions)
+	}
+
+	if cursor != 3 {
+		t.Errorf("expected cursor 3, got %d", cursor)
+	}
+
+	// No new interactions keeps the cursor unchanged
+	interactions, next, _ := manager.PeekInteractions("test123", cursor)
+	if len(interactions) != 0 || next != cursor {
+		t.Errorf("expected no interactions and cursor %d, got %d and %d", cursor, len(interactions), next)
+	}
+}
+
+func TestMemoryManager_PendingCount(t *testing.T) {
+	manager := NewMemoryManager(func() string { return "test123" })
+	manager.CreateHook("example.com")
+
+	for _, id := range []string{"int1", "int2", "int3"} {
+		manager.AddInteraction("test123", DNSInteraction(id, "1.2.3.4", "test.com", "A"))
+	}
+
+	if pending := manager.PendingCount("test123"); pending != 3 {
+		t.Errorf("expected 3 pending interactions, got %d", pending)
+	}
+
+	manager.AckInteractions("test123", 2)
+	if pending := manager.PendingCount("test123"); pending != 1 {
+		t.Errorf("expected 1 pending interaction after the ack, got %d", pending)
+	}
+
+	if pending := manager.PendingCount("nonexistent"); pending != 0 {
+		t.Errorf("expected 0 pending for non-existent hook, got %d", pending)
+	}
+}
+
+func TestMemoryManager_AckInteractions(t *testing.T) {
+	manager := NewMemoryManager(func() string { return "test123" })
+	manager.CreateHook("example.com")
+
+	for _, id := range []string{"int1", "int2", "int3"} {
+		manager.AddInteraction("test123", DNSInteraction(id, "1.2.3.4", "test.com", "A"))
+	}
+
+	if acked := manager.AckInteractions("test123", 2); acked != 2 {
+		t.Errorf("expected 2 acknowledged interactions, got %d", acked)
+	}
+
+	interactions, _, _ := manager.PeekInteractions("test123", 0)
+	if len(interactions) != 1 || interactions[0].ID != "int3" {
+		t.Fatalf("expected only int3 to remain, got %v", interactions)
+	}
+
+	// Sequence numbers keep increasing after an ack
+	manager.AddInteraction("test123", DNSInteraction("int4", "1.2.3.4", "test.com", "A"))
+	interactions, _, _ = manager.PeekInteractions("test123", 3)
+	if len(interactions) != 1 || interactions[0].Seq != 4 {
+		t.Errorf("expected int4 with seq 4, got %v", interactions)
+	}
+
+	if acked := manager.AckInteractions("nonexistent", 10); acked != 0 {
+		t.Errorf("expected 0 acknowledged for non-existent hook, got %d", acked)
+	}
+}
+
+func TestMemoryManager_Subscribe(t *testing.T) {
+	counter := 0
+	manager := NewMemoryManager(func() string {
+		counter++
+		return "test-id-" + string(rune('0'+counter))
+	})
+
+	hook1 := manager.CreateHook("example.com")
+	hook2 := manager.CreateHook("example.com")
+
+	events, unsubscribe := manager.Subscribe([]string{hook1.ID}, 1)
+
+	// Interactions for other hooks are not delivered
+	manager.AddInteraction(hook2.ID, DNSInteraction("int1", "1.2.3.4", "test.com", "A"))
+	select {
+	case event := <-events:
+		t.Fatalf("unexpected event for hook %s", event.HookID)
+	default:
+	}
+
+	manager.AddInteraction(hook1.ID, DNSInteraction("int2", "1.2.3.4", "test.com", "A"))
+	select {
+	case event := <-events:
+		if event.HookID != hook1.ID || event.Interaction.ID != "int2" {
+			t.Errorf("unexpected event: %+v", event)
+		}
+	default:
+		t.Fatal("expected an event")
+	}
+
+	// A full buffer drops events instead of blocking the writer
+	manager.AddInteraction(hook1.ID, DNSInteraction("int3", "1.2.3.4", "test.com", "A"))
+	manager.AddInteraction(hook1.ID, DNSInteraction("int4", "1.2.3.4", "test.com", "A"))
+	if len(events) != 1 {
+		t.Errorf("expected 1 buffered event, got %d", len(events))
+	}
+	<-events
+
+	// No events after unsubscribing
+	unsubscribe()
+	unsubscribe()
+	manager.AddInteraction(hook1.ID, DNSInteraction("int5", "1.2.3.4", "test.com", "A"))
+	if len(events) != 0 {
+		t.Errorf("expected no events after unsubscribe, got %d", len(events))
+	}
+}
+
+func TestMemoryManager_StatsCounters(t *testing.T) {
+	counter := 0
+	manager := NewMemoryManager(func() string {
+		counter++
+		return "test-id-" + string(rune('0'+counter))
+	})
+
+	hook1 := manager.CreateHook("example.com")
+	hook2 := manager.CreateHook("example.com")
+
+	manager.AddInteraction(hook1.ID, DNSInteraction("int1", "1.2.3.4", "test.com", "A"))
+	manager.AddInteraction(hook1.ID, HTTPInteraction("int2", "1.2.3.4", "GET", "/", nil, ""))
+	manager.AddInteraction(hook1.ID, DNSInteraction("int3", "1.2.3.4", "test.com", "A"))
+	manager.AddInteraction(hook2.ID, HTTPInteraction("int4", "1.2.3.4", "GET", "/", nil, ""))
+	manager.AddInteraction(hook2.ID, DNSInteraction("int5", "1.2.3.4", "test.com", "A"))
+
+	// Every removal path must keep the counters in sync
+	manager.AckInteractions(hook1.ID, 1)
+	manager.DeleteInteractions(hook1.ID, []string{"int2"})
+	manager.PollInteractionsBatch([]string{hook2.ID})
+
+	stats := manager.Stats()
+	if stats.HooksActive != 2 || stats.InteractionsTotal != 1 || stats.InteractionsDNS != 1 || stats.InteractionsHTTP != 0 {
+		t.Errorf("unexpected stats after removals: %+v", stats)
+	}
+
+	manager.DeleteHook(hook1.ID)
+	manager.DeleteHook(hook1.ID)
+
+	stats = manager.Stats()
+	if stats.HooksActive != 1 || stats.InteractionsTotal != 0 || stats.InteractionsDNS != 0 {
+		t.Errorf("unexpected stats after hook deletion: %+v", stats)
+	}
+}
+
+func TestMemoryManager_ConcurrentAccess(t *testing.T) {
+	var mu sync.Mutex
+	counter := 0
+	manager := NewMemoryManager(func() string {
+		mu.Lock()
+		defer mu.Unlock()
+		counter++
+		return fmt.Sprintf("hook%d", counter)
+	})
+
+	hooks := make([]*Hook, 16)
+	for i := range hooks {
+		hooks[i] = manager.CreateHook("example.com")
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				hook := hooks[(w+i)%len(hooks)]
+				manager.AddInteraction(hook.ID, DNSInteraction(fmt.Sprintf("int-%d-%d", w, i), "1.2.3.4", "test.com", "A"))
+				manager.Stats()
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	stats := manager.Stats()
+	if stats.InteractionsTotal != 800 {
+		t.Fatalf("expected 800 interactions, got %d", stats.InteractionsTotal)
+	}
+
+	total := 0
+	for _, hook := range hooks {
+		interactions, _ := manager.PollInteractions(hook.ID)
+		total += len(interactions)
+
+		// Sequence numbers are unique and ordered per hook
+		for i := 1; i < len(interactions); i++ {
+			if interactions[i].Seq <= interactions[i-1].Seq {
+				t.Fatalf("sequence numbers out of order for %s", hook.ID)
+			}
+		}
+	}
+
+	if total != 800 || manager.Stats().InteractionsTotal != 0 {
+		t.Errorf("expected to poll 800 interactions and leave none, got %d", total)
+	}
+}
+
+func TestMemoryManager_RenewHook(t *testing.T) {
+	idGen := func() string { return "test123" }
+	manager := NewMemoryManager(idGen)
+	original := manager.CreateHook("example.com")
+
+	expiresAt := time.Now().Add(48 * time.Hour)
+	renewed, exists := manager.RenewHook("test123", expiresAt)
+	if !exists {
+		t.Fatal("expected hook to exist")
+	}
+
+	if !renewed.ExpiresAt.Equal(expiresAt) {
+		t.Errorf("expected expiry %v, got %v", expiresAt, renewed.ExpiresAt)
+	}
+
+	// The previously returned hook is left untouched
+	if !original.ExpiresAt.IsZero() {
+		t.Errorf("expected original hook to be unchanged, got expiry %v", original.ExpiresAt)
+	}
+
+	stored, _ := manager.GetHook("test123")
+	if !stored.ExpiresAt.Equal(expiresAt) {
+		t.Errorf("expected stored expiry %v, got %v", expiresAt, stored.ExpiresAt)
+	}
+
+	if _, exists := manager.RenewHook("nonexistent", expiresAt); exists {
+		t.Error("expected renewing a nonexistent hook to fail")
+	}
+}
+
+func TestMemoryManager_SetDNSRecords(t *testing.T) {
+	idGen := func() string { return "test123" }
+	manager := NewMemoryManager(idGen)
+	original := manager.CreateHook("example.com")
+
+	records := []DNSRecord{
+		{Type: "A", Value: "10.0.0.1"},
+		{Name: "www", Type: "CNAME", Value: "target.example.net."},
+	}
+
+	updated, exists := manager.SetDNSRecords("test123", records)
+	if !exists {
+		t.Fatal("expected hook to exist")
+	}
+
+	if len(updated.DNSRecords) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(updated.DNSRecords))
+	}
+
+	// The caller's slice is copied and the previously returned hook is left untouched
+	records[0].Value = "10.0.0.2"
+	if updated.DNSRecords[0].Value != "10.0.0.1" {
+		t.Errorf("expected stored records to be copied, got %s", updated.DNSRecords[0].Value)
+	}
+
+	if len(original.DNSRecords) != 0 {
+		t.Errorf("expected original hook to be unchanged, got %v", original.DNSRecords)
+	}
+
+	cleared, _ := manager.SetDNSRecords("test123", nil)
+	if cleared.DNSRecords != nil {
+		t.Errorf("expected records to be cleared, got %v", cleared.DNSRecords)
+	}
+
+	if _, exists := manager.SetDNSRecords("nonexistent", records); exists {
+		t.Error("expected setting records on a nonexistent hook to fail")
+	}
+}
+
+func TestMemoryManager_SetRebind(t *testing.T) {
+	idGen := func() string { return "test123" }
+	manager := NewMemoryManager(idGen)
+	original := manager.CreateHook("example.com")
+
+	rebind := &RebindConfig{Mode: RebindModeCount, Answers: []string{"self", "127.0.0.1"}, Count: 1}
+
+	updated, exists := manager.SetRebind("test123", rebind)
+	if !exists {
+		t.Fatal("expected hook to exist")
+	}
+
+	if updated.Rebind == nil || updated.Rebind.Mode != RebindModeCount {
+		t.Fatalf("expected count strategy, got %+v", updated.Rebind)
+	}
+
+	// The caller's config is copied and the previously returned hook is left untouched
+	rebind.Answers[1] = "10.0.0.1"
+	if updated.Rebind.Answers[1] != "127.0.0.1" {
+		t.Errorf("expected stored answers to be copied, got %s", updated.Rebind.Answers[1])
+	}
+
+	if original.Rebind != nil {
+		t.Errorf("expected original hook to be unchanged, got %+v", original.Rebind)
+	}
+
+	cleared, _ := manager.SetRebind("test123", nil)
+	if cleared.Rebind != nil {
+		t.Errorf("expected strategy to be cleared, got %+v", cleared.Rebind)
+	}
+
+	if _, exists := manager.SetRebind("nonexistent", rebind); exists {
+		t.Error("expected setting a strategy on a nonexistent hook to fail")
+	}
+}
+
+func TestMemoryManager_SetHTTPResponses(t *testing.T) {
+	idGen := func() string { return "test123" }
+	manager := NewMemoryManager(idGen)
+	original := manager.CreateHook("example.com")
+
+	responses := []HTTPResponse{{Path: "/redirect", Status: 302, Headers: map[string]string{"Location": "http://127.0.0.1/"}}}
+
+	updated, exists := manager.SetHTTPResponses("test123", responses)
+	if !exists {
+		t.Fatal("expected hook to exist")
+	}
+
+	if len(updated.HTTPResponses) != 1 || updated.HTTPResponses[0].Status != 302 {
+		t.Fatalf("expected the redirect, got %+v", updated.HTTPResponses)
+	}
+
+	// The caller's headers are copied and the previously returned hook is left untouched
+	responses[0].Headers["Location"] = "http://10.0.0.1/"
+	if updated.HTTPResponses[0].Headers["Location"] != "http://127.0.0.1/" {
+		t.Errorf("expected stored headers to be copied, got %s", updated.HTTPResponses[0].Headers["Location"])
+	}
+
+	if original.HTTPResponses != nil {
+		t.Errorf("expected original hook to be unchanged, got %+v", original.HTTPResponses)
+	}
+
+	cleared, _ := manager.SetHTTPResponses("test123", nil)
+	if cleared.HTTPResponses != nil {
+		t.Errorf("expected responses to be cleared, got %+v", cleared.HTTPResponses)
+	}
+
+	if _, exists := manager.SetHTTPResponses("nonexistent", responses); exists {
+		t.Error("expected setting responses on a nonexistent hook to fail")
+	}
+}
+
+func TestMemoryManager_ExfilChunks(t *testing.T) {
+	idGen := func() string { return "test123" }
+	manager := NewMemoryManager(idGen)
+	manager.CreateHook("example.com")
+
+	if manager.AddExfilChunk("test123", ExfilChunk{Seq: 0, Data: "72"}) {
+		t.Error("expected chunks to be ignored while decoding is disabled")
+	}
+
+	manager.SetExfil("test123", &ExfilConfig{Encoding: ExfilEncodingHex})
+
+	if !manager.AddExfilChunk("test123", ExfilChunk{Seq: 1, Data: "6f"}) {
+		t.Fatal("expected chunk to be added")
+	}
+	manager.AddExfilChunk("test123", ExfilChunk{Seq: 0, Data: "72"})
+
+	// Resolver retries repeat a sequence number, the first chunk is kept
+	if manager.AddExfilChunk("test123", ExfilChunk{Seq: 1, Data: "ff"}) {
+		t.Error("expected duplicate sequence number to be ignored")
+	}
+
+	chunks, exists := manager.ExfilChunks("test123")
+	if !exists {
+		t.Fatal("expected hook to exist")
+	}
+	if len(chunks) != 2 || chunks[0].Data != "72" || chunks[1].Data != "6f" {
+		t.Errorf("expected chunks sorted by sequence number, got %v", chunks)
+	}
+
+	// Setting the config again starts a new payload
+	manager.SetExfil("test123", &ExfilConfig{Encoding: ExfilEncodingHex})
+	if chunks, _ := manager.ExfilChunks("test123"); len(chunks) != 0 {
+		t.Errorf("expected chunks to be dropped, got %v", chunks)
+	}
+
+	if _, exists := manager.ExfilChunks("nonexistent"); exists {
+		t.Error("expected nonexistent hook to be reported")
+	}
+}
+
+func TestMemoryManager_Orphans(t *testing.T) {
+	idGen := func() string { return "test123" }
+	manager := NewMemoryManager(idGen)
+
+	if manager.Orphans() != nil {
+		t.Fatal("expected no orphan store by default")
+	}
+
+	manager.SetOrphans(NewOrphanStore(time.Hour, 10, 1<<20))
+	manager.CreateHook("example.com")
+
+	manager.AddInteraction("test123", DNSInteraction("int1", "1.2.3.4", "test123.example.com", "A"))
+	if err := manager.AddInteraction("expired", DNSInteraction("int2", "1.2.3.4", "expired.example.com", "A")); err != nil {
+		t.Fatalf("expected no error for unknown hook, got %v", err)
+	}
+
+	orphans := manager.Orphans().Search(nil, nil, 0)
+	if len(orphans) != 1 || orphans[0].HookID != "expired" || orphans[0].ID != "int2" {
+		t.Errorf("expected only the interaction for the unknown hook, got %v", orphans)
+	}
+}
+
+func TestMemoryManager_CreateHookWithOptions(t *testing.T) {
+	idGen := func() string { return "test123" }
+	manager := NewMemoryManager(idGen)
+
+	labels := map[string]string{"project": "acme"}
+	hook, _ := manager.CreateHookWithOptions("example.com", HookOptions{
+		TTL:         90 * time.Minute,
+		Labels:      labels,
+		Description: "login form",
+	})
+
+	if hook.TTL != 5400 {
+		t.Errorf("expected ttl 5400, got %d", hook.TTL)
+	}
+
+	if hook.Description != "login form" {
+		t.Errorf("expected description, got %q", hook.Description)
+	}
+
+	// Labels are copied, not shared with the caller
+	labels["project"] = "changed"
+	if hook.Labels["project"] != "acme" {
+		t.Errorf("expected label acme, got %q", hook.Labels["project"])
+	}
+}
+
+func TestMemoryManager_CreateHookWithOptions_IDs(t *testing.T) {
+	counter := 0
+	idGen := func() string {
+		counter++
+		return fmt.Sprintf("gen%d", counter)
+	}
+	manager := NewMemoryManager(idGen)
+
+	t.Run("requested ID", func(t *testing.T) {
+		hook, err := manager.CreateHookWithOptions("example.com", HookOptions{ID: "acme-login-01"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if hook.ID != "acme-login-01" || hook.DNS != "acme-login-01.example.com" {
+			t.Errorf("unexpected hook: %+v", hook)
+		}
+	})
+
+	t.Run("requested ID already taken", func(t *testing.T) {
+		manager.AddInteraction("acme-login-01", DNSInteraction("int1", "1.2.3.4", "test.com", "A"))
+
+		if _, err := manager.CreateHookWithOptions("example.com", HookOptions{ID: "acme-login-01"}); !errors.Is(err, ErrHookExists) {
+			t.Errorf("expected ErrHookExists, got %v", err)
+		}
+
+		// The existing hook keeps its interactions
+		if stats := manager.Stats(); stats.HooksActive != 1 || stats.InteractionsTotal != 1 {
+			t.Errorf("expected existing hook to be untouched, got %+v", stats)
+		}
+	})
+
+	t.Run("prefix", func(t *testing.T) {
+		hook, err := manager.CreateHookWithOptions("example.com", HookOptions{Prefix: "acme"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !strings.HasPrefix(hook.ID, "acme-gen") {
+			t.Errorf("expected prefixed ID, got %s", hook.ID)
+		}
+	})
+}
+
+func TestMemoryManager_CreateHook_Collision(t *testing.T) {
+	ids := []string{"dup", "dup", "unique"}
+	idGen := func() string {
+		id := ids[0]
+		if len(ids) > 1 {
+			ids = ids[1:]
+		}
+		return id
+	}
+	manager := NewMemoryManager(idGen)
+
+	first := manager.CreateHook("example.com")
+	second := manager.CreateHook("example.com")
+
+	// The colliding ID is skipped and a new one drawn
+	if first.ID != "dup" || second.ID != "unique" {
+		t.Errorf("expected dup then unique, got %s then %s", first.ID, second.ID)
+	}
+
+	// Once the generator only yields taken IDs, creation fails instead of replacing a hook
+	if _, err := manager.CreateHookWithOptions("example.com", HookOptions{}); !errors.Is(err, ErrHookExists) {
+		t.Errorf("expected ErrHookExists, got %v", err)
+	}
+
+	if stats := manager.Stats(); stats.HooksActive != 2 {
+		t.Errorf("expected 2 hooks, got %d", stats.HooksActive)
+	}
+}
+
+func TestMemoryManager_PollMatching(t *testing.T) {
+	idGen := func() string { return "test123" }
+	manager := NewMemoryManager(idGen)
+	manager.CreateHook("example.com")
+
+	manager.AddInteraction("test123", DNSInteraction("int1", "1.2.3.4", "test.com", "A"))
+	manager.AddInteraction("test123", HTTPInteraction("int2", "1.2.3.4", "GET", "/", nil, ""))
+	manager.AddInteraction("test123", DNSInteraction("int3", "1.2.3.4", "test.com", "TXT"))
+
+	opts := PollOptions{Filter: &Filter{Types: []InteractionType{InteractionTypeDNS}}}
+
+	t.Run("peek returns matches and skips the rest", func(t *testing.T) {
+		interactions, cursor, _, _ := manager.PeekMatching("test123", 0, opts)
+		if len(interactions) != 2 || interactions[0].ID != "int1" || interactions[1].ID != "int3" {
+			t.Fatalf("expected int1 and int3, got %v", interactions)
+		}
+
+		if cursor != 3 {
+			t.Errorf("expected cursor 3, got %d", cursor)
+		}
+	})
+
+	t.Run("poll consumes only matches", func(t *testing.T) {
+		interactions, _, _ := manager.PollMatching("test123", opts)
+		if len(interactions) != 2 {
+			t.Fatalf("expected 2 interactions, got %d", len(interactions))
+		}
+
+		stats := manager.Stats()
+		if stats.InteractionsTotal != 1 || stats.InteractionsDNS != 0 || stats.InteractionsHTTP != 1 {
+			t.Errorf("unexpected counters after filtered poll: %+v", stats)
+		}
+
+		remaining, _ := manager.PollInteractions("test123")
+		if len(remaining) != 1 || remaining[0].ID != "int2" {
+			t.Errorf("expected int2 to remain, got %v", remaining)
+		}
+	})
+
+	t.Run("batch", func(t *testing.T) {
+		manager.AddInteraction("test123", HTTPInteraction("int4", "1.2.3.4", "GET", "/", nil, ""))
+
+		results := manager.PollMatchingBatch([]string{"test123", "missing"}, opts)
+		if len(results["test123"].Interactions) != 0 {
+			t.Errorf("expected no matching interactions, got %v", results["test123"].Interactions)
+		}
+
+		if results["missing"].Error != "Hook not found" {
+			t.Errorf("expected hook not found, got %q", results["missing"].Error)
+		}
+
+		if stats := manager.Stats(); stats.InteractionsTotal != 1 {
+			t.Errorf("expected non-matching interaction to stay queued, got %d", stats.InteractionsTotal)
+		}
+	})
+}
@@ -0,0 +1,180 @@
+package storage
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// Orphan is an interaction captured for a hook ID that was not registered,
+// e.g. a late callback to an expired hook or a mistyped ID
+type Orphan struct {
+	HookID string `json:"hook_id"` // the hook ID that was tried
+	*Interaction
+}
+
+// OrphanStore is a catch-all bucket for interactions to unknown hooks. It has
+// its own retention and size limits, independent of the eviction settings,
+// and is kept in memory only.
+type OrphanStore struct {
+	orphans   []*Orphan // oldest first
+	sizes     []int     // approximate size of each orphan, in bytes
+	bytes     int       // sum of sizes
+	retention time.Duration
+	maxSize   int
+	maxBytes  int
+	mu        sync.Mutex
+}
+
+// NewOrphanStore creates an orphan store keeping at most maxSize orphans,
+// taking at most maxBytes together, for at most retention
+func NewOrphanStore(retention time.Duration, maxSize, maxBytes int) *OrphanStore {
+	return &OrphanStore{
+		retention: retention,
+		maxSize:   maxSize,
+		maxBytes:  maxBytes,
+	}
+}
+
+// Add stores an orphan, dropping the oldest ones when the store is full.
+// An orphan larger than the whole byte budget is not stored.
+func (o *OrphanStore) Add(hookID string, interaction *Interaction) {
+	size := interactionSize(interaction)
+	if size > o.maxBytes {
+		return
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.expire(time.Now().UTC())
+
+	drop := 0
+	if len(o.orphans) >= o.maxSize {
+		drop = len(o.orphans) - o.maxSize + 1
+	}
+	for dropped := o.sumSizes(drop); o.bytes-dropped+size > o.maxBytes; drop++ {
+		dropped += o.sizes[drop]
+	}
+	o.dropOldest(drop)
+
+	o.orphans = append(o.orphans, &Orphan{HookID: hookID, Interaction: interaction})
+	o.sizes = append(o.sizes, size)
+	o.bytes += size
+}
+
+// Search returns the orphans matching the tried hook IDs (any when empty) and
+// the filter, newest first, at most limit of them when limit is positive
+func (o *OrphanStore) Search(hookIDs []string, filter *Filter, limit int) []*Orphan {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.expire(time.Now().UTC())
+
+	results := make([]*Orphan, 0)
+	for i := len(o.orphans) - 1; i >= 0; i-- {
+		orphan := o.orphans[i]
+		if len(hookIDs) > 0 && !slices.Contains(hookIDs, orphan.HookID) {
+			continue
+		}
+		if !filter.Match(orphan.Interaction) {
+			continue
+		}
+
+		results = append(results, orphan)
+		if limit > 0 && len(results) == limit {
+			break
+		}
+	}
+
+	return results
+}
+
+// Claim removes and returns the orphans that tried hookID or, when ids is not
+// empty, the orphans with those interaction IDs whatever ID they tried.
+// Orphans are returned oldest first.
+func (o *OrphanStore) Claim(hookID string, ids []string) []*Orphan {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.expire(time.Now().UTC())
+
+	var claimed []*Orphan
+	kept := 0
+	for i, orphan := range o.orphans {
+		match := orphan.HookID == hookID
+		if len(ids) > 0 {
+			match = slices.Contains(ids, orphan.ID)
+		}
+		if match {
+			claimed = append(claimed, orphan)
+			o.bytes -= o.sizes[i]
+			continue
+		}
+		o.orphans[kept], o.sizes[kept] = orphan, o.sizes[i]
+		kept++
+	}
+	clear(o.orphans[kept:])
+	o.orphans, o.sizes = o.orphans[:kept], o.sizes[:kept]
+
+	return claimed
+}
+
+// DropOldest drops up to n of the oldest orphans and returns how many were
+// dropped. It is used to free memory under pressure, orphans going before
+// registered hooks.
+func (o *OrphanStore) DropOldest(n int) int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	n = min(n, len(o.orphans))
+	o.dropOldest(n)
+	return n
+}
+
+// Len returns the number of orphans held
+func (o *OrphanStore) Len() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return len(o.orphans)
+}
+
+// Bytes returns the approximate size of the orphans held
+func (o *OrphanStore) Bytes() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.bytes
+}
+
+// expire drops orphans older than the retention (caller must hold o.mu)
+func (o *OrphanStore) expire(now time.Time) {
+	cutoff := now.Add(-o.retention)
+
+	expired := 0
+	for expired < len(o.orphans) && o.orphans[expired].Timestamp.Before(cutoff) {
+		expired++
+	}
+
+	o.dropOldest(expired)
+}
+
+// dropOldest drops the n oldest orphans (caller must hold o.mu)
+func (o *OrphanStore) dropOldest(n int) {
+	if n == 0 {
+		return
+	}
+	o.bytes -= o.sumSizes(n)
+	o.orphans = slices.Delete(o.orphans, 0, n)
+	o.sizes = slices.Delete(o.sizes, 0, n)
+}
+
+// sumSizes returns the size of the n oldest orphans (caller must hold o.mu)
+func (o *OrphanStore) sumSizes(n int) int {
+	var sum int
+	for _, size := range o.sizes[:n] {
+		sum += size
+	}
+	return sum
+}
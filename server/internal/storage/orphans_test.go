@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOrphanStore_Search(t *testing.T) {
+	orphans := NewOrphanStore(time.Hour, 10, 1<<20)
+	orphans.Add("expired1", DNSInteraction("int1", "1.2.3.4", "expired1.example.com", "A"))
+	orphans.Add("typo", HTTPInteraction("int2", "5.6.7.8", "GET", "/", nil, ""))
+	orphans.Add("expired1", HTTPInteraction("int3", "1.2.3.4", "POST", "/cb", nil, ""))
+
+	all := orphans.Search(nil, nil, 0)
+	if len(all) != 3 || all[0].ID != "int3" {
+		t.Fatalf("expected 3 orphans newest first, got %v", all)
+	}
+
+	byHook := orphans.Search([]string{"expired1"}, nil, 0)
+	if len(byHook) != 2 {
+		t.Errorf("expected 2 orphans for expired1, got %d", len(byHook))
+	}
+
+	byType := orphans.Search(nil, &Filter{Types: []InteractionType{InteractionTypeHTTP}}, 1)
+	if len(byType) != 1 || byType[0].ID != "int3" {
+		t.Errorf("expected newest http orphan only, got %v", byType)
+	}
+}
+
+func TestOrphanStore_Limits(t *testing.T) {
+	orphans := NewOrphanStore(time.Hour, 2, 1<<20)
+
+	old := DNSInteraction("old", "1.2.3.4", "a.example.com", "A")
+	old.Timestamp = time.Now().UTC().Add(-2 * time.Hour)
+	orphans.Add("a", old)
+
+	orphans.Add("b", DNSInteraction("int1", "1.2.3.4", "b.example.com", "A"))
+	if orphans.Len() != 1 {
+		t.Errorf("expected orphans past retention to be dropped, got %d", orphans.Len())
+	}
+
+	orphans.Add("c", DNSInteraction("int2", "1.2.3.4", "c.example.com", "A"))
+	orphans.Add("d", DNSInteraction("int3", "1.2.3.4", "d.example.com", "A"))
+
+	kept := orphans.Search(nil, nil, 0)
+	if len(kept) != 2 || kept[0].ID != "int3" || kept[1].ID != "int2" {
+		t.Errorf("expected the oldest orphan to be dropped when full, got %v", kept)
+	}
+}
+
+func TestOrphanStore_Claim(t *testing.T) {
+	orphans := NewOrphanStore(time.Hour, 10, 1<<20)
+	orphans.Add("abc123", DNSInteraction("int1", "1.2.3.4", "abc123.example.com", "A"))
+	orphans.Add("abc12", DNSInteraction("int2", "1.2.3.4", "abc12.example.com", "A"))
+	orphans.Add("abc123", DNSInteraction("int3", "1.2.3.4", "abc123.example.com", "TXT"))
+
+	claimed := orphans.Claim("abc123", nil)
+	if len(claimed) != 2 || claimed[0].ID != "int1" || claimed[1].ID != "int3" {
+		t.Fatalf("expected orphans of abc123 oldest first, got %v", claimed)
+	}
+
+	// Claiming by interaction ID takes orphans that tried another ID
+	claimed = orphans.Claim("abc123", []string{"int2"})
+	if len(claimed) != 1 || claimed[0].HookID != "abc12" {
+		t.Errorf("expected the mistyped orphan, got %v", claimed)
+	}
+
+	if orphans.Len() != 0 {
+		t.Errorf("expected claimed orphans to be removed, got %d", orphans.Len())
+	}
+}
+
+func TestOrphanStore_ByteBudget(t *testing.T) {
+	body := strings.Repeat("a", 1000)
+	size := interactionSize(HTTPInteraction("int0", "1.2.3.4", "POST", "/", nil, body))
+	orphans := NewOrphanStore(time.Hour, 100, 2*size)
+
+	for _, id := range []string{"int1", "int2", "int3"} {
+		orphans.Add("a", HTTPInteraction(id, "1.2.3.4", "POST", "/", nil, body))
+	}
+
+	kept := orphans.Search(nil, nil, 0)
+	if len(kept) != 2 || kept[0].ID != "int3" || kept[1].ID != "int2" {
+		t.Errorf("expected the oldest orphan to be dropped past the byte budget, got %v", kept)
+	}
+	if orphans.Bytes() != 2*size {
+		t.Errorf("expected %d bytes, got %d", 2*size, orphans.Bytes())
+	}
+
+	// An orphan larger than the whole budget is not stored
+	orphans.Add("a", HTTPInteraction("huge", "1.2.3.4", "POST", "/", nil, strings.Repeat("a", 3000)))
+	if orphans.Len() != 2 {
+		t.Errorf("expected an oversized orphan to be rejected, got %d orphans", orphans.Len())
+	}
+
+	orphans.Claim("a", []string{"int2"})
+	if orphans.Bytes() != size {
+		t.Errorf("expected claimed orphans to release their bytes, got %d", orphans.Bytes())
+	}
+}
+
+func TestOrphanStore_DropOldest(t *testing.T) {
+	orphans := NewOrphanStore(time.Hour, 100, 1<<20)
+	for _, id := range []string{"int1", "int2", "int3"} {
+		orphans.Add("a", DNSInteraction(id, "1.2.3.4", "a.example.com", "A"))
+	}
+
+	if dropped := orphans.DropOldest(2); dropped != 2 {
+		t.Errorf("expected 2 dropped, got %d", dropped)
+	}
+	kept := orphans.Search(nil, nil, 0)
+	if len(kept) != 1 || kept[0].ID != "int3" {
+		t.Errorf("expected the newest orphan to be kept, got %v", kept)
+	}
+
+	if dropped := orphans.DropOldest(5); dropped != 1 {
+		t.Errorf("expected the last orphan to be dropped, got %d", dropped)
+	}
+	if orphans.Bytes() != 0 {
+		t.Errorf("expected no bytes left, got %d", orphans.Bytes())
+	}
+}
@@ -0,0 +1,94 @@
+package storage
+
+// PollOptions filters and bounds a poll
+type PollOptions struct {
+	Filter *Filter
+
+	// Limit caps the number of interactions returned, 0 means no limit
+	Limit int
+
+	// MaxBytes caps the approximate encoded size of the interactions returned,
+	// 0 means no limit. At least one interaction is always returned so that an
+	// oversized interaction cannot block a hook forever.
+	MaxBytes int
+}
+
+// unbounded reports whether the options neither filter nor limit a poll
+func (o PollOptions) unbounded() bool {
+	return o.Filter.IsEmpty() && o.Limit <= 0 && o.MaxBytes <= 0
+}
+
+// pollBudget tracks how much a poll may still return.
+// A batch poll shares one budget across all its hooks.
+type pollBudget struct {
+	count int // interactions taken so far
+	bytes int // approximate size taken so far
+	limit int
+	max   int
+	full  bool // set once an interaction did not fit, keeping polls in order
+}
+
+// newPollBudget creates a budget from poll options
+func newPollBudget(opts PollOptions) *pollBudget {
+	return &pollBudget{limit: opts.Limit, max: opts.MaxBytes}
+}
+
+// take reserves room for an interaction and reports whether it fits
+func (b *pollBudget) take(interaction *Interaction) bool {
+	if b.full || (b.limit > 0 && b.count >= b.limit) {
+		b.full = true
+		return false
+	}
+
+	size := interactionSize(interaction)
+	if b.max > 0 && b.count > 0 && b.bytes+size > b.max {
+		b.full = true
+		return false
+	}
+
+	b.count++
+	b.bytes += size
+	return true
+}
+
+// interactionSize approximates the JSON-encoded size of an interaction
+func interactionSize(interaction *Interaction) int {
+	// Field names, type, timestamp and sequence number
+	const overhead = 128
+	return overhead + len(interaction.ID) + len(interaction.SourceIP) + valueSize(interaction.Data)
+}
+
+// valueSize approximates the JSON-encoded size of an interaction data value
+func valueSize(value interface{}) int {
+	switch v := value.(type) {
+	case string:
+		return len(v) + 2
+	case map[string]interface{}:
+		size := 2
+		for key, item := range v {
+			size += len(key) + 4 + valueSize(item)
+		}
+		return size
+	case map[string]string:
+		size := 2
+		for key, item := range v {
+			size += len(key) + len(item) + 6
+		}
+		return size
+	case []interface{}:
+		size := 2
+		for _, item := range v {
+			size += valueSize(item) + 1
+		}
+		return size
+	case []string:
+		size := 2
+		for _, item := range v {
+			size += len(item) + 3
+		}
+		return size
+	default:
+		// Numbers, booleans and null
+		return 8
+	}
+}
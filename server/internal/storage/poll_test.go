@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func newLimitTestManager(t *testing.T, count int) *MemoryManager {
+	t.Helper()
+
+	idGen := func() string { return "test123" }
+	manager := NewMemoryManager(idGen)
+	manager.CreateHook("example.com")
+
+	for i := 1; i <= count; i++ {
+		manager.AddInteraction("test123", DNSInteraction(fmt.Sprintf("int%d", i), "1.2.3.4", "test.com", "A"))
+	}
+
+	return manager
+}
+
+func TestMemoryManager_PollMatching_Limit(t *testing.T) {
+	manager := newLimitTestManager(t, 5)
+
+	interactions, hasMore, _ := manager.PollMatching("test123", PollOptions{Limit: 2})
+	if len(interactions) != 2 || interactions[0].ID != "int1" || interactions[1].ID != "int2" {
+		t.Fatalf("expected int1 and int2, got %v", interactions)
+	}
+
+	if !hasMore {
+		t.Error("expected has_more")
+	}
+
+	interactions, hasMore, _ = manager.PollMatching("test123", PollOptions{Limit: 3})
+	if len(interactions) != 3 || interactions[0].ID != "int3" {
+		t.Fatalf("expected int3 to int5, got %v", interactions)
+	}
+
+	if hasMore {
+		t.Error("expected no more interactions")
+	}
+}
+
+func TestMemoryManager_PollMatching_MaxBytes(t *testing.T) {
+	idGen := func() string { return "test123" }
+	manager := NewMemoryManager(idGen)
+	manager.CreateHook("example.com")
+
+	body := strings.Repeat("x", 1000)
+	for i := 1; i <= 3; i++ {
+		manager.AddInteraction("test123", HTTPInteraction(fmt.Sprintf("int%d", i), "1.2.3.4", "POST", "/", nil, body))
+	}
+
+	t.Run("stops before exceeding the size", func(t *testing.T) {
+		interactions, hasMore, _ := manager.PollMatching("test123", PollOptions{MaxBytes: 2500})
+		if len(interactions) != 2 || !hasMore {
+			t.Fatalf("expected 2 interactions and has_more, got %d, %v", len(interactions), hasMore)
+		}
+	})
+
+	t.Run("always returns one interaction", func(t *testing.T) {
+		interactions, hasMore, _ := manager.PollMatching("test123", PollOptions{MaxBytes: 10})
+		if len(interactions) != 1 || interactions[0].ID != "int3" || hasMore {
+			t.Fatalf("expected the oversized int3 alone, got %v, %v", interactions, hasMore)
+		}
+	})
+}
+
+func TestMemoryManager_PeekMatching_Limit(t *testing.T) {
+	manager := newLimitTestManager(t, 3)
+
+	interactions, cursor, hasMore, _ := manager.PeekMatching("test123", 0, PollOptions{Limit: 2})
+	if len(interactions) != 2 || cursor != 2 || !hasMore {
+		t.Fatalf("expected 2 interactions up to cursor 2 with has_more, got %d, %d, %v", len(interactions), cursor, hasMore)
+	}
+
+	interactions, cursor, hasMore, _ = manager.PeekMatching("test123", cursor, PollOptions{Limit: 2})
+	if len(interactions) != 1 || interactions[0].ID != "int3" || cursor != 3 || hasMore {
+		t.Fatalf("expected int3 up to cursor 3, got %v, %d, %v", interactions, cursor, hasMore)
+	}
+}
+
+func TestMemoryManager_PollMatchingBatch_SharedBudget(t *testing.T) {
+	counter := 0
+	idGen := func() string {
+		counter++
+		return fmt.Sprintf("hook%d", counter)
+	}
+	manager := NewMemoryManager(idGen)
+	first := manager.CreateHook("example.com")
+	second := manager.CreateHook("example.com")
+
+	for i := 0; i < 2; i++ {
+		manager.AddInteraction(first.ID, DNSInteraction(fmt.Sprintf("a%d", i), "1.2.3.4", "test.com", "A"))
+		manager.AddInteraction(second.ID, DNSInteraction(fmt.Sprintf("b%d", i), "1.2.3.4", "test.com", "A"))
+	}
+
+	results := manager.PollMatchingBatch([]string{first.ID, second.ID}, PollOptions{Limit: 3})
+
+	if len(results[first.ID].Interactions) != 2 || results[first.ID].HasMore {
+		t.Errorf("expected first hook to be drained, got %+v", results[first.ID])
+	}
+
+	if len(results[second.ID].Interactions) != 1 || !results[second.ID].HasMore {
+		t.Errorf("expected one interaction and has_more for second hook, got %+v", results[second.ID])
+	}
+
+	if stats := manager.Stats(); stats.InteractionsTotal != 1 {
+		t.Errorf("expected 1 interaction left queued, got %d", stats.InteractionsTotal)
+	}
+}
+
+func TestInteractionSize(t *testing.T) {
+	small := DNSInteraction("int1", "1.2.3.4", "test.com", "A")
+	large := HTTPInteraction("int2", "1.2.3.4", "POST", "/", map[string]string{"User-Agent": "curl"}, strings.Repeat("x", 10000))
+
+	if size := interactionSize(small); size < 128 || size > 512 {
+		t.Errorf("unexpected size for a DNS interaction: %d", size)
+	}
+
+	if size := interactionSize(large); size < 10000 {
+		t.Errorf("expected body to be counted, got %d", size)
+	}
+}
@@ -11,6 +11,77 @@ type Hook struct {
 	HTTP      string    `json:"http"`
 	HTTPS     string    `json:"https"`
 	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at,omitzero"` // set when the hook is renewed
+
+	TTL         int               `json:"ttl,omitempty"` // seconds, overrides eviction.hook_ttl
+	Labels      map[string]string `json:"labels,omitempty"`
+	Description string            `json:"description,omitempty"`
+
+	DNSRecords []DNSRecord   `json:"dns_records,omitempty"` // custom answers replacing the defaults
+	Rebind     *RebindConfig `json:"rebind,omitempty"`      // DNS rebinding strategy for A/AAAA answers
+	Exfil      *ExfilConfig  `json:"exfil,omitempty"`       // decoding of payloads exfiltrated through DNS labels
+
+	HTTPResponses []HTTPResponse `json:"http_responses,omitempty"` // responses served instead of an empty 200
+}
+
+// DNSRecord is a custom DNS answer served for a hook name or one of its sub-labels
+type DNSRecord struct {
+	Name     string `json:"name,omitempty"`     // sub-label in front of the hook, "" for the hook itself, "*" for any
+	Type     string `json:"type"`               // A, AAAA, CNAME, TXT, MX or CAA
+	Value    string `json:"value"`              // CAA values are "<flags> <tag> <value>"
+	Priority int    `json:"priority,omitempty"` // MX preference
+	TTL      int    `json:"ttl,omitempty"`      // seconds, the server default if zero
+}
+
+// Rebinding modes, selecting which answer of a RebindConfig is served
+const (
+	RebindModeCount      = "count"       // move to the next answer every Count queries
+	RebindModeTime       = "time"        // move to the next answer every Interval seconds after the first query
+	RebindModeRoundRobin = "round_robin" // cycle through the answers on every query
+)
+
+// RebindConfig makes a hook's A/AAAA answers change between queries
+type RebindConfig struct {
+	Mode     string   `json:"mode"`
+	Answers  []string `json:"answers"`            // IP addresses, "self" for the server's own address
+	Count    int      `json:"count,omitempty"`    // queries per answer in count mode
+	Interval int      `json:"interval,omitempty"` // seconds per answer in time mode
+	TTL      int      `json:"ttl"`                // seconds, usually 0 or 1
+}
+
+// Exfiltration encodings of the chunks carried in DNS labels
+const (
+	ExfilEncodingHex       = "hex"
+	ExfilEncodingBase32    = "base32"    // RFC 4648, padding optional
+	ExfilEncodingBase64URL = "base64url" // RFC 4648 URL-safe alphabet, padding optional
+)
+
+// ExfilConfig makes a hook collect the chunks of a payload exfiltrated through
+// DNS queries of the form <seq>.<chunk>[.<chunk>...].<hook>
+type ExfilConfig struct {
+	Encoding string `json:"encoding"`
+}
+
+// HTTPResponse is a response served to the HTTP requests of a hook that match
+// its method and path. The first matching response of a hook is served
+type HTTPResponse struct {
+	Method      string            `json:"method,omitempty"`       // "" matches any method
+	Path        string            `json:"path,omitempty"`         // "" matches any path, a trailing "*" matches a prefix
+	Status      int               `json:"status"`                 // 200 if zero
+	Headers     map[string]string `json:"headers,omitempty"`      // e.g. Location or WWW-Authenticate
+	ContentType string            `json:"content_type,omitempty"` // overrides a Content-Type header
+	Body        string            `json:"body,omitempty"`
+	BodyBase64  string            `json:"body_base64,omitempty"` // binary body, exclusive with body
+	DelayMS     int               `json:"delay_ms,omitempty"`    // wait before responding
+}
+
+// HookOptions holds the optional settings of a new hook
+type HookOptions struct {
+	ID          string // requested ID, generated when empty
+	Prefix      string // prepended to the generated ID as "prefix-"
+	TTL         time.Duration
+	Labels      map[string]string
+	Description string
 }
 
 // InteractionType represents the type of interaction
@@ -24,9 +95,11 @@ const (
 // Interaction represents a captured DNS or HTTP interaction
 type Interaction struct {
 	ID        string                 `json:"id"`
+	Seq       uint64                 `json:"seq"`
 	Type      InteractionType        `json:"type"`
 	Timestamp time.Time              `json:"timestamp"`
 	SourceIP  string                 `json:"source_ip"`
+	Tags      []string               `json:"tags,omitempty"` // labels left of the hook ID, e.g. a payload identifier
 	Data      map[string]interface{} `json:"data"`
 }
 
@@ -41,6 +114,7 @@ type MemoryStats struct {
 // PollResult represents the result of polling a single hook
 type PollResult struct {
 	Interactions []*Interaction `json:"interactions"`
+	HasMore      bool           `json:"has_more,omitempty"` // matching interactions left queued by a limit
 	Error        string         `json:"error,omitempty"`
 }
 
@@ -0,0 +1,156 @@
+package storage
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+)
+
+// maxWALBatch bounds the lines written by a single write call
+const maxWALBatch = 256
+
+// walRequest is a log line queued for the writer, or a compacted log to
+// install in place of the current one
+type walRequest struct {
+	line    []byte
+	install *walInstall
+	done    chan error
+}
+
+// walInstall replaces the log with a written snapshot followed by the lines
+// appended since the snapshot was captured
+type walInstall struct {
+	snapshot *os.File
+	path     string
+	backlog  [][]byte
+}
+
+// walWriter owns the log file and writes to it from a single goroutine.
+// Mutations only hold the manager lock to queue their line, which keeps the
+// log in mutation order without holding the lock across the write. Lines
+// queued while a write is in flight are written together by the next one.
+type walWriter struct {
+	file     *os.File
+	requests chan walRequest
+	stopped  chan error
+	logger   *slog.Logger
+}
+
+func newWALWriter(logger *slog.Logger) *walWriter {
+	w := &walWriter{
+		requests: make(chan walRequest, maxWALBatch),
+		stopped:  make(chan error, 1),
+		logger:   logger,
+	}
+	go w.run()
+	return w
+}
+
+// run serves requests until the queue is closed, then syncs and closes the log
+func (w *walWriter) run() {
+	var carry *walRequest
+	for {
+		req, ok := walRequest{}, true
+		if carry != nil {
+			req, carry = *carry, nil
+		} else if req, ok = <-w.requests; !ok {
+			break
+		}
+
+		if req.install != nil {
+			req.done <- w.replace(req.install)
+			continue
+		}
+
+		batch := []walRequest{req}
+	collect:
+		for len(batch) < maxWALBatch {
+			select {
+			case next, ok := <-w.requests:
+				if !ok {
+					break collect
+				}
+				if next.install != nil {
+					carry = &next
+					break collect
+				}
+				batch = append(batch, next)
+			default:
+				break collect
+			}
+		}
+
+		err := w.write(batch)
+		for _, req := range batch {
+			req.done <- err
+		}
+	}
+
+	w.stopped <- w.close()
+}
+
+// write appends a batch of lines. A failed write is truncated away, so the
+// lines appended next do not follow a torn entry that would stop the replay.
+func (w *walWriter) write(batch []walRequest) error {
+	if w.file == nil {
+		return fmt.Errorf("wal is closed")
+	}
+
+	offset, err := w.file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return fmt.Errorf("failed to write wal entry: %w", err)
+	}
+
+	var buf []byte
+	for _, req := range batch {
+		buf = append(buf, req.line...)
+	}
+
+	if _, err := w.file.Write(buf); err != nil {
+		if terr := w.file.Truncate(offset); terr != nil {
+			w.logger.Error("failed to truncate partial wal write", "error", terr)
+		}
+		return fmt.Errorf("failed to write wal entry: %w", err)
+	}
+	return nil
+}
+
+// replace appends the backlog to a written snapshot and makes it the log. The
+// old log is only closed once replaced.
+func (w *walWriter) replace(in *walInstall) error {
+	buf := bufio.NewWriter(in.snapshot)
+	for _, line := range in.backlog {
+		if _, err := buf.Write(line); err != nil {
+			return fmt.Errorf("failed to write wal snapshot: %w", err)
+		}
+	}
+	if err := buf.Flush(); err != nil {
+		return fmt.Errorf("failed to write wal snapshot: %w", err)
+	}
+
+	if err := os.Rename(in.snapshot.Name(), in.path); err != nil {
+		return fmt.Errorf("failed to replace wal: %w", err)
+	}
+
+	if w.file != nil {
+		w.file.Close()
+	}
+	w.file = in.snapshot
+	return nil
+}
+
+// close flushes the log to disk and closes it
+func (w *walWriter) close() error {
+	if w.file == nil {
+		return nil
+	}
+
+	if err := w.file.Sync(); err != nil {
+		w.file.Close()
+		return fmt.Errorf("failed to sync wal: %w", err)
+	}
+
+	return w.file.Close()
+}
@@ -4,25 +4,169 @@ import "time"
 
 // Hook represents a registered hook (public API type)
 type Hook struct {
-	ID        string    `json:"id"`
-	DNS       string    `json:"dns"`
-	HTTP      string    `json:"http"`
-	HTTPS     string    `json:"https"`
-	CreatedAt time.Time `json:"created_at"`
+	ID            string            `json:"id"`
+	DNS           string            `json:"dns"`
+	HTTP          string            `json:"http"`
+	HTTPS         string            `json:"https"`
+	CreatedAt     time.Time         `json:"created_at"`
+	TTL           int               `json:"ttl,omitempty"` // seconds
+	Labels        map[string]string `json:"labels,omitempty"`
+	Description   string            `json:"description,omitempty"`
+	DNSRecords    []DNSRecord       `json:"dns_records,omitempty"`
+	Rebind        *RebindConfig     `json:"rebind,omitempty"`
+	Exfil         *ExfilConfig      `json:"exfil,omitempty"`
+	HTTPResponses []HTTPResponse    `json:"http_responses,omitempty"`
+}
+
+// DNSRecord represents a custom DNS answer of a hook, set with PUT /hooks/:id/dns
+type DNSRecord struct {
+	Name     string `json:"name,omitempty"`     // sub-label in front of the hook, "" for the hook itself, "*" for any
+	Type     string `json:"type"`               // A, AAAA, CNAME, TXT, MX or CAA
+	Value    string `json:"value"`              // CAA values are "<flags> <tag> <value>"
+	Priority int    `json:"priority,omitempty"` // MX preference
+	TTL      int    `json:"ttl,omitempty"`      // seconds
+}
+
+// DNSRecordsRequest represents the request body for PUT /hooks/:id/dns
+// and the response of the /hooks/:id/dns endpoints
+type DNSRecordsRequest struct {
+	Records []DNSRecord `json:"records"`
+}
+
+// RebindConfig represents the DNS rebinding strategy of a hook, set with
+// PUT /hooks/:id/rebind and returned by the /hooks/:id/rebind endpoints as
+// {"rebind": ...}
+type RebindConfig struct {
+	Mode     string   `json:"mode"`               // count, time or round_robin
+	Answers  []string `json:"answers"`            // IP addresses, "self" for the server's own address
+	Count    int      `json:"count,omitempty"`    // queries per answer in count mode
+	Interval int      `json:"interval,omitempty"` // seconds per answer in time mode
+	TTL      int      `json:"ttl"`                // seconds
+}
+
+// ExfilConfig represents the request body for PUT /hooks/:id/exfil
+type ExfilConfig struct {
+	Encoding string `json:"encoding"` // hex, base32 or base64url
+}
+
+// ExfilPayload represents the response from GET /hooks/:id/exfil
+type ExfilPayload struct {
+	Encoding string `json:"encoding"`
+	Chunks   int    `json:"chunks"`
+	FirstSeq int    `json:"first_seq"`
+	LastSeq  int    `json:"last_seq"`
+	Missing  []int  `json:"missing"`
+	Data     []byte `json:"data"`
+	Text     string `json:"text,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// HTTPResponse represents a response served to the HTTP requests of a hook,
+// set with PUT /hooks/:id/response. The first matching response is served
+type HTTPResponse struct {
+	Method      string            `json:"method,omitempty"` // "" matches any method
+	Path        string            `json:"path,omitempty"`   // "" matches any path, a trailing "*" matches a prefix
+	Status      int               `json:"status"`           // 200 if zero
+	Headers     map[string]string `json:"headers,omitempty"`
+	ContentType string            `json:"content_type,omitempty"`
+	Body        string            `json:"body,omitempty"`
+	BodyBase64  string            `json:"body_base64,omitempty"` // exclusive with body
+	DelayMS     int               `json:"delay_ms,omitempty"`    // at most 60000
+}
+
+// HTTPResponsesRequest represents the request body for PUT /hooks/:id/response
+// and the response of the /hooks/:id/response endpoints
+type HTTPResponsesRequest struct {
+	Responses []HTTPResponse `json:"responses"`
+}
+
+// HookInfo represents a hook with its lifecycle state, returned by /hooks endpoints
+type HookInfo struct {
+	Hook
+	ExpiresAt           time.Time `json:"expires_at"`
+	PendingInteractions int       `json:"pending_interactions"`
+}
+
+// HookListResponse represents the response from GET /hooks
+type HookListResponse struct {
+	Hooks  []HookInfo `json:"hooks"`
+	Total  int        `json:"total"`
+	Limit  int        `json:"limit"`
+	Offset int        `json:"offset"`
+}
+
+// RenewRequest represents the optional request body for /hooks/:id/renew
+type RenewRequest struct {
+	TTL string `json:"ttl,omitempty"`
 }
 
 // Interaction represents a captured interaction (public API type)
 type Interaction struct {
 	ID        string                 `json:"id"`
+	Seq       uint64                 `json:"seq"`
 	Type      string                 `json:"type"`
 	Timestamp time.Time              `json:"timestamp"`
 	SourceIP  string                 `json:"source_ip"`
+	Tags      []string               `json:"tags,omitempty"`
 	Data      map[string]interface{} `json:"data"`
 }
 
+// Orphan represents an interaction for an unknown or expired hook,
+// returned by GET /orphans
+type Orphan struct {
+	HookID string `json:"hook_id"` // the hook ID that was tried
+	Interaction
+}
+
+// OrphanListResponse represents the response from GET /orphans
+type OrphanListResponse struct {
+	Orphans []Orphan `json:"orphans"`
+	Total   int      `json:"total"`
+}
+
+// ClaimOrphansRequest represents the request body for POST /orphans/claim
+type ClaimOrphansRequest struct {
+	HookID string   `json:"hook_id"`
+	IDs    []string `json:"ids,omitempty"`
+}
+
 // PollResponse represents the response from /poll/:id
+// Cursor is only set when polling with ?cursor=
+// HasMore is set when ?limit= or ?max_bytes= left matching interactions queued
 type PollResponse struct {
 	Interactions []Interaction `json:"interactions"`
+	Cursor       uint64        `json:"cursor,omitempty"`
+	HasMore      bool          `json:"has_more"`
+}
+
+// PollResult represents the result for one hook in a batch poll
+type PollResult struct {
+	Interactions []Interaction `json:"interactions"`
+	HasMore      bool          `json:"has_more,omitempty"`
+	Error        string        `json:"error,omitempty"`
+}
+
+// PollBatchResponse represents the response from /poll
+type PollBatchResponse struct {
+	Results map[string]PollResult `json:"results"`
+}
+
+// PollBatchRequest represents the object form of the /poll request body
+// A plain JSON array of hook IDs is also accepted
+type PollBatchRequest struct {
+	HookIDs []string `json:"hook_ids"`
+	Wait    string   `json:"wait,omitempty"`
+}
+
+// AckRequest represents the request body for /poll/:id/ack
+type AckRequest struct {
+	Cursor uint64 `json:"cursor"`
+}
+
+// AckResponse represents the response from /poll/:id/ack
+type AckResponse struct {
+	Acknowledged int    `json:"acknowledged"`
+	Cursor       uint64 `json:"cursor"`
 }
 
 // ErrorResponse represents an error response
@@ -31,8 +175,14 @@ type ErrorResponse struct {
 }
 
 // RegisterRequest represents the request body for /register
+// TTL ("72h" or seconds) is capped by eviction.hook_ttl
 type RegisterRequest struct {
-	Count int `json:"count,omitempty"`
+	Count       int               `json:"count,omitempty"`
+	ID          string            `json:"id,omitempty"`     // requested hook ID, a DNS label
+	Prefix      string            `json:"prefix,omitempty"` // generated IDs become "prefix-<id>"
+	TTL         string            `json:"ttl,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Description string            `json:"description,omitempty"`
 }
 
 // RegisterResponse represents the response from /register
@@ -40,11 +190,14 @@ type RegisterRequest struct {
 // For multiple hooks (count>1), returns Hooks array
 type RegisterResponse struct {
 	// Single hook response fields (when count=1 or omitted)
-	ID        string    `json:"id,omitempty"`
-	DNS       string    `json:"dns,omitempty"`
-	HTTP      string    `json:"http,omitempty"`
-	HTTPS     string    `json:"https,omitempty"`
-	CreatedAt time.Time `json:"created_at,omitempty"`
+	ID          string            `json:"id,omitempty"`
+	DNS         string            `json:"dns,omitempty"`
+	HTTP        string            `json:"http,omitempty"`
+	HTTPS       string            `json:"https,omitempty"`
+	CreatedAt   time.Time         `json:"created_at,omitempty"`
+	TTL         int               `json:"ttl,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Description string            `json:"description,omitempty"`
 
 	// Multiple hooks response (when count>1)
 	Hooks []Hook `json:"hooks,omitempty"`
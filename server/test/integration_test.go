@@ -41,6 +41,7 @@ func setupTestServer(t *testing.T) *testServer {
 	cfg.Server.Domain = "hookd.test.local"
 	cfg.Server.DNS.Enabled = true
 	cfg.Server.DNS.Port = 15353 // Use non-privileged port for testing
+	cfg.Server.DNS.PublicIP = "127.0.0.1"
 	cfg.Server.HTTP.Port = 18080
 	cfg.Server.HTTPS.Enabled = false
 	cfg.Server.API.AuthToken = "test-token-123"
@@ -66,8 +67,7 @@ func setupTestServer(t *testing.T) *testServer {
 
 	// Start DNS server
 	dnsServer, err := dnsserver.NewServer(
-		cfg.Server.Domain,
-		cfg.Server.DNS.Port,
+		cfg.Server,
 		storageManager,
 		acmeProvider,
 		logger,